@@ -11,4 +11,7 @@ require (
 	github.com/hashicorp/terraform-plugin-framework v0.1.1-0.20210721014642-f89b01bbbf40
 	github.com/hashicorp/terraform-plugin-go v0.3.1
 	github.com/hashicorp/terraform-plugin-log v0.9.0
+	github.com/prometheus/client_golang v1.12.2
+	google.golang.org/protobuf v1.27.1 // indirect
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -2,51 +2,257 @@ package api
 
 import (
 	"net/http"
+	"path"
+	"time"
 
 	"darlinggo.co/api"
 	"darlinggo.co/trout/v2"
 )
 
 type API struct {
-	Storer *Storer
+	Storer     *Storer
+	Chaos      *Chaos
+	Auth       *Auth
+	Metrics    *Metrics
+	Audit      *AuditLog
+	Webhooks   *Webhooks
+	Limiter    *RateLimiter
+	Operations *Operations
+	Upgrades   *Upgrades
+	Migrations *Migrations
+	Autoscaler *Autoscaler
+	Placement  *Placement
+	Scheduler  *Scheduler
+
+	// Retention is how long deleted resources are kept, and can be
+	// restored, before they're purged. If it's 0, deletes are permanent.
+	Retention time.Duration
+
+	// RevisionRetention is how long revisions are kept before they're
+	// pruned. Each resource's latest revision is kept while it exists. If
+	// it's 0, revisions are kept forever.
+	RevisionRetention time.Duration
+
+	// MaxWait caps how long a blocking query can wait for a change. It
+	// defaults to 5 minutes.
+	MaxWait time.Duration
+
+	// ValidateRequests rejects requests that don't match the OpenAPI
+	// document before they reach a handler.
+	ValidateRequests bool
+
+	// VersionPolicies sets when each version of the API is deprecated and
+	// sunset, keyed by version name. Versions without one are current.
+	VersionPolicies map[string]VersionPolicy
+}
+
+// route is a single method on a pattern served by the main, authenticated
+// JSON router.
+type route struct {
+	method  string
+	pattern string
+	handler http.Handler
+}
+
+func (a API) routes() []route {
+	routes := []route{
+		{http.MethodGet, "/eastores", http.HandlerFunc(a.handleListEAStores)},
+		{http.MethodPost, "/eastores", http.HandlerFunc(a.handlePostEAStore)},
+		{http.MethodGet, "/eastores/{id}", http.HandlerFunc(a.handleGetEAStore)},
+		{http.MethodPost, "/eastores/{id}", http.HandlerFunc(a.handleRestoreEAStore)},
+		{http.MethodPut, "/eastores/{id}", http.HandlerFunc(a.handlePutEAStore)},
+		{http.MethodDelete, "/eastores/{id}", http.HandlerFunc(a.handleDeleteEAStore)},
+		{http.MethodGet, "/eastores/{id}/revisions", a.handleListRevisions("eastore")},
+
+		{http.MethodGet, "/ehsclusters", http.HandlerFunc(a.handleListEHSClusters)},
+		{http.MethodPost, "/ehsclusters", http.HandlerFunc(a.handlePostEHSCluster)},
+		{http.MethodGet, "/ehsclusters/{id}", http.HandlerFunc(a.handleGetEHSCluster)},
+		{http.MethodPost, "/ehsclusters/{id}", http.HandlerFunc(a.handleRestoreEHSCluster)},
+		{http.MethodPut, "/ehsclusters/{id}", http.HandlerFunc(a.handlePutEHSCluster)},
+		{http.MethodDelete, "/ehsclusters/{id}", http.HandlerFunc(a.handleDeleteEHSCluster)},
+		{http.MethodGet, "/ehsclusters/{id}/revisions", a.handleListRevisions("ehscluster")},
+		{http.MethodGet, "/ehsclusters/{id}/nodepools", http.HandlerFunc(a.handleListNodePools)},
+		{http.MethodPost, "/ehsclusters/{id}/nodepools", http.HandlerFunc(a.handlePostNodePool)},
+		{http.MethodGet, "/ehsclusters/{id}/nodepools/{pool}", http.HandlerFunc(a.handleGetNodePool)},
+		{http.MethodPut, "/ehsclusters/{id}/nodepools/{pool}", http.HandlerFunc(a.handlePutNodePool)},
+		{http.MethodDelete, "/ehsclusters/{id}/nodepools/{pool}", http.HandlerFunc(a.handleDeleteNodePool)},
+
+		{http.MethodGet, "/aws", http.HandlerFunc(a.handleListAWs)},
+		{http.MethodPost, "/aws", http.HandlerFunc(a.handlePostAW)},
+		{http.MethodGet, "/aws/{id}", http.HandlerFunc(a.handleGetAW)},
+		{http.MethodPost, "/aws/{id}", http.HandlerFunc(a.handleRestoreAW)},
+		{http.MethodPut, "/aws/{id}", http.HandlerFunc(a.handlePutAW)},
+		{http.MethodDelete, "/aws/{id}", http.HandlerFunc(a.handleDeleteAW)},
+		{http.MethodGet, "/aws/{id}/revisions", a.handleListRevisions("aw")},
+
+		{http.MethodGet, "/avs", http.HandlerFunc(a.handleListAVs)},
+		{http.MethodPost, "/avs", http.HandlerFunc(a.handlePostAV)},
+		{http.MethodGet, "/avs/{id}", http.HandlerFunc(a.handleGetAV)},
+		{http.MethodPost, "/avs/{id}", http.HandlerFunc(a.handleRestoreAV)},
+		{http.MethodPut, "/avs/{id}", http.HandlerFunc(a.handlePutAV)},
+		{http.MethodDelete, "/avs/{id}", http.HandlerFunc(a.handleDeleteAV)},
+		{http.MethodGet, "/avs/{id}/revisions", a.handleListRevisions("av")},
+
+		{http.MethodGet, "/quotas", http.HandlerFunc(a.handleListQuotas)},
+
+		{http.MethodGet, "/catalog/releases", http.HandlerFunc(a.handleListReleases)},
+		{http.MethodGet, "/catalog/regions", http.HandlerFunc(a.handleListRegions)},
+		{http.MethodGet, "/catalog/profiles", http.HandlerFunc(a.handleListProfiles)},
+		{http.MethodGet, "/catalog/instancesizes", http.HandlerFunc(a.handleListInstanceSizes)},
+	}
+	if a.Webhooks != nil {
+		routes = append(routes, []route{
+			{http.MethodGet, "/webhooks", http.HandlerFunc(a.handleListWebhooks)},
+			{http.MethodPost, "/webhooks", http.HandlerFunc(a.handlePostWebhook)},
+			{http.MethodGet, "/webhooks/{id}", http.HandlerFunc(a.handleGetWebhook)},
+			{http.MethodPost, "/webhooks/{id}", http.HandlerFunc(a.handlePingWebhook)},
+			{http.MethodPut, "/webhooks/{id}", http.HandlerFunc(a.handlePutWebhook)},
+			{http.MethodDelete, "/webhooks/{id}", http.HandlerFunc(a.handleDeleteWebhook)},
+			{http.MethodGet, "/webhooks/{id}/deliveries", http.HandlerFunc(a.handleListDeliveries)},
+		}...)
+	}
+	if a.Audit != nil {
+		routes = append(routes, route{http.MethodGet, "/audit", http.HandlerFunc(a.handleListAudit)})
+	}
+	if a.Operations != nil {
+		routes = append(routes, []route{
+			{http.MethodGet, "/operations", http.HandlerFunc(a.handleListOperations)},
+			{http.MethodGet, "/operations/{id}", http.HandlerFunc(a.handleGetOperation)},
+			{http.MethodPost, "/operations/{id}", http.HandlerFunc(a.handleCancelOperation)},
+		}...)
+	}
+	if a.Upgrades != nil {
+		routes = append(routes, []route{
+			{http.MethodGet, "/upgrades", http.HandlerFunc(a.handleListUpgrades)},
+			{http.MethodGet, "/upgrades/{id}", http.HandlerFunc(a.handleGetUpgrade)},
+			{http.MethodPost, "/upgrades/{id}", http.HandlerFunc(a.handleControlUpgrade)},
+		}...)
+	}
+	if a.Migrations != nil {
+		routes = append(routes, []route{
+			{http.MethodGet, "/migrations", http.HandlerFunc(a.handleListMigrations)},
+			{http.MethodGet, "/migrations/{id}", http.HandlerFunc(a.handleGetMigration)},
+		}...)
+	}
+	if a.Scheduler != nil {
+		routes = append(routes, []route{
+			{http.MethodGet, "/awschedules", http.HandlerFunc(a.handleListAWSchedules)},
+			{http.MethodPost, "/awschedules", http.HandlerFunc(a.handlePostAWSchedule)},
+			{http.MethodGet, "/awschedules/{id}", http.HandlerFunc(a.handleGetAWSchedule)},
+			{http.MethodPut, "/awschedules/{id}", http.HandlerFunc(a.handlePutAWSchedule)},
+			{http.MethodDelete, "/awschedules/{id}", http.HandlerFunc(a.handleDeleteAWSchedule)},
+		}...)
+	}
+	return routes
 }
 
 func (a API) Server(baseURL string) http.Handler {
 	var router trout.Router
 	router.SetPrefix(baseURL)
+	var routes []string
+	endpoint := func(pattern string) *trout.Endpoint {
+		routes = append(routes, pattern)
+		return router.Endpoint(pattern)
+	}
 
-	router.Endpoint("/eastores").Methods(http.MethodPost).Handler(http.HandlerFunc(a.handlePostEAStore))
-	router.Endpoint("/eastores/{id}").Methods(http.MethodGet).Handler(http.HandlerFunc(a.handleGetEAStore))
-	router.Endpoint("/eastores/{id}").Methods(http.MethodPut).Handler(http.HandlerFunc(a.handlePutEAStore))
-	router.Endpoint("/eastores/{id}").Methods(http.MethodDelete).Handler(http.HandlerFunc(a.handleDeleteEAStore))
+	for _, rt := range a.routes() {
+		endpoint(rt.pattern).Methods(rt.method).Handler(rt.handler)
+	}
 
-	router.Endpoint("/ehsclusters").Methods(http.MethodPost).Handler(http.HandlerFunc(a.handlePostEHSCluster))
-	router.Endpoint("/ehsclusters/{id}").Methods(http.MethodGet).Handler(http.HandlerFunc(a.handleGetEHSCluster))
-	router.Endpoint("/ehsclusters/{id}").Methods(http.MethodPut).Handler(http.HandlerFunc(a.handlePutEHSCluster))
-	router.Endpoint("/ehsclusters/{id}").Methods(http.MethodDelete).Handler(http.HandlerFunc(a.handleDeleteEHSCluster))
+	var ops trout.Router
+	ops.SetPrefix(baseURL)
+	ops.Endpoint("/healthz").Methods(http.MethodGet).Handler(http.HandlerFunc(a.handleHealthz))
+	ops.Endpoint("/readyz").Methods(http.MethodGet).Handler(http.HandlerFunc(a.handleReadyz))
+	ops.Endpoint("/capabilities").Methods(http.MethodGet).Handler(http.HandlerFunc(a.handleCapabilities))
 
-	router.Endpoint("/aws").Methods(http.MethodPost).Handler(http.HandlerFunc(a.handlePostAW))
-	router.Endpoint("/aws/{id}").Methods(http.MethodGet).Handler(http.HandlerFunc(a.handleGetAW))
-	router.Endpoint("/aws/{id}").Methods(http.MethodPut).Handler(http.HandlerFunc(a.handlePutAW))
-	router.Endpoint("/aws/{id}").Methods(http.MethodDelete).Handler(http.HandlerFunc(a.handleDeleteAW))
+	var admin trout.Router
+	admin.SetPrefix(baseURL)
 
-	router.Endpoint("/avs").Methods(http.MethodPost).Handler(http.HandlerFunc(a.handlePostAV))
-	router.Endpoint("/avs/{id}").Methods(http.MethodGet).Handler(http.HandlerFunc(a.handleGetAV))
-	router.Endpoint("/avs/{id}").Methods(http.MethodPut).Handler(http.HandlerFunc(a.handlePutAV))
-	router.Endpoint("/avs/{id}").Methods(http.MethodDelete).Handler(http.HandlerFunc(a.handleDeleteAV))
+	// The event stream isn't JSON, so it skips content negotiation.
+	var events trout.Router
+	events.SetPrefix(baseURL)
+	events.Endpoint("/events").Methods(http.MethodGet).Handler(http.HandlerFunc(a.handleEvents))
+	var eventsHandler http.Handler = events
 
-	return api.NegotiateMiddleware(router)
-}
+	doc := a.OpenAPI(baseURL)
+	handler := a.indexMiddleware(api.NegotiateMiddleware(router))
+	adminHandler := api.NegotiateMiddleware(admin)
+	if a.ValidateRequests {
+		handler = validationMiddleware(baseURL, doc, handler)
+		adminHandler = validationMiddleware(baseURL, doc, adminHandler)
+	}
+	if a.Audit != nil {
+		handler = a.auditMiddleware(baseURL, handler)
+	}
+	if a.Operations != nil {
+		// Chaos applies to the replayed change as well as the 202, so
+		// async creates lag and fail like synchronous ones.
+		replay := handler
+		if a.Chaos != nil {
+			replay = a.Chaos.Middleware(baseURL, handler)
+		}
+		handler = a.Operations.Middleware(baseURL, handler, replay)
+	}
+	if a.Chaos != nil {
+		admin.Endpoint("/admin/chaos").Methods(http.MethodGet).Handler(http.HandlerFunc(a.handleGetChaos))
+		admin.Endpoint("/admin/chaos").Methods(http.MethodPut).Handler(http.HandlerFunc(a.handlePutChaos))
+		handler = a.Chaos.Middleware(baseURL, handler)
+	}
+	if a.Limiter != nil {
+		handler = a.Limiter.Middleware(baseURL, routes, handler)
+	}
+	if a.Auth != nil {
+		handler = a.Auth.Middleware(handler)
+		adminHandler = a.Auth.Middleware(adminHandler)
+		eventsHandler = a.Auth.Middleware(eventsHandler)
+	}
+	if a.Metrics != nil {
+		handler = a.Metrics.Middleware(baseURL, routes, handler)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(path.Join("/", baseURL, "healthz"), api.NegotiateMiddleware(ops))
+	mux.Handle(path.Join("/", baseURL, "readyz"), api.NegotiateMiddleware(ops))
+	mux.Handle(path.Join("/", baseURL, "openapi.json"), a.handleOpenAPI(doc))
+	if a.Metrics != nil {
+		mux.Handle(path.Join("/", baseURL, "metrics"), a.Metrics.Handler())
+	}
+	mux.Handle(path.Join("/", baseURL, "capabilities"), api.NegotiateMiddleware(ops))
+	mux.Handle(path.Join("/", baseURL, "admin")+"/", adminHandler)
 
-func isAuthenticated(r *http.Request) bool {
-	return r.Header.Get("Authentication") == "secrettoken"
+	// Every version is served by the same routes, with its prefix stripped.
+	versioned := http.NewServeMux()
+	versioned.Handle(path.Join("/", baseURL, "events"), eventsHandler)
+	versioned.Handle("/", handler)
+	for _, version := range Versions {
+		if version == LegacyVersion {
+			mux.Handle("/", a.versionMiddleware(baseURL, version, versioned))
+			continue
+		}
+		mux.Handle(path.Join("/", baseURL, versionPath(version))+"/", a.versionMiddleware(baseURL, version, versioned))
+	}
+	return mux
 }
 
 type Response struct {
-	Errors      []api.RequestError `json:"errors,omitempty"`
-	Status      int                `json:"-"`
-	EAStores    []EAStore          `json:"eastores,omitempty"`
-	EHSClusters []EHSCluster       `json:"ehsclusters,omitempty"`
-	AWs         []AW               `json:"aws,omitempty"`
-	AVs         []AV               `json:"avs,omitempty"`
+	Errors        []api.RequestError `json:"errors,omitempty"`
+	Status        int                `json:"-"`
+	EAStores      []EAStore          `json:"eastores,omitempty"`
+	EHSClusters   []EHSCluster       `json:"ehsclusters,omitempty"`
+	AWs           []AW               `json:"aws,omitempty"`
+	AVs           []AV               `json:"avs,omitempty"`
+	AuditEvents   []AuditEvent       `json:"audit_events,omitempty"`
+	Revisions     []Revision         `json:"revisions,omitempty"`
+	Webhooks      []Webhook          `json:"webhooks,omitempty"`
+	Deliveries    []Delivery         `json:"deliveries,omitempty"`
+	Quotas        []QuotaUsage       `json:"quotas,omitempty"`
+	Operations    []Operation        `json:"operations,omitempty"`
+	Upgrades      []Upgrade          `json:"upgrades,omitempty"`
+	Migrations    []Migration        `json:"migrations,omitempty"`
+	Releases      []Release          `json:"releases,omitempty"`
+	Regions       []Region           `json:"regions,omitempty"`
+	Profiles      []Profile          `json:"profiles,omitempty"`
+	InstanceSizes []InstanceSize     `json:"instance_sizes,omitempty"`
+	NodePools     []NodePool         `json:"nodepools,omitempty"`
+	AWSchedules   []AWSchedule       `json:"awschedules,omitempty"`
 }
@@ -0,0 +1,359 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"darlinggo.co/api"
+	"github.com/hashicorp/go-uuid"
+)
+
+type AuditEvent struct {
+	ID           string          `json:"id"`
+	Time         time.Time       `json:"time"`
+	Principal    string          `json:"principal"`
+	SourceIP     string          `json:"source_ip"`
+	RequestID    string          `json:"request_id"`
+	Method       string          `json:"method"`
+	Path         string          `json:"path"`
+	Status       int             `json:"status"`
+	ResourceType string          `json:"resource_type"`
+	ResourceID   string          `json:"resource_id,omitempty"`
+	Before       json.RawMessage `json:"before,omitempty"`
+	After        json.RawMessage `json:"after,omitempty"`
+	Changes      []AuditChange   `json:"changes,omitempty"`
+}
+
+type AuditChange struct {
+	Field  string          `json:"field"`
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+type AuditFilter struct {
+	ResourceType string
+	ResourceID   string
+	Principal    string
+	Since        time.Time
+	Until        time.Time
+}
+
+func (f AuditFilter) matches(e AuditEvent) bool {
+	if f.ResourceType != "" && f.ResourceType != e.ResourceType {
+		return false
+	}
+	if f.ResourceID != "" && !strings.EqualFold(f.ResourceID, e.ResourceID) {
+		return false
+	}
+	if f.Principal != "" && f.Principal != e.Principal {
+		return false
+	}
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.Time.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// AuditLog records mutating API calls. Events are appended to a JSONL file,
+// if one is configured, and kept in memory for querying.
+type AuditLog struct {
+	mu     sync.RWMutex
+	file   *os.File
+	events []AuditEvent
+}
+
+// NewAuditLog opens the JSONL file at path for appending, loading any events
+// already in it. If path is empty, events are only kept in memory.
+func NewAuditLog(path string) (*AuditLog, error) {
+	l := &AuditLog{}
+	if path == "" {
+		return l, nil
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var e AuditEvent
+		err = json.Unmarshal(scanner.Bytes(), &e)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		l.events = append(l.events, e)
+	}
+	if err := scanner.Err(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	l.file = f
+	return l, nil
+}
+
+func (l *AuditLog) Append(e AuditEvent) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil {
+		b, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		_, err = l.file.Write(append(b, '\n'))
+		if err != nil {
+			return err
+		}
+		err = l.file.Sync()
+		if err != nil {
+			return err
+		}
+	}
+	l.events = append(l.events, e)
+	return nil
+}
+
+func (l *AuditLog) Events(filter AuditFilter) []AuditEvent {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	var results []AuditEvent
+	for _, e := range l.events {
+		if filter.matches(e) {
+			results = append(results, e)
+		}
+	}
+	return results
+}
+
+func (l *AuditLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Close()
+}
+
+// auditTarget works out which resource a request path refers to, using the
+// last collection/id pair in the path.
+func auditTarget(path string) (collection, id string) {
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	for i := 0; i < len(segs); i += 2 {
+		collection = segs[i]
+		id = ""
+		if i+1 < len(segs) {
+			id = segs[i+1]
+		}
+	}
+	if pos := strings.Index(id, ":"); pos >= 0 {
+		id = id[:pos]
+	}
+	return collection, id
+}
+
+// lookupResource returns the stored resource with the given id in the
+// named collection, or nil if there isn't one.
+func (a API) lookupResource(collection, id string) interface{} {
+	var obj interface{}
+	var err error
+	switch collection {
+	case "eastores":
+		obj, err = a.Storer.GetEAStore(id)
+	case "ehsclusters":
+		obj, err = a.Storer.GetEHSCluster(id)
+	case "aws":
+		obj, err = a.Storer.GetAW(id)
+	case "avs":
+		obj, err = a.Storer.GetAV(id)
+	case "nodepools":
+		obj, err = a.Storer.GetNodePool(id)
+	default:
+		return nil
+	}
+	if err != nil {
+		return nil
+	}
+	return obj
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+type bodyRecorder struct {
+	statusRecorder
+	body bytes.Buffer
+}
+
+func (p *bodyRecorder) Write(b []byte) (int, error) {
+	p.body.Write(b)
+	return p.ResponseWriter.Write(b)
+}
+
+// auditMiddleware records every mutating request to the AuditLog, along with
+// the state of the affected resource before and after the request.
+func (a API) auditMiddleware(baseURL string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get("X-Request-ID")
+		if requestID == "" {
+			requestID, _ = uuid.GenerateUUID()
+		}
+		w.Header().Set("X-Request-ID", requestID)
+		if !isMutating(r.Method) {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		collection, id := auditTarget(strings.TrimPrefix(r.URL.Path, baseURL))
+		var before interface{}
+		if id != "" {
+			before = a.lookupResource(collection, id)
+		}
+
+		rec := &bodyRecorder{statusRecorder: statusRecorder{ResponseWriter: w, status: http.StatusOK}}
+		h.ServeHTTP(rec, r)
+
+		if id == "" && rec.status < 300 {
+			id = createdID(rec.body.Bytes())
+		}
+		var after interface{}
+		if id != "" {
+			after = a.lookupResource(collection, id)
+		}
+
+		sourceIP, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			sourceIP = r.RemoteAddr
+		}
+		eventID, _ := uuid.GenerateUUID()
+		event := AuditEvent{
+			ID:           eventID,
+			Time:         time.Now().UTC(),
+			Principal:    PrincipalFromContext(r.Context()),
+			SourceIP:     sourceIP,
+			RequestID:    requestID,
+			Method:       r.Method,
+			Path:         r.URL.Path,
+			Status:       rec.status,
+			ResourceType: strings.TrimSuffix(collection, "s"),
+			ResourceID:   id,
+		}
+		event.Before, event.After, event.Changes = auditDiff(before, after)
+		err = a.Audit.Append(event)
+		if err != nil {
+			log.Println("Error writing audit log:", err.Error())
+		}
+	})
+}
+
+// createdID pulls the ID of the first resource out of a response body.
+func createdID(body []byte) string {
+	var resp map[string]json.RawMessage
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return ""
+	}
+	keys := make([]string, 0, len(resp))
+	for k := range resp {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if key == "errors" {
+			continue
+		}
+		var objs []struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(resp[key], &objs); err != nil {
+			continue
+		}
+		if len(objs) > 0 && objs[0].ID != "" {
+			return objs[0].ID
+		}
+	}
+	return ""
+}
+
+func auditDiff(before, after interface{}) (json.RawMessage, json.RawMessage, []AuditChange) {
+	var beforeJSON, afterJSON json.RawMessage
+	beforeFields := map[string]json.RawMessage{}
+	afterFields := map[string]json.RawMessage{}
+	if before != nil {
+		beforeJSON, _ = json.Marshal(before)
+		json.Unmarshal(beforeJSON, &beforeFields) //nolint:errcheck
+	}
+	if after != nil {
+		afterJSON, _ = json.Marshal(after)
+		json.Unmarshal(afterJSON, &afterFields) //nolint:errcheck
+	}
+	fields := map[string]struct{}{}
+	for k := range beforeFields {
+		fields[k] = struct{}{}
+	}
+	for k := range afterFields {
+		fields[k] = struct{}{}
+	}
+	var changes []AuditChange
+	for field := range fields {
+		var b, a interface{}
+		json.Unmarshal(beforeFields[field], &b) //nolint:errcheck
+		json.Unmarshal(afterFields[field], &a)  //nolint:errcheck
+		if reflect.DeepEqual(a, b) {
+			continue
+		}
+		changes = append(changes, AuditChange{
+			Field:  field,
+			Before: beforeFields[field],
+			After:  afterFields[field],
+		})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+	return beforeJSON, afterJSON, changes
+}
+
+func (a API) handleListAudit(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := AuditFilter{
+		ResourceType: q.Get("resource_type"),
+		ResourceID:   q.Get("resource_id"),
+		Principal:    q.Get("principal"),
+	}
+	var errs []api.RequestError
+	for param, target := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := q.Get(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				errs = append(errs, api.RequestError{Param: param, Slug: api.RequestErrInvalidFormat})
+				continue
+			}
+			*target = t
+		}
+	}
+	if len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{AuditEvents: a.Audit.Events(filter)})
+}
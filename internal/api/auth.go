@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"darlinggo.co/api"
+	"gopkg.in/yaml.v2"
+)
+
+// Token is a bearer token accepted by the API and the principal it
+// authenticates as. RateLimit overrides the server's default limit for
+// requests made with the token.
+type Token struct {
+	Token     string     `json:"token" yaml:"token"`
+	Principal string     `json:"principal" yaml:"principal"`
+	RateLimit *RateLimit `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty"`
+}
+
+type tokenFile struct {
+	Tokens []Token `json:"tokens" yaml:"tokens"`
+}
+
+// DefaultTokens are used when no token file is configured.
+var DefaultTokens = []Token{{Token: "secrettoken", Principal: "default"}}
+
+// LoadTokens reads a YAML or JSON token file.
+func LoadTokens(path string) ([]Token, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f tokenFile
+	err = yaml.Unmarshal(b, &f)
+	if err != nil {
+		return nil, err
+	}
+	return f.Tokens, nil
+}
+
+type Auth struct {
+	mu     sync.RWMutex
+	tokens map[string]Token
+}
+
+func NewAuth(tokens []Token) *Auth {
+	a := &Auth{}
+	a.SetTokens(tokens)
+	return a
+}
+
+// SetTokens replaces the set of accepted tokens.
+func (a *Auth) SetTokens(tokens []Token) {
+	m := make(map[string]Token, len(tokens))
+	for _, t := range tokens {
+		m[t.Token] = t
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.tokens = m
+}
+
+// Authenticate returns the Token presented by r, if it's a valid one. Both
+// the "Authorization: Bearer <token>" form and the older "Authentication"
+// header are accepted.
+func (a *Auth) Authenticate(r *http.Request) (Token, bool) {
+	presented := r.Header.Get("Authentication")
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		presented = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(auth, "Bearer"), ":"))
+	}
+	if presented == "" {
+		return Token{}, false
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	t, ok := a.tokens[presented]
+	return t, ok
+}
+
+type tokenKey struct{}
+
+func tokenFromContext(ctx context.Context) (Token, bool) {
+	t, ok := ctx.Value(tokenKey{}).(Token)
+	return t, ok
+}
+
+// PrincipalFromContext returns the principal the request was authenticated
+// as, or an empty string.
+func PrincipalFromContext(ctx context.Context) string {
+	t, _ := tokenFromContext(ctx)
+	return t.Principal
+}
+
+func (a *Auth) Middleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t, ok := a.Authenticate(r)
+		if !ok {
+			api.Encode(w, r, http.StatusUnauthorized, Response{Errors: []api.RequestError{{Header: "Authorization", Slug: api.RequestErrAccessDenied}}})
+			return
+		}
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tokenKey{}, t)))
+	})
+}
@@ -0,0 +1,256 @@
+package api
+
+import (
+	"context"
+	"log"
+	"math"
+	"reflect"
+	"sync"
+	"time"
+
+	"darlinggo.co/api"
+)
+
+// maxAutoscaleNodes is the most of its profile's nodes an EHS cluster can
+// scale out to.
+const maxAutoscaleNodes = 50
+
+// Autoscaling lets edisond scale the number of its profile's nodes an EHS
+// cluster runs between MinNodes and MaxNodes, keeping the AW seats it hosts
+// at TargetUtilization percent of what those nodes can host. Nodes are only
+// removed once ScaleInCooldown, a duration like "10m", has passed since the
+// cluster last scaled.
+type Autoscaling struct {
+	MinNodes          int    `json:"min_nodes"`
+	MaxNodes          int    `json:"max_nodes"`
+	TargetUtilization int    `json:"target_utilization"`
+	ScaleInCooldown   string `json:"scale_in_cooldown,omitempty"`
+}
+
+func (as Autoscaling) cooldown() time.Duration {
+	d, _ := time.ParseDuration(as.ScaleInCooldown)
+	return d
+}
+
+func validateAutoscaling(as *Autoscaling) []api.RequestError {
+	if as == nil {
+		return nil
+	}
+	var errs []api.RequestError
+	if as.MinNodes < 1 {
+		errs = append(errs, api.RequestError{Field: "/autoscaling/min_nodes", Slug: api.RequestErrInvalidValue})
+	}
+	if as.MaxNodes < as.MinNodes || as.MaxNodes > maxAutoscaleNodes {
+		errs = append(errs, api.RequestError{Field: "/autoscaling/max_nodes", Slug: api.RequestErrInvalidValue})
+	}
+	if as.TargetUtilization < 1 || as.TargetUtilization > 100 {
+		errs = append(errs, api.RequestError{Field: "/autoscaling/target_utilization", Slug: api.RequestErrInvalidValue})
+	}
+	if as.ScaleInCooldown != "" {
+		d, err := time.ParseDuration(as.ScaleInCooldown)
+		if err != nil || d < 0 {
+			errs = append(errs, api.RequestError{Field: "/autoscaling/scale_in_cooldown", Slug: api.RequestErrInvalidValue})
+		}
+	}
+	return errs
+}
+
+// clampNodes keeps nodes within the cluster's autoscaling limits, if it has
+// any.
+func clampNodes(as *Autoscaling, nodes int) int {
+	if as == nil {
+		return nodes
+	}
+	if nodes < as.MinNodes {
+		return as.MinNodes
+	}
+	if nodes > as.MaxNodes {
+		return as.MaxNodes
+	}
+	return nodes
+}
+
+// nodes is how many of its profile's nodes the cluster is running. Clusters
+// that have never been scaled run the profile's usual number.
+func (c EHSCluster) nodes() int {
+	if c.CurrentNodes > 0 {
+		return c.CurrentNodes
+	}
+	profile, _ := findProfile(c.Profile)
+	return profile.Nodes
+}
+
+// maxNodes is the most of profile's nodes the cluster can run.
+func (c EHSCluster) maxNodes(profile Profile) int {
+	if c.Autoscaling == nil {
+		return profile.Nodes
+	}
+	return c.Autoscaling.MaxNodes
+}
+
+// resizedNodes is how many of profile's nodes the cluster runs once it's
+// resized onto profile: the profile's usual number, unless it's autoscaling,
+// when it keeps as many nodes as it has.
+func (c EHSCluster) resizedNodes(profile Profile) int {
+	if c.Autoscaling == nil {
+		return profile.Nodes
+	}
+	return clampNodes(c.Autoscaling, c.nodes())
+}
+
+// neededNodes is how many of profile's nodes are needed to host seats AW
+// seats at the target utilization, on top of the seats the cluster's node
+// pools host.
+func neededNodes(as Autoscaling, profile Profile, seats int, pools Capacity) int {
+	seats -= pools.MaxAWSeats
+	var nodes int
+	if seats > 0 && profile.MaxAWSeats > 0 {
+		perNode := float64(profile.MaxAWSeats) / float64(profile.Nodes) * float64(as.TargetUtilization) / 100
+		nodes = int(math.Ceil(float64(seats) / perNode))
+	}
+	return clampNodes(&as, nodes)
+}
+
+// AutoscalerConfig sets how often the autoscaler reconciles EHS clusters
+// with the AWs they host. Nodes it adds or removes take an interval to come
+// up or go away.
+type AutoscalerConfig struct {
+	Interval time.Duration `json:"interval" yaml:"interval"`
+}
+
+// Autoscaler reconciles the number of nodes EHS clusters run with the AW
+// seats they host, in the background. Each pass works out how many nodes
+// each cluster needs, and brings up or removes the nodes earlier passes
+// asked for. Every change is recorded as a scale event.
+type Autoscaler struct {
+	storer *Storer
+	wake   chan struct{}
+
+	mu     sync.Mutex
+	config AutoscalerConfig
+}
+
+func NewAutoscaler(storer *Storer, config AutoscalerConfig) *Autoscaler {
+	return &Autoscaler{
+		storer: storer,
+		wake:   make(chan struct{}, 1),
+		config: config,
+	}
+}
+
+// SetConfig replaces the interval from the next pass on.
+func (s *Autoscaler) SetConfig(config AutoscalerConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config = config
+}
+
+func (s *Autoscaler) interval() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.config.Interval
+}
+
+// Wake asks for a pass straight away, instead of at the next interval.
+func (s *Autoscaler) Wake() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run reconciles every EHS cluster each interval, and whenever it's woken,
+// until ctx is done.
+func (s *Autoscaler) Run(ctx context.Context) {
+	for {
+		timer := time.NewTimer(s.interval())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		case <-s.wake:
+			timer.Stop()
+		}
+		err := s.Reconcile(time.Now())
+		if err != nil {
+			log.Println("Error autoscaling EHS clusters:", err.Error())
+		}
+	}
+}
+
+// Reconcile makes a single pass over every EHS cluster. Clusters that are
+// upgrading or resizing are left until they're ready.
+func (s *Autoscaler) Reconcile(now time.Time) error {
+	clusters, err := s.storer.ListEHSClusters(nil)
+	if err != nil {
+		return err
+	}
+	for _, cluster := range clusters {
+		if cluster.DeletedAt != "" || cluster.Status != EHSClusterReady {
+			continue
+		}
+		profile, ok := findProfile(cluster.Profile)
+		if !ok {
+			continue
+		}
+		seats, err := s.storer.hostedSeats(cluster.ID)
+		if err != nil {
+			return err
+		}
+		pools, err := s.storer.poolCapacity(cluster.ID, "")
+		if err != nil {
+			return err
+		}
+		scaled, changed := s.reconcile(cluster, profile, seats, pools, now)
+		if !changed {
+			continue
+		}
+		err = s.storer.ScaleEHSCluster(scaled)
+		if err != nil && err != ErrEHSClusterNotFound {
+			return err
+		}
+	}
+	return nil
+}
+
+// reconcile returns the cluster as it should be after a pass at now, and
+// whether that's changed it.
+func (s *Autoscaler) reconcile(cluster EHSCluster, profile Profile, seats int, pools Capacity, now time.Time) (EHSCluster, bool) {
+	scaled := cluster
+	current := cluster.nodes()
+	desired := cluster.DesiredNodes
+	if desired < 1 {
+		desired = current
+	}
+	var sinceScaled time.Duration = math.MaxInt64
+	if last, err := time.Parse(time.RFC3339, cluster.LastScaledAt); err == nil {
+		sinceScaled = now.Sub(last)
+	}
+	if current != desired && sinceScaled >= s.interval() {
+		current = desired
+	}
+	target := profile.Nodes
+	if cluster.Autoscaling != nil {
+		target = neededNodes(*cluster.Autoscaling, profile, seats, pools)
+		if target < desired && sinceScaled < cluster.Autoscaling.cooldown() {
+			target = desired
+		}
+	}
+	if target != desired {
+		desired = target
+		scaled.LastScaledAt = now.UTC().Format(time.RFC3339)
+	}
+	scaled.CurrentNodes = current
+	scaled.DesiredNodes = desired
+	scaled.Capacity = clusterCapacity(profile.Name, current, pools)
+	return scaled, !reflect.DeepEqual(scaled, cluster)
+}
+
+// wakeAutoscaler asks the autoscaler, if there is one, to reconcile now that
+// something it depends on has changed.
+func (a API) wakeAutoscaler() {
+	if a.Autoscaler != nil {
+		a.Autoscaler.Wake()
+	}
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"net/http"
+	"time"
 
 	"darlinggo.co/api"
 	"darlinggo.co/trout/v2"
@@ -9,16 +10,28 @@ import (
 )
 
 type AV struct {
-	ID           string `json:"id,omitempty"`
-	AccountID    string `json:"account_id"`
-	TenantID     string `json:"tenant_id"`
-	TenantFolder string `json:"tenant_folder,omitempty"`
-	TenantQueue  string `json:"tenant_queue,omitempty"`
-	CreatedAt    string `json:"created_at,omitempty"`
-	UpdatedAt    string `json:"updated_at,omitempty"`
+	ID           string            `json:"id,omitempty"`
+	AccountID    string            `json:"account_id"`
+	TenantID     string            `json:"tenant_id"`
+	TenantFolder string            `json:"tenant_folder,omitempty"`
+	TenantQueue  string            `json:"tenant_queue,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	CreatedAt    string            `json:"created_at,omitempty"`
+	UpdatedAt    string            `json:"updated_at,omitempty"`
+	DeletedAt    string            `json:"deleted_at,omitempty"`
 }
 
 func (a API) handleGetAV(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("revision") != "" {
+		var ap AV
+		if a.getRevision(w, r, "av", &ap) {
+			api.Encode(w, r, http.StatusOK, Response{AVs: []AV{ap}})
+		}
+		return
+	}
+	if !a.blockingQuery(w, r, "av") {
+		return
+	}
 	ap, err := a.Storer.GetAV(trout.RequestVars(r).Get("id"))
 	if err != nil {
 		if err == ErrAVNotFound {
@@ -38,17 +51,25 @@ func (a API) handlePostAV(w http.ResponseWriter, r *http.Request) {
 		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
 		return
 	}
+	if errs := validateLabels(ap.Labels); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
 	ap.ID, err = uuid.GenerateUUID()
 	if err != nil {
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
+	ap.DeletedAt = ""
 	err = a.Storer.CreateAV(ap)
 	if err != nil {
 		if err == ErrAVAlreadyExists {
 			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrConflict}}})
 			return
 		}
+		if quotaExceeded(w, r, err) {
+			return
+		}
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
@@ -62,21 +83,58 @@ func (a API) handlePutAV(w http.ResponseWriter, r *http.Request) {
 		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
 		return
 	}
+	if errs := validateLabels(ap.Labels); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
 	ap.ID = trout.RequestVars(r).Get("id")
+	ap.DeletedAt = ""
 	err = a.Storer.UpdateAV(ap)
 	if err != nil {
 		if err == ErrAVNotFound {
 			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
 			return
 		}
+		if quotaExceeded(w, r, err) {
+			return
+		}
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
 	api.Encode(w, r, http.StatusOK, Response{AVs: []AV{ap}})
 }
 
+func (a API) handleListAVs(w http.ResponseWriter, r *http.Request) {
+	filter, ok := parseDeletedFilter(w, r)
+	if !ok {
+		return
+	}
+	selector, ok := parseSelector(w, r)
+	if !ok {
+		return
+	}
+	aps, err := a.Storer.ListAVs(selector)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	results := []AV{}
+	for _, ap := range aps {
+		if filter.matches(ap.DeletedAt) {
+			results = append(results, ap)
+		}
+	}
+	api.Encode(w, r, http.StatusOK, Response{AVs: results})
+}
+
 func (a API) handleDeleteAV(w http.ResponseWriter, r *http.Request) {
-	ap, err := a.Storer.GetAV(trout.RequestVars(r).Get("id"))
+	var ap AV
+	var err error
+	if a.softDelete(r) {
+		ap, err = a.Storer.SoftDeleteAV(trout.RequestVars(r).Get("id"), time.Now())
+	} else {
+		ap, err = a.Storer.DeleteAV(trout.RequestVars(r).Get("id"))
+	}
 	if err != nil {
 		if err == ErrAVNotFound {
 			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
@@ -85,12 +143,28 @@ func (a API) handleDeleteAV(w http.ResponseWriter, r *http.Request) {
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
-	err = a.Storer.DeleteAV(trout.RequestVars(r).Get("id"))
+	api.Encode(w, r, http.StatusOK, Response{AVs: []AV{ap}})
+}
+
+func (a API) handleRestoreAV(w http.ResponseWriter, r *http.Request) {
+	id, action := splitAction(trout.RequestVars(r).Get("id"))
+	if action != "restore" {
+		api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+		return
+	}
+	ap, err := a.Storer.RestoreAV(id)
 	if err != nil {
 		if err == ErrAVNotFound {
 			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
 			return
 		}
+		if err == ErrAVNotDeleted {
+			api.Encode(w, r, http.StatusConflict, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrConflict}}})
+			return
+		}
+		if quotaExceeded(w, r, err) {
+			return
+		}
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
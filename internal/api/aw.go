@@ -2,6 +2,7 @@ package api
 
 import (
 	"net/http"
+	"time"
 
 	"darlinggo.co/api"
 	"darlinggo.co/trout/v2"
@@ -9,16 +10,38 @@ import (
 )
 
 type AW struct {
-	ID              string `json:"id,omitempty"`
-	ConcurrentUsers int    `json:"concurrent_users"`
-	EHSClusterID    string `json:"ehs_cluster_id"`
-	DicomEndPoint   string `json:"dicom_endpoint"`
-	DNSEndPoint     string `json:"dns_endpoint,omitempty"`
-	CreatedAt       string `json:"created_at,omitempty"`
-	UpdatedAt       string `json:"updated_at,omitempty"`
+	ID                 string            `json:"id,omitempty"`
+	ConcurrentUsers    int               `json:"concurrent_users"`
+	EHSClusterID       string            `json:"ehs_cluster_id"`
+	Region             string            `json:"region,omitempty"`
+	DicomEndPoint      string            `json:"dicom_endpoint"`
+	DNSEndPoint        string            `json:"dns_endpoint,omitempty"`
+	EAAccountID        string            `json:"ea_account_id"`
+	EAServiceEP        string            `json:"ea_service_ep"`
+	EAVpcEP            string            `json:"ea_vpc_ep,omitempty"`
+	Status             string            `json:"status,omitempty"`
+	MigrationStrategy  string            `json:"migration_strategy,omitempty"`
+	TargetEHSClusterID string            `json:"target_ehs_cluster_id,omitempty"`
+	MigrationID        string            `json:"migration_id,omitempty"`
+	OperationID        string            `json:"operation_id,omitempty"`
+	ScheduleID         string            `json:"schedule_id,omitempty"`
+	Labels             map[string]string `json:"labels,omitempty"`
+	CreatedAt          string            `json:"created_at,omitempty"`
+	UpdatedAt          string            `json:"updated_at,omitempty"`
+	DeletedAt          string            `json:"deleted_at,omitempty"`
 }
 
 func (a API) handleGetAW(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("revision") != "" {
+		var ap AW
+		if a.getRevision(w, r, "aw", &ap) {
+			api.Encode(w, r, http.StatusOK, Response{AWs: []AW{ap}})
+		}
+		return
+	}
+	if !a.blockingQuery(w, r, "aw") {
+		return
+	}
 	ap, err := a.Storer.GetAW(trout.RequestVars(r).Get("id"))
 	if err != nil {
 		if err == ErrAWNotFound {
@@ -38,20 +61,58 @@ func (a API) handlePostAW(w http.ResponseWriter, r *http.Request) {
 		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
 		return
 	}
+	if errs := validateLabels(ap.Labels); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
 	ap.ID, err = uuid.GenerateUUID()
 	if err != nil {
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
+	ap.DeletedAt = ""
+	ap.Status = AWReady
+	ap.TargetEHSClusterID = ""
+	ap.MigrationID = ""
+	ap.OperationID = ""
+	ap.ScheduleID = ""
+	if ap.MigrationStrategy == "" {
+		ap.MigrationStrategy = MigrationCutOver
+	}
+	if !validMigrationStrategy(ap.MigrationStrategy) {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/migration_strategy", Slug: api.RequestErrInvalidValue}}})
+		return
+	}
+	placed := ap.EHSClusterID == ""
+	ap, ok := a.assignEHSCluster(w, r, ap)
+	if !ok {
+		return
+	}
+	if ap.DNSEndPoint == "" {
+		ap.DNSEndPoint = awDNSEndPoint(ap.ID, ap.EHSClusterID)
+	}
 	err = a.Storer.CreateAW(ap)
 	if err != nil {
 		if err == ErrAWAlreadyExists {
 			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrConflict}}})
 			return
 		}
+		if quotaExceeded(w, r, err) {
+			return
+		}
+		if err == ErrNoRoom {
+			// Another AW took the cluster's last seats first.
+			field := "/concurrent_users"
+			if placed {
+				field = "/ehs_cluster_id"
+			}
+			api.Encode(w, r, http.StatusConflict, Response{Errors: []api.RequestError{{Field: field, Slug: api.RequestErrInsufficient}}})
+			return
+		}
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
+	a.wakeAutoscaler()
 	api.Encode(w, r, http.StatusCreated, Response{AWs: []AW{ap}})
 }
 
@@ -62,8 +123,13 @@ func (a API) handlePutAW(w http.ResponseWriter, r *http.Request) {
 		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
 		return
 	}
+	if errs := validateLabels(ap.Labels); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
 	ap.ID = trout.RequestVars(r).Get("id")
-	err = a.Storer.UpdateAW(ap)
+	ap.DeletedAt = ""
+	existing, err := a.Storer.GetAW(ap.ID)
 	if err != nil {
 		if err == ErrAWNotFound {
 			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
@@ -72,11 +138,94 @@ func (a API) handlePutAW(w http.ResponseWriter, r *http.Request) {
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
+	// An AW's schedule sets its concurrent users while it has one.
+	ap.ScheduleID = existing.ScheduleID
+	if ap.ScheduleID != "" {
+		ap.ConcurrentUsers = existing.ConcurrentUsers
+	}
+	// AWs stay where they are unless they're moved, and only need their
+	// cluster's room checked when they move or grow.
+	if ap.EHSClusterID == "" {
+		ap.EHSClusterID = existing.EHSClusterID
+	}
+	if existing.Status == AWMigrating && ap.EHSClusterID == existing.TargetEHSClusterID {
+		ap.EHSClusterID = existing.EHSClusterID
+	}
+	if ap.EHSClusterID == "" || ap.EHSClusterID != existing.EHSClusterID || ap.ConcurrentUsers > existing.ConcurrentUsers || (ap.Region != "" && ap.Region != existing.Region) {
+		var ok bool
+		ap, ok = a.assignEHSCluster(w, r, ap)
+		if !ok {
+			return
+		}
+	} else {
+		ap.Region = existing.Region
+	}
+	ap, ok := a.migrateAW(w, r, ap, existing)
+	if !ok {
+		return
+	}
+	migrating := ap.MigrationID != "" && ap.MigrationID != existing.MigrationID
+	err = a.Storer.SwapAW(existing, ap)
+	if err != nil && migrating {
+		a.Migrations.Abandon(ap.MigrationID)
+	}
+	if err != nil {
+		if err == ErrAWNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrAWChanged {
+			api.Encode(w, r, http.StatusConflict, Response{Errors: []api.RequestError{{Field: "/status", Slug: api.RequestErrConflict}}})
+			return
+		}
+		if quotaExceeded(w, r, err) {
+			return
+		}
+		if err == ErrNoRoom {
+			api.Encode(w, r, http.StatusConflict, Response{Errors: []api.RequestError{{Field: "/concurrent_users", Slug: api.RequestErrInsufficient}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	if migrating {
+		a.Migrations.Begin(ap.MigrationID)
+	}
+	a.wakeAutoscaler()
 	api.Encode(w, r, http.StatusOK, Response{AWs: []AW{ap}})
 }
 
+func (a API) handleListAWs(w http.ResponseWriter, r *http.Request) {
+	filter, ok := parseDeletedFilter(w, r)
+	if !ok {
+		return
+	}
+	selector, ok := parseSelector(w, r)
+	if !ok {
+		return
+	}
+	aps, err := a.Storer.ListAWs(selector)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	results := []AW{}
+	for _, ap := range aps {
+		if filter.matches(ap.DeletedAt) {
+			results = append(results, ap)
+		}
+	}
+	api.Encode(w, r, http.StatusOK, Response{AWs: results})
+}
+
 func (a API) handleDeleteAW(w http.ResponseWriter, r *http.Request) {
-	ap, err := a.Storer.GetAW(trout.RequestVars(r).Get("id"))
+	var ap AW
+	var err error
+	if a.softDelete(r) {
+		ap, err = a.Storer.SoftDeleteAW(trout.RequestVars(r).Get("id"), time.Now())
+	} else {
+		ap, err = a.Storer.DeleteAW(trout.RequestVars(r).Get("id"))
+	}
 	if err != nil {
 		if err == ErrAWNotFound {
 			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
@@ -85,14 +234,32 @@ func (a API) handleDeleteAW(w http.ResponseWriter, r *http.Request) {
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
-	err = a.Storer.DeleteAW(trout.RequestVars(r).Get("id"))
+	a.wakeAutoscaler()
+	api.Encode(w, r, http.StatusOK, Response{AWs: []AW{ap}})
+}
+
+func (a API) handleRestoreAW(w http.ResponseWriter, r *http.Request) {
+	id, action := splitAction(trout.RequestVars(r).Get("id"))
+	if action != "restore" {
+		api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+		return
+	}
+	ap, err := a.Storer.RestoreAW(id)
 	if err != nil {
 		if err == ErrAWNotFound {
 			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
 			return
 		}
+		if err == ErrAWNotDeleted {
+			api.Encode(w, r, http.StatusConflict, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrConflict}}})
+			return
+		}
+		if quotaExceeded(w, r, err) {
+			return
+		}
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
+	a.wakeAutoscaler()
 	api.Encode(w, r, http.StatusOK, Response{AWs: []AW{ap}})
 }
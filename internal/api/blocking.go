@@ -0,0 +1,105 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"darlinggo.co/api"
+	"darlinggo.co/trout/v2"
+)
+
+const defaultMaxWait = 5 * time.Minute
+
+// indexWriter sets the X-Edison-Index header to the Storer's index as of
+// when the response is written, after any change the request made.
+type indexWriter struct {
+	http.ResponseWriter
+	storer      *Storer
+	wroteHeader bool
+}
+
+func (i *indexWriter) WriteHeader(status int) {
+	if !i.wroteHeader {
+		i.Header().Set("X-Edison-Index", strconv.FormatUint(i.storer.Index(), 10))
+		i.wroteHeader = true
+	}
+	i.ResponseWriter.WriteHeader(status)
+}
+
+func (i *indexWriter) Write(b []byte) (int, error) {
+	if !i.wroteHeader {
+		i.WriteHeader(http.StatusOK)
+	}
+	return i.ResponseWriter.Write(b)
+}
+
+func (i *indexWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := i.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijacking not supported")
+	}
+	return hj.Hijack()
+}
+
+func (i *indexWriter) Flush() {
+	if f, ok := i.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (a API) indexMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(&indexWriter{ResponseWriter: w, storer: a.Storer}, r)
+	})
+}
+
+// blockingQuery handles the index and wait query params on a GET. If index
+// is set, it waits until the requested resource has changed since that
+// index, or until wait (capped at the API's MaxWait) has elapsed. If the
+// params are invalid, it writes an error response and returns false.
+func (a API) blockingQuery(w http.ResponseWriter, r *http.Request, resourceType string) bool {
+	return a.block(w, r, func(ctx context.Context, index uint64, wait time.Duration) error {
+		return a.Storer.WaitForChange(ctx, resourceType, trout.RequestVars(r).Get("id"), index, wait)
+	})
+}
+
+// block parses the index and wait query params and, if index is set, calls
+// wait with them. If the params are invalid or wait fails, it writes an
+// error response and returns false.
+func (a API) block(w http.ResponseWriter, r *http.Request, wait func(ctx context.Context, index uint64, timeout time.Duration) error) bool {
+	q := r.URL.Query()
+	if q.Get("index") == "" {
+		return true
+	}
+	index, err := strconv.ParseUint(q.Get("index"), 10, 64)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "index", Slug: api.RequestErrInvalidFormat}}})
+		return false
+	}
+	maxWait := a.MaxWait
+	if maxWait <= 0 {
+		maxWait = defaultMaxWait
+	}
+	timeout := maxWait
+	if q.Get("wait") != "" {
+		timeout, err = time.ParseDuration(q.Get("wait"))
+		if err != nil || timeout < 0 {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "wait", Slug: api.RequestErrInvalidFormat}}})
+			return false
+		}
+		if timeout > maxWait {
+			timeout = maxWait
+		}
+	}
+	err = wait(r.Context(), index, timeout)
+	if err != nil && err != ErrWaitTimeout && r.Context().Err() == nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,287 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"darlinggo.co/api"
+)
+
+var (
+	ErrReleaseNotFound    = errors.New("release not in catalog")
+	ErrInvalidUpgradePath = errors.New("invalid upgrade path")
+)
+
+const (
+	ReleasePreview    = "preview"
+	ReleaseGA         = "ga"
+	ReleaseDeprecated = "deprecated"
+	ReleaseEOL        = "eol"
+)
+
+// Region is somewhere EHS clusters can run. Releases and Profiles list what's
+// available there, and are filled in from the catalog.
+type Region struct {
+	Name     string   `json:"name"`
+	Location string   `json:"location"`
+	Releases []string `json:"releases"`
+	Profiles []string `json:"profiles"`
+}
+
+// Regions is the region catalog.
+var Regions = []Region{
+	{Name: "us-east-1", Location: "US East (N. Virginia)"},
+	{Name: "us-west-2", Location: "US West (Oregon)"},
+	{Name: "eu-west-1", Location: "Europe (Ireland)"},
+	{Name: "ap-southeast-2", Location: "Asia Pacific (Sydney)"},
+}
+
+// Release is an EHS release. Releases are referred to by name, and ordered
+// by their major.minor version. New clusters can't be created on, or
+// upgraded to, an EOL release.
+type Release struct {
+	Name    string   `json:"name"`
+	Version string   `json:"version"`
+	Status  string   `json:"status"`
+	Regions []string `json:"regions"`
+}
+
+// Releases is the release catalog, oldest first.
+var Releases = []Release{
+	{Name: "dunwood", Version: "1.0", Status: ReleaseEOL, Regions: []string{"us-east-1", "us-west-2"}},
+	{Name: "elmwood", Version: "1.1", Status: ReleaseDeprecated, Regions: []string{"us-east-1", "us-west-2", "eu-west-1"}},
+	{Name: "fenwood", Version: "2.0", Status: ReleaseGA, Regions: []string{"us-east-1", "us-west-2", "eu-west-1", "ap-southeast-2"}},
+	{Name: "glenwood", Version: "2.1", Status: ReleaseGA, Regions: []string{"us-east-1", "us-west-2", "eu-west-1", "ap-southeast-2"}},
+	{Name: "hazelwood", Version: "3.0", Status: ReleasePreview, Regions: []string{"us-east-1"}},
+}
+
+func findRelease(name string) (Release, bool) {
+	for _, r := range Releases {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return Release{}, false
+}
+
+func (r Release) major() int {
+	major, _ := strconv.Atoi(strings.SplitN(r.Version, ".", 2)[0])
+	return major
+}
+
+func (r Release) minor() int {
+	parts := strings.SplitN(r.Version, ".", 2)
+	if len(parts) < 2 {
+		return 0
+	}
+	minor, _ := strconv.Atoi(parts[1])
+	return minor
+}
+
+// newerThan reports whether r is a later release than other.
+func (r Release) newerThan(other Release) bool {
+	if r.major() != other.major() {
+		return r.major() > other.major()
+	}
+	return r.minor() > other.minor()
+}
+
+// checkUpgradePath returns an error unless a cluster on release from can be
+// upgraded straight to release to. Upgrades only go forward, and can't skip
+// a major version, or to an EOL release.
+func checkUpgradePath(from, to string) error {
+	fromRelease, ok := findRelease(from)
+	if !ok {
+		return ErrReleaseNotFound
+	}
+	toRelease, ok := findRelease(to)
+	if !ok {
+		return ErrReleaseNotFound
+	}
+	if toRelease.Status == ReleaseEOL || !toRelease.newerThan(fromRelease) || toRelease.major() > fromRelease.major()+1 {
+		return ErrInvalidUpgradePath
+	}
+	return nil
+}
+
+// Profile is an EHS cluster size, and the capacity a cluster of that size
+// has.
+type Profile struct {
+	Name       string   `json:"name"`
+	Nodes      int      `json:"nodes"`
+	VCPU       int      `json:"vcpu"`
+	MemoryGB   int      `json:"memory_gb"`
+	MaxAWSeats int      `json:"max_aw_seats"`
+	Regions    []string `json:"regions"`
+}
+
+// Profiles is the profile catalog, smallest first.
+var Profiles = []Profile{
+	{Name: "small", Nodes: 3, VCPU: 24, MemoryGB: 96, MaxAWSeats: 16, Regions: []string{"us-east-1", "us-west-2", "eu-west-1", "ap-southeast-2"}},
+	{Name: "medium", Nodes: 5, VCPU: 80, MemoryGB: 320, MaxAWSeats: 48, Regions: []string{"us-east-1", "us-west-2", "eu-west-1", "ap-southeast-2"}},
+	{Name: "large", Nodes: 8, VCPU: 256, MemoryGB: 1024, MaxAWSeats: 128, Regions: []string{"us-east-1", "us-west-2", "eu-west-1"}},
+}
+
+func findProfile(name string) (Profile, bool) {
+	for _, p := range Profiles {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Profile{}, false
+}
+
+// Capacity is what a cluster of profile p can host.
+func (p Profile) Capacity() *Capacity {
+	return &Capacity{Nodes: p.Nodes, VCPU: p.VCPU, MemoryGB: p.MemoryGB, MaxAWSeats: p.MaxAWSeats}
+}
+
+// scaled is what a cluster of profile p can host when it's running nodes
+// nodes instead of the profile's usual number.
+func (p Profile) scaled(nodes int) Capacity {
+	if p.Nodes < 1 {
+		return Capacity{}
+	}
+	return Capacity{
+		Nodes:      nodes,
+		VCPU:       p.VCPU * nodes / p.Nodes,
+		MemoryGB:   p.MemoryGB * nodes / p.Nodes,
+		MaxAWSeats: p.MaxAWSeats * nodes / p.Nodes,
+	}
+}
+
+// Capacity is how much an EHS cluster can host.
+type Capacity struct {
+	Nodes      int `json:"nodes"`
+	VCPU       int `json:"vcpu"`
+	MemoryGB   int `json:"memory_gb"`
+	GPUs       int `json:"gpus"`
+	MaxAWSeats int `json:"max_aw_seats"`
+}
+
+func (c *Capacity) add(other Capacity) {
+	c.Nodes += other.Nodes
+	c.VCPU += other.VCPU
+	c.MemoryGB += other.MemoryGB
+	c.GPUs += other.GPUs
+	c.MaxAWSeats += other.MaxAWSeats
+}
+
+// clusterCapacity is what a cluster with the named profile can host running
+// nodes of the profile's nodes, with what its node pools add. It's nil if
+// neither adds anything.
+func clusterCapacity(profileName string, nodes int, pools Capacity) *Capacity {
+	var capacity Capacity
+	if profile, ok := findProfile(profileName); ok {
+		capacity = profile.scaled(nodes)
+	}
+	capacity.add(pools)
+	if capacity == (Capacity{}) {
+		return nil
+	}
+	return &capacity
+}
+
+// InstanceSize is the size of a node pool's nodes. Viewer nodes host AW
+// seats; GPU nodes run processing, and don't.
+type InstanceSize struct {
+	Name     string `json:"name"`
+	VCPU     int    `json:"vcpu"`
+	MemoryGB int    `json:"memory_gb"`
+	GPUs     int    `json:"gpus"`
+	AWSeats  int    `json:"aw_seats"`
+}
+
+// InstanceSizes is the instance size catalog.
+var InstanceSizes = []InstanceSize{
+	{Name: "viewer.large", VCPU: 8, MemoryGB: 32, AWSeats: 4},
+	{Name: "viewer.xlarge", VCPU: 16, MemoryGB: 64, AWSeats: 8},
+	{Name: "gpu.xlarge", VCPU: 16, MemoryGB: 64, GPUs: 1},
+	{Name: "gpu.4xlarge", VCPU: 64, MemoryGB: 256, GPUs: 4},
+}
+
+func findInstanceSize(name string) (InstanceSize, bool) {
+	for _, size := range InstanceSizes {
+		if size.Name == name {
+			return size, true
+		}
+	}
+	return InstanceSize{}, false
+}
+
+// nodeCount returns how many nodes the cluster runs. Clusters with a
+// profile that isn't in the catalog are treated as small.
+func (c EHSCluster) nodeCount() int {
+	if c.Capacity != nil && c.Capacity.Nodes > 0 {
+		return c.Capacity.Nodes
+	}
+	return Profiles[0].Nodes
+}
+
+func inRegion(regions []string, region string) bool {
+	for _, r := range regions {
+		if r == region {
+			return true
+		}
+	}
+	return false
+}
+
+// catalogRegions returns the region catalog, with what's available in each
+// region filled in.
+func catalogRegions() []Region {
+	results := make([]Region, 0, len(Regions))
+	for _, region := range Regions {
+		region.Releases = []string{}
+		region.Profiles = []string{}
+		for _, release := range Releases {
+			if inRegion(release.Regions, region.Name) {
+				region.Releases = append(region.Releases, release.Name)
+			}
+		}
+		for _, profile := range Profiles {
+			if inRegion(profile.Regions, region.Name) {
+				region.Profiles = append(region.Profiles, profile.Name)
+			}
+		}
+		results = append(results, region)
+	}
+	return results
+}
+
+func (a API) handleListReleases(w http.ResponseWriter, r *http.Request) {
+	region, status := r.URL.Query().Get("region"), r.URL.Query().Get("status")
+	results := []Release{}
+	for _, release := range Releases {
+		if region != "" && !inRegion(release.Regions, region) {
+			continue
+		}
+		if status != "" && release.Status != status {
+			continue
+		}
+		results = append(results, release)
+	}
+	api.Encode(w, r, http.StatusOK, Response{Releases: results})
+}
+
+func (a API) handleListRegions(w http.ResponseWriter, r *http.Request) {
+	api.Encode(w, r, http.StatusOK, Response{Regions: catalogRegions()})
+}
+
+func (a API) handleListProfiles(w http.ResponseWriter, r *http.Request) {
+	region := r.URL.Query().Get("region")
+	results := []Profile{}
+	for _, profile := range Profiles {
+		if region != "" && !inRegion(profile.Regions, region) {
+			continue
+		}
+		results = append(results, profile)
+	}
+	api.Encode(w, r, http.StatusOK, Response{Profiles: results})
+}
+
+func (a API) handleListInstanceSizes(w http.ResponseWriter, r *http.Request) {
+	api.Encode(w, r, http.StatusOK, Response{InstanceSizes: InstanceSizes})
+}
@@ -0,0 +1,243 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"darlinggo.co/api"
+)
+
+// ChaosRule describes the faults to inject into requests matching Method
+// and Path. Path uses the same {var} syntax as the router; an empty Method
+// or Path matches everything. Rates are probabilities between 0 and 1.
+type ChaosRule struct {
+	Method       string  `json:"method,omitempty" yaml:"method,omitempty"`
+	Path         string  `json:"path,omitempty" yaml:"path,omitempty"`
+	LatencyMS    int     `json:"latency_ms,omitempty" yaml:"latency_ms,omitempty"`
+	ErrorRate    float64 `json:"error_rate,omitempty" yaml:"error_rate,omitempty"`
+	DropRate     float64 `json:"drop_rate,omitempty" yaml:"drop_rate,omitempty"`
+	TruncateRate float64 `json:"truncate_rate,omitempty" yaml:"truncate_rate,omitempty"`
+}
+
+// ChaosConfig is the complete fault-injection configuration. ReadLagMS is
+// how long a GET for a newly created resource keeps returning 404.
+type ChaosConfig struct {
+	Enabled   bool        `json:"enabled" yaml:"enabled"`
+	Seed      int64       `json:"seed" yaml:"seed"`
+	ReadLagMS int         `json:"read_lag_ms,omitempty" yaml:"read_lag_ms,omitempty"`
+	Rules     []ChaosRule `json:"rules,omitempty" yaml:"rules,omitempty"`
+}
+
+// Chaos is a middleware that makes the API misbehave on demand, so clients'
+// retry and read-after-write handling can be exercised deterministically.
+type Chaos struct {
+	mu      sync.Mutex
+	config  ChaosConfig
+	rand    *rand.Rand
+	created map[string]time.Time
+}
+
+func NewChaos(config ChaosConfig) *Chaos {
+	c := &Chaos{}
+	c.SetConfig(config)
+	return c
+}
+
+// SetConfig replaces the configuration and reseeds the random source, so
+// the same sequence of requests sees the same faults. It does nothing if
+// chaos isn't being served.
+func (c *Chaos) SetConfig(config ChaosConfig) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.config = config
+	c.rand = rand.New(rand.NewSource(config.Seed))
+	c.created = map[string]time.Time{}
+}
+
+func (c *Chaos) Config() ChaosConfig {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.config
+}
+
+type chaosFaults struct {
+	latency  time.Duration
+	fail     bool
+	drop     bool
+	truncate bool
+	lagging  bool
+}
+
+func (c *Chaos) roll(r *http.Request, route string) chaosFaults {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var f chaosFaults
+	if !c.config.Enabled {
+		return f
+	}
+	for _, rule := range c.config.Rules {
+		if rule.Method != "" && !strings.EqualFold(rule.Method, r.Method) {
+			continue
+		}
+		if rule.Path != "" && !matchRoute(rule.Path, route) {
+			continue
+		}
+		f.latency += time.Duration(rule.LatencyMS) * time.Millisecond
+		f.fail = f.fail || c.rand.Float64() < rule.ErrorRate
+		f.drop = f.drop || c.rand.Float64() < rule.DropRate
+		f.truncate = f.truncate || c.rand.Float64() < rule.TruncateRate
+	}
+	if r.Method == http.MethodGet {
+		if at, ok := c.created[r.URL.Path]; ok {
+			if time.Since(at) < time.Duration(c.config.ReadLagMS)*time.Millisecond {
+				f.lagging = true
+			} else {
+				delete(c.created, r.URL.Path)
+			}
+		}
+	}
+	return f
+}
+
+func (c *Chaos) recordCreated(r *http.Request, body []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.config.Enabled || c.config.ReadLagMS <= 0 {
+		return
+	}
+	var resp map[string]json.RawMessage
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return
+	}
+	for key, raw := range resp {
+		if key == "errors" {
+			continue
+		}
+		var objs []struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(raw, &objs); err != nil {
+			continue
+		}
+		for _, obj := range objs {
+			if obj.ID == "" {
+				continue
+			}
+			c.created[strings.TrimSuffix(r.URL.Path, "/")+"/"+obj.ID] = time.Now()
+		}
+	}
+}
+
+// Middleware wraps h, matching rules against request paths with baseURL
+// removed.
+func (c *Chaos) Middleware(baseURL string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		faults := c.roll(r, strings.TrimPrefix(r.URL.Path, baseURL))
+		if faults.latency > 0 {
+			select {
+			case <-time.After(faults.latency):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if faults.drop {
+			dropConnection(w)
+			return
+		}
+		if faults.fail {
+			api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+			return
+		}
+		if faults.lagging {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		rec := &chaosRecorder{header: http.Header{}, status: http.StatusOK}
+		h.ServeHTTP(rec, r)
+		if r.Method == http.MethodPost && rec.status == http.StatusCreated {
+			c.recordCreated(r, rec.body.Bytes())
+		}
+		body := rec.body.Bytes()
+		if faults.truncate && len(body) > 0 {
+			body = body[:len(body)/2]
+		}
+		for k, v := range rec.header {
+			w.Header()[k] = v
+		}
+		w.Header().Del("Content-Length")
+		w.WriteHeader(rec.status)
+		w.Write(body) //nolint:errcheck
+	})
+}
+
+func dropConnection(w http.ResponseWriter) {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		panic(http.ErrAbortHandler)
+	}
+	conn, _, err := hj.Hijack()
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	conn.Close()
+}
+
+type chaosRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (c *chaosRecorder) Header() http.Header {
+	return c.header
+}
+
+func (c *chaosRecorder) WriteHeader(status int) {
+	c.status = status
+}
+
+func (c *chaosRecorder) Write(b []byte) (int, error) {
+	return c.body.Write(b)
+}
+
+// matchRoute reports whether path matches a router-style pattern such as
+// /ehsclusters/{id}.
+func matchRoute(pattern, path string) bool {
+	want := strings.Split(strings.Trim(pattern, "/"), "/")
+	got := strings.Split(strings.Trim(path, "/"), "/")
+	if len(want) != len(got) {
+		return false
+	}
+	for i, seg := range want {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			continue
+		}
+		if seg != got[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (a API) handleGetChaos(w http.ResponseWriter, r *http.Request) {
+	api.Encode(w, r, http.StatusOK, a.Chaos.Config())
+}
+
+func (a API) handlePutChaos(w http.ResponseWriter, r *http.Request) {
+	var config ChaosConfig
+	err := api.Decode(r, &config)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	a.Chaos.SetConfig(config)
+	api.Encode(w, r, http.StatusOK, config)
+}
@@ -0,0 +1,122 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rahoolp/terraform-provider-edison/internal/api"
+)
+
+// TestChaosAsyncCreate checks that chaos applies to the change an async
+// create makes, not just its 202, so clients waiting on operations see the
+// same faults and read lag as synchronous ones.
+func TestChaosAsyncCreate(t *testing.T) {
+	storer, err := api.NewStorer()
+	if err != nil {
+		t.Fatalf("Error setting up storer: %s", err)
+	}
+	const readLag = 200 * time.Millisecond
+	a := api.API{
+		Storer: storer,
+		Chaos: api.NewChaos(api.ChaosConfig{
+			Enabled:   true,
+			Seed:      1,
+			ReadLagMS: int(readLag / time.Millisecond),
+			Rules: []api.ChaosRule{
+				{Method: http.MethodPost, Path: "/eastores", ErrorRate: 0.3},
+			},
+		}),
+	}
+	a.Operations = api.NewOperations(storer, nil, api.OperationsConfig{})
+	srv := httptest.NewServer(a.Server(""))
+	defer srv.Close()
+
+	var accepted, failed, created int
+	for i := 0; i < 20; i++ {
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/v1/eastores", strings.NewReader(`{"partition_space_tb": 1}`))
+		if err != nil {
+			t.Fatalf("Error building request: %s", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Prefer", "respond-async")
+		var resp api.Response
+		status := do(t, req, &resp)
+		if status == http.StatusInternalServerError {
+			continue
+		}
+		if status != http.StatusAccepted {
+			t.Fatalf("Expected 202 creating an EA Store, got %d", status)
+		}
+		accepted++
+		op := waitForOperation(t, srv.URL, resp.Operations[0].ID)
+		if op.Status == api.OperationFailed {
+			failed++
+			continue
+		}
+		if op.Status != api.OperationSucceeded {
+			t.Fatalf("Expected operation %s to succeed or fail, got %s", op.ID, op.Status)
+		}
+		created++
+
+		get, err := http.NewRequest(http.MethodGet, srv.URL+"/v1/eastores/"+op.ResourceID, nil)
+		if err != nil {
+			t.Fatalf("Error building request: %s", err)
+		}
+		if status := do(t, get, nil); status != http.StatusNotFound {
+			t.Errorf("Expected the new EA Store to lag with 404, got %d", status)
+		}
+		time.Sleep(readLag)
+		if status := do(t, get, nil); status != http.StatusOK {
+			t.Errorf("Expected the new EA Store after the read lag, got %d", status)
+		}
+	}
+	if accepted == 20 {
+		t.Error("Expected chaos to fail some 202s")
+	}
+	if failed == 0 {
+		t.Error("Expected chaos to fail some operations")
+	}
+	if created == 0 {
+		t.Error("Expected some EA Stores to be created")
+	}
+}
+
+func do(t *testing.T, req *http.Request, v interface{}) int {
+	t.Helper()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Error making request: %s", err)
+	}
+	defer resp.Body.Close()
+	if v != nil {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("Error decoding response: %s", err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func waitForOperation(t *testing.T, baseURL, id string) api.Operation {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		req, err := http.NewRequest(http.MethodGet, baseURL+"/v1/operations/"+id, nil)
+		if err != nil {
+			t.Fatalf("Error building request: %s", err)
+		}
+		var resp api.Response
+		if status := do(t, req, &resp); status != http.StatusOK {
+			t.Fatalf("Expected 200 getting operation %s, got %d", id, status)
+		}
+		if op := resp.Operations[0]; op.Status != api.OperationRunning {
+			return op
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Operation %s didn't finish", id)
+	return api.Operation{}
+}
@@ -0,0 +1,564 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rahoolp/terraform-provider-edison/internal/api"
+	"gopkg.in/yaml.v2"
+)
+
+type Config struct {
+	ListenAddress string                       `yaml:"listen_address"`
+	BasePath      string                       `yaml:"base_path"`
+	Storage       StorageConfig                `yaml:"storage"`
+	TokenFile     string                       `yaml:"token_file"`
+	TLS           TLSConfig                    `yaml:"tls"`
+	Timeouts      TimeoutsConfig               `yaml:"timeouts"`
+	Audit         AuditConfig                  `yaml:"audit"`
+	SoftDelete    SoftDeleteConfig             `yaml:"soft_delete"`
+	RateLimit     api.RateLimit                `yaml:"rate_limit"`
+	Quotas        api.QuotaConfig              `yaml:"quotas"`
+	OpenAPI       OpenAPIConfig                `yaml:"openapi"`
+	Versions      map[string]api.VersionPolicy `yaml:"versions"`
+	Placement     api.PlacementConfig          `yaml:"placement"`
+	Simulation    SimulationConfig             `yaml:"simulation"`
+}
+
+type StorageConfig struct {
+	Backend string `yaml:"backend"`
+}
+
+type TLSConfig struct {
+	CertFile          string `yaml:"cert_file"`
+	KeyFile           string `yaml:"key_file"`
+	SelfSigned        bool   `yaml:"self_signed"`
+	ClientCAFile      string `yaml:"client_ca_file"`
+	RequireClientCert bool   `yaml:"require_client_cert"`
+}
+
+// TimeoutsConfig sets the server's timeouts. On shutdown, readiness checks
+// fail for Grace before the listener closes, so load balancers stop sending
+// requests, then in-flight requests have Drain to finish.
+type TimeoutsConfig struct {
+	Read  time.Duration `yaml:"read"`
+	Write time.Duration `yaml:"write"`
+	Idle  time.Duration `yaml:"idle"`
+	Grace time.Duration `yaml:"grace"`
+	Drain time.Duration `yaml:"drain"`
+}
+
+type AuditConfig struct {
+	Path string `yaml:"path"`
+}
+
+type SoftDeleteConfig struct {
+	Retention         time.Duration `yaml:"retention"`
+	RevisionRetention time.Duration `yaml:"revision_retention"`
+	PurgeInterval     time.Duration `yaml:"purge_interval"`
+}
+
+type OpenAPIConfig struct {
+	ValidateRequests bool `yaml:"validate_requests"`
+}
+
+type SimulationConfig struct {
+	Chaos       api.ChaosConfig      `yaml:"chaos"`
+	Operations  api.OperationsConfig `yaml:"operations"`
+	Upgrades    api.UpgradesConfig   `yaml:"upgrades"`
+	Migrations  api.MigrationsConfig `yaml:"migrations"`
+	Autoscaling api.AutoscalerConfig `yaml:"autoscaling"`
+	Scheduler   api.SchedulerConfig  `yaml:"scheduler"`
+}
+
+func defaultConfig() Config {
+	return Config{
+		ListenAddress: ":12345",
+		Storage: StorageConfig{
+			Backend: "memory",
+		},
+		Timeouts: TimeoutsConfig{
+			Read:  10 * time.Second,
+			Write: 30 * time.Second,
+			Idle:  120 * time.Second,
+			Grace: 5 * time.Second,
+			Drain: 30 * time.Second,
+		},
+		SoftDelete: SoftDeleteConfig{
+			Retention:         72 * time.Hour,
+			RevisionRetention: 7 * 24 * time.Hour,
+			PurgeInterval:     time.Minute,
+		},
+		Versions: map[string]api.VersionPolicy{
+			// The unversioned routes were deprecated when /v1 was added.
+			api.LegacyVersion: {DeprecatedAt: time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)},
+		},
+		Placement: api.PlacementConfig{
+			Strategy: api.PlacementBestFit,
+		},
+		Simulation: SimulationConfig{
+			Operations: api.OperationsConfig{
+				Duration:  5 * time.Second,
+				Retention: time.Hour,
+			},
+			Upgrades: api.UpgradesConfig{
+				NodeDuration: 2 * time.Second,
+			},
+			Migrations: api.MigrationsConfig{
+				StepDuration: 2 * time.Second,
+			},
+			Autoscaling: api.AutoscalerConfig{
+				Interval: 10 * time.Second,
+			},
+			Scheduler: api.SchedulerConfig{
+				Interval: 15 * time.Second,
+			},
+		},
+	}
+}
+
+// loadConfig builds the Config from, in increasing order of precedence, the
+// defaults, the config file at path (YAML or JSON), EDISON_* environment
+// variables, and any flags explicitly set on fs.
+func loadConfig(path string, fs *flag.FlagSet) (Config, error) {
+	config := defaultConfig()
+	if path != "" {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return config, fmt.Errorf("error reading config file: %w", err)
+		}
+		err = yaml.UnmarshalStrict(b, &config)
+		if err != nil {
+			return config, fmt.Errorf("error parsing config file: %w", err)
+		}
+	}
+	err := applyEnv(&config)
+	if err != nil {
+		return config, err
+	}
+	err = applyFlags(&config, fs)
+	if err != nil {
+		return config, err
+	}
+	return config, nil
+}
+
+func applyEnv(config *Config) error {
+	var errs []string
+	str := func(name string, target *string) {
+		if v, ok := os.LookupEnv(name); ok {
+			*target = v
+		}
+	}
+	boolean := func(name string, target *bool) {
+		if v, ok := os.LookupEnv(name); ok {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				errs = append(errs, name+": "+err.Error())
+				return
+			}
+			*target = b
+		}
+	}
+	dur := func(name string, target *time.Duration) {
+		if v, ok := os.LookupEnv(name); ok {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				errs = append(errs, name+": "+err.Error())
+				return
+			}
+			*target = d
+		}
+	}
+	str("EDISON_LISTEN_ADDRESS", &config.ListenAddress)
+	str("EDISON_BASE_PATH", &config.BasePath)
+	str("EDISON_STORAGE_BACKEND", &config.Storage.Backend)
+	str("EDISON_TOKEN_FILE", &config.TokenFile)
+	str("EDISON_TLS_CERT_FILE", &config.TLS.CertFile)
+	str("EDISON_TLS_KEY_FILE", &config.TLS.KeyFile)
+	boolean("EDISON_TLS_SELF_SIGNED", &config.TLS.SelfSigned)
+	str("EDISON_TLS_CLIENT_CA_FILE", &config.TLS.ClientCAFile)
+	boolean("EDISON_TLS_REQUIRE_CLIENT_CERT", &config.TLS.RequireClientCert)
+	str("EDISON_AUDIT_LOG", &config.Audit.Path)
+	dur("EDISON_READ_TIMEOUT", &config.Timeouts.Read)
+	dur("EDISON_WRITE_TIMEOUT", &config.Timeouts.Write)
+	dur("EDISON_IDLE_TIMEOUT", &config.Timeouts.Idle)
+	dur("EDISON_SHUTDOWN_GRACE", &config.Timeouts.Grace)
+	dur("EDISON_DRAIN_TIMEOUT", &config.Timeouts.Drain)
+	dur("EDISON_SOFT_DELETE_RETENTION", &config.SoftDelete.Retention)
+	dur("EDISON_REVISION_RETENTION", &config.SoftDelete.RevisionRetention)
+	dur("EDISON_PURGE_INTERVAL", &config.SoftDelete.PurgeInterval)
+	if v, ok := os.LookupEnv("EDISON_RATE_LIMIT_RPS"); ok {
+		rps, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			errs = append(errs, "EDISON_RATE_LIMIT_RPS: "+err.Error())
+		}
+		config.RateLimit.RequestsPerSecond = rps
+	}
+	if v, ok := os.LookupEnv("EDISON_RATE_LIMIT_BURST"); ok {
+		burst, err := strconv.Atoi(v)
+		if err != nil {
+			errs = append(errs, "EDISON_RATE_LIMIT_BURST: "+err.Error())
+		}
+		config.RateLimit.Burst = burst
+	}
+	boolean("EDISON_VALIDATE_REQUESTS", &config.OpenAPI.ValidateRequests)
+	if v, ok := os.LookupEnv("EDISON_PLACEMENT_STRATEGY"); ok {
+		config.Placement.Strategy = v
+	}
+	dur("EDISON_OPERATION_DURATION", &config.Simulation.Operations.Duration)
+	dur("EDISON_OPERATION_RETENTION", &config.Simulation.Operations.Retention)
+	dur("EDISON_UPGRADE_NODE_DURATION", &config.Simulation.Upgrades.NodeDuration)
+	dur("EDISON_MIGRATION_STEP_DURATION", &config.Simulation.Migrations.StepDuration)
+	dur("EDISON_AUTOSCALE_INTERVAL", &config.Simulation.Autoscaling.Interval)
+	dur("EDISON_SCHEDULE_INTERVAL", &config.Simulation.Scheduler.Interval)
+	boolean("EDISON_CHAOS_ENABLED", &config.Simulation.Chaos.Enabled)
+	if v, ok := os.LookupEnv("EDISON_CHAOS_SEED"); ok {
+		seed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			errs = append(errs, "EDISON_CHAOS_SEED: "+err.Error())
+		}
+		config.Simulation.Chaos.Seed = seed
+	}
+	if v, ok := os.LookupEnv("EDISON_CHAOS_READ_LAG_MS"); ok {
+		lag, err := strconv.Atoi(v)
+		if err != nil {
+			errs = append(errs, "EDISON_CHAOS_READ_LAG_MS: "+err.Error())
+		}
+		config.Simulation.Chaos.ReadLagMS = lag
+	}
+	if len(errs) > 0 {
+		return errors.New("invalid environment: " + strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func applyFlags(config *Config, fs *flag.FlagSet) error {
+	var rule api.ChaosRule
+	var setRule bool
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		get := f.Value.(flag.Getter).Get()
+		switch f.Name {
+		case "listen":
+			config.ListenAddress = get.(string)
+		case "base-path":
+			config.BasePath = get.(string)
+		case "storage":
+			config.Storage.Backend = get.(string)
+		case "token-file":
+			config.TokenFile = get.(string)
+		case "tls-cert":
+			config.TLS.CertFile = get.(string)
+		case "tls-key":
+			config.TLS.KeyFile = get.(string)
+		case "tls-self-signed":
+			config.TLS.SelfSigned = get.(bool)
+		case "tls-client-ca":
+			config.TLS.ClientCAFile = get.(string)
+		case "tls-require-client-cert":
+			config.TLS.RequireClientCert = get.(bool)
+		case "audit-log":
+			config.Audit.Path = get.(string)
+		case "read-timeout":
+			config.Timeouts.Read = get.(time.Duration)
+		case "write-timeout":
+			config.Timeouts.Write = get.(time.Duration)
+		case "idle-timeout":
+			config.Timeouts.Idle = get.(time.Duration)
+		case "shutdown-grace":
+			config.Timeouts.Grace = get.(time.Duration)
+		case "drain-timeout":
+			config.Timeouts.Drain = get.(time.Duration)
+		case "soft-delete-retention":
+			config.SoftDelete.Retention = get.(time.Duration)
+		case "revision-retention":
+			config.SoftDelete.RevisionRetention = get.(time.Duration)
+		case "purge-interval":
+			config.SoftDelete.PurgeInterval = get.(time.Duration)
+		case "rate-limit-rps":
+			config.RateLimit.RequestsPerSecond = get.(float64)
+		case "rate-limit-burst":
+			config.RateLimit.Burst = get.(int)
+		case "validate-requests":
+			config.OpenAPI.ValidateRequests = get.(bool)
+		case "placement-strategy":
+			config.Placement.Strategy = get.(string)
+		case "operation-duration":
+			config.Simulation.Operations.Duration = get.(time.Duration)
+		case "operation-retention":
+			config.Simulation.Operations.Retention = get.(time.Duration)
+		case "upgrade-node-duration":
+			config.Simulation.Upgrades.NodeDuration = get.(time.Duration)
+		case "migration-step-duration":
+			config.Simulation.Migrations.StepDuration = get.(time.Duration)
+		case "autoscale-interval":
+			config.Simulation.Autoscaling.Interval = get.(time.Duration)
+		case "schedule-interval":
+			config.Simulation.Scheduler.Interval = get.(time.Duration)
+		case "chaos-config":
+			var chaos api.ChaosConfig
+			var b []byte
+			b, err = ioutil.ReadFile(get.(string))
+			if err != nil {
+				err = fmt.Errorf("error reading chaos config: %w", err)
+				return
+			}
+			err = yaml.UnmarshalStrict(b, &chaos)
+			if err != nil {
+				err = fmt.Errorf("error parsing chaos config: %w", err)
+				return
+			}
+			chaos.Enabled = true
+			config.Simulation.Chaos = chaos
+		case "chaos-seed":
+			config.Simulation.Chaos.Seed = get.(int64)
+		case "chaos-read-lag-ms":
+			config.Simulation.Chaos.ReadLagMS = get.(int)
+			config.Simulation.Chaos.Enabled = true
+		case "chaos-latency-ms":
+			rule.LatencyMS = get.(int)
+			setRule = true
+		case "chaos-error-rate":
+			rule.ErrorRate = get.(float64)
+			setRule = true
+		case "chaos-drop-rate":
+			rule.DropRate = get.(float64)
+			setRule = true
+		case "chaos-truncate-rate":
+			rule.TruncateRate = get.(float64)
+			setRule = true
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if setRule {
+		config.Simulation.Chaos.Rules = append(config.Simulation.Chaos.Rules, rule)
+		config.Simulation.Chaos.Enabled = true
+	}
+	return nil
+}
+
+// Validate returns an error describing every problem with the Config.
+func (c Config) Validate() error {
+	var errs []string
+	if _, _, err := net.SplitHostPort(c.ListenAddress); err != nil {
+		errs = append(errs, "listen_address: "+err.Error())
+	}
+	if c.BasePath != "" && (!strings.HasPrefix(c.BasePath, "/") || strings.HasSuffix(c.BasePath, "/")) {
+		errs = append(errs, "base_path: must start with / and must not end with /")
+	}
+	if c.Storage.Backend != "memory" {
+		errs = append(errs, fmt.Sprintf("storage.backend: unsupported backend %q", c.Storage.Backend))
+	}
+	if c.TokenFile != "" {
+		tokens, err := api.LoadTokens(c.TokenFile)
+		if err != nil {
+			errs = append(errs, "token_file: "+err.Error())
+		} else if len(tokens) < 1 {
+			errs = append(errs, "token_file: no tokens defined")
+		}
+		for i, t := range tokens {
+			if t.RateLimit != nil {
+				errs = append(errs, validateRateLimit(fmt.Sprintf("token_file: tokens[%d].rate_limit", i), *t.RateLimit)...)
+			}
+		}
+	}
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		errs = append(errs, "tls: cert_file and key_file must be set together")
+	}
+	if c.TLS.SelfSigned && c.TLS.CertFile != "" {
+		errs = append(errs, "tls: self_signed can't be used with cert_file")
+	}
+	if c.TLS.ClientCAFile != "" && !c.TLS.enabled() {
+		errs = append(errs, "tls.client_ca_file: requires cert_file or self_signed")
+	}
+	if c.TLS.RequireClientCert && c.TLS.ClientCAFile == "" {
+		errs = append(errs, "tls.require_client_cert: requires client_ca_file")
+	}
+	for name, d := range map[string]time.Duration{
+		"read":  c.Timeouts.Read,
+		"write": c.Timeouts.Write,
+		"idle":  c.Timeouts.Idle,
+		"grace": c.Timeouts.Grace,
+		"drain": c.Timeouts.Drain,
+	} {
+		if d < 0 {
+			errs = append(errs, "timeouts."+name+": must not be negative")
+		}
+	}
+	if c.SoftDelete.Retention < 0 {
+		errs = append(errs, "soft_delete.retention: must not be negative")
+	}
+	if c.SoftDelete.RevisionRetention < 0 {
+		errs = append(errs, "soft_delete.revision_retention: must not be negative")
+	}
+	if (c.SoftDelete.Retention > 0 || c.SoftDelete.RevisionRetention > 0) && c.SoftDelete.PurgeInterval <= 0 {
+		errs = append(errs, "soft_delete.purge_interval: must be positive when retention or revision_retention is set")
+	}
+	errs = append(errs, validateRateLimit("rate_limit", c.RateLimit)...)
+	errs = append(errs, validateQuota("quotas.default", c.Quotas.Default)...)
+	for account, quota := range c.Quotas.Accounts {
+		errs = append(errs, validateQuota(fmt.Sprintf("quotas.accounts[%q]", account), quota)...)
+	}
+	for version, policy := range c.Versions {
+		errs = append(errs, validateVersionPolicy(version, policy)...)
+	}
+	switch c.Placement.Strategy {
+	case api.PlacementBestFit, api.PlacementFirstFit, api.PlacementWorstFit:
+	default:
+		errs = append(errs, fmt.Sprintf("placement.strategy: must be one of %s", strings.Join(api.PlacementStrategies, ", ")))
+	}
+	if c.Simulation.Operations.Duration < 0 {
+		errs = append(errs, "simulation.operations.duration: must not be negative")
+	}
+	if c.Simulation.Operations.Retention < 0 {
+		errs = append(errs, "simulation.operations.retention: must not be negative")
+	}
+	for kind, d := range c.Simulation.Operations.Kinds {
+		if d < 0 {
+			errs = append(errs, fmt.Sprintf("simulation.operations.kinds[%q]: must not be negative", kind))
+		}
+	}
+	if c.Simulation.Upgrades.NodeDuration < 0 {
+		errs = append(errs, "simulation.upgrades.node_duration: must not be negative")
+	}
+	if c.Simulation.Migrations.StepDuration <= 0 {
+		errs = append(errs, "simulation.migrations.step_duration: must be positive")
+	}
+	if c.Simulation.Autoscaling.Interval <= 0 {
+		errs = append(errs, "simulation.autoscaling.interval: must be positive")
+	}
+	if c.Simulation.Scheduler.Interval <= 0 {
+		errs = append(errs, "simulation.scheduler.interval: must be positive")
+	}
+	if c.Simulation.Chaos.ReadLagMS < 0 {
+		errs = append(errs, "simulation.chaos.read_lag_ms: must not be negative")
+	}
+	for i, rule := range c.Simulation.Chaos.Rules {
+		for name, rate := range map[string]float64{
+			"error_rate":    rule.ErrorRate,
+			"drop_rate":     rule.DropRate,
+			"truncate_rate": rule.TruncateRate,
+		} {
+			if rate < 0 || rate > 1 {
+				errs = append(errs, fmt.Sprintf("simulation.chaos.rules[%d].%s: must be between 0 and 1", i, name))
+			}
+		}
+		if rule.LatencyMS < 0 {
+			errs = append(errs, fmt.Sprintf("simulation.chaos.rules[%d].latency_ms: must not be negative", i))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New("invalid configuration:\n  " + strings.Join(errs, "\n  "))
+	}
+	return nil
+}
+
+func validateRateLimit(name string, limit api.RateLimit) []string {
+	var errs []string
+	if limit.RequestsPerSecond < 0 {
+		errs = append(errs, name+".requests_per_second: must not be negative")
+	}
+	if limit.Burst < 0 {
+		errs = append(errs, name+".burst: must not be negative")
+	}
+	return errs
+}
+
+func validateVersionPolicy(version string, policy api.VersionPolicy) []string {
+	name := fmt.Sprintf("versions[%q]", version)
+	known := false
+	for _, v := range api.Versions {
+		if v == version {
+			known = true
+		}
+	}
+	if !known {
+		return []string{name + ": unknown version, must be one of " + strings.Join(api.Versions, ", ")}
+	}
+	var errs []string
+	if version == api.CurrentVersion && !policy.SunsetAt.IsZero() {
+		errs = append(errs, name+".sunset_at: the current version can't be sunset")
+	}
+	if !policy.SunsetAt.IsZero() && policy.SunsetAt.Before(policy.DeprecatedAt) {
+		errs = append(errs, name+".sunset_at: must not be before deprecated_at")
+	}
+	return errs
+}
+
+func validateQuota(name string, quota api.Quota) []string {
+	var errs []string
+	for field, limit := range map[string]int64{
+		"ehs_clusters_per_region": quota.EHSClustersPerRegion,
+		"partition_space_tb":      quota.PartitionSpaceTB,
+		"concurrent_users":        quota.ConcurrentUsers,
+		"av_tenants":              quota.AVTenants,
+	} {
+		if limit < 0 {
+			errs = append(errs, name+"."+field+": must not be negative")
+		}
+	}
+	return errs
+}
+
+// tokens returns the tokens the API should accept under this Config.
+func (c Config) tokens() ([]api.Token, error) {
+	if c.TokenFile == "" {
+		return api.DefaultTokens, nil
+	}
+	return api.LoadTokens(c.TokenFile)
+}
+
+// unsafeChanges lists settings that differ between c and next but can't be
+// applied without a restart.
+func (c Config) unsafeChanges(next Config) []string {
+	var changed []string
+	if c.ListenAddress != next.ListenAddress {
+		changed = append(changed, "listen_address")
+	}
+	if c.BasePath != next.BasePath {
+		changed = append(changed, "base_path")
+	}
+	if c.Storage != next.Storage {
+		changed = append(changed, "storage")
+	}
+	if c.TLS != next.TLS {
+		changed = append(changed, "tls")
+	}
+	if c.Timeouts != next.Timeouts {
+		changed = append(changed, "timeouts")
+	}
+	if c.Audit != next.Audit {
+		changed = append(changed, "audit")
+	}
+	if c.SoftDelete != next.SoftDelete {
+		changed = append(changed, "soft_delete")
+	}
+	if c.OpenAPI != next.OpenAPI {
+		changed = append(changed, "openapi")
+	}
+	// Chaos is only served if it was enabled at startup.
+	if !c.Simulation.Chaos.Enabled && next.Simulation.Chaos.Enabled {
+		changed = append(changed, "simulation.chaos.enabled")
+	}
+	if len(c.Versions) != len(next.Versions) {
+		changed = append(changed, "versions")
+	} else {
+		for version, policy := range c.Versions {
+			if !policy.DeprecatedAt.Equal(next.Versions[version].DeprecatedAt) || !policy.SunsetAt.Equal(next.Versions[version].SunsetAt) {
+				changed = append(changed, "versions")
+				break
+			}
+		}
+	}
+	return changed
+}
@@ -1,27 +1,226 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/rahoolp/terraform-provider-edison/internal/api"
 )
 
 func main() {
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	configFile := fs.String("config", os.Getenv("EDISON_CONFIG"), "path to a YAML or JSON config file")
+	fs.String("listen", "", "address to listen on")
+	fs.String("base-path", "", "path prefix for every route")
+	fs.String("storage", "", "storage backend")
+	fs.String("token-file", "", "path to a YAML or JSON file of accepted tokens")
+	fs.String("tls-cert", "", "path to a PEM-encoded TLS certificate")
+	fs.String("tls-key", "", "path to a PEM-encoded TLS private key")
+	fs.Bool("tls-self-signed", false, "serve TLS with a generated self-signed certificate (development only)")
+	fs.String("tls-client-ca", "", "path to PEM-encoded CA certificates used to verify client certificates")
+	fs.Bool("tls-require-client-cert", false, "reject clients that don't present a verified certificate")
+	fs.String("audit-log", "", "path to the append-only JSONL audit log")
+	fs.Duration("soft-delete-retention", 0, "how long deleted resources can be restored before they're purged; 0 makes deletes permanent")
+	fs.Duration("revision-retention", 0, "how long revisions are kept before they're pruned; 0 keeps them forever")
+	fs.Duration("purge-interval", 0, "how often to purge deleted resources and revisions past their retention")
+	fs.Float64("rate-limit-rps", 0, "default requests per second allowed per token and route; 0 is unlimited")
+	fs.Int("rate-limit-burst", 0, "default burst of requests allowed per token and route")
+	fs.Bool("validate-requests", false, "reject requests that don't match the OpenAPI document")
+	fs.String("placement-strategy", "", "how AWs created without an EHS cluster are placed: best_fit, first_fit or worst_fit")
+	fs.Duration("read-timeout", 0, "maximum duration for reading a request")
+	fs.Duration("write-timeout", 0, "maximum duration for writing a response")
+	fs.Duration("idle-timeout", 0, "how long idle keep-alive connections are kept open")
+	fs.Duration("shutdown-grace", 0, "how long readiness checks fail on shutdown before the listener closes")
+	fs.Duration("drain-timeout", 0, "how long to wait for in-flight requests on shutdown")
+	fs.Duration("operation-duration", 0, "how long operations started with Prefer: respond-async take to finish")
+	fs.Duration("operation-retention", 0, "how long finished operations are kept; 0 keeps them forever")
+	fs.Duration("upgrade-node-duration", 0, "how long rolling upgrades take to upgrade each EHS cluster node")
+	fs.Duration("migration-step-duration", 0, "how long each step of migrating an AW between EHS clusters takes")
+	fs.Duration("autoscale-interval", 0, "how often the autoscaler reconciles EHS cluster nodes with the AWs they host")
+	fs.Duration("schedule-interval", 0, "how often AW schedules are checked for seat changes that are due")
+	fs.String("chaos-config", "", "path to a YAML or JSON fault-injection config")
+	fs.Int64("chaos-seed", 0, "seed for fault injection")
+	fs.Int("chaos-latency-ms", 0, "latency to add to every request, in milliseconds")
+	fs.Float64("chaos-error-rate", 0, "fraction of requests that fail with a server error")
+	fs.Float64("chaos-drop-rate", 0, "fraction of requests whose connection is dropped")
+	fs.Float64("chaos-truncate-rate", 0, "fraction of responses whose body is truncated")
+	fs.Int("chaos-read-lag-ms", 0, "how long newly created resources return 404, in milliseconds")
+	fs.Parse(os.Args[1:]) //nolint:errcheck
+
+	config, err := loadConfig(*configFile, fs)
+	if err == nil {
+		err = config.Validate()
+	}
+	if err != nil {
+		log.Println("Error loading config:", err.Error())
+		os.Exit(1)
+	}
+	tokens, err := config.tokens()
+	if err != nil {
+		log.Println("Error loading tokens:", err.Error())
+		os.Exit(1)
+	}
+
+	tlsConfig, err := config.TLS.serverConfig()
+	if err != nil {
+		log.Println("Error setting up TLS:", err.Error())
+		os.Exit(1)
+	}
+
 	storer, err := api.NewStorer()
 	if err != nil {
 		log.Println("Error setting up storer:", err.Error())
 		os.Exit(1)
 	}
+	storer.SetQuotas(config.Quotas)
+	audit, err := api.NewAuditLog(config.Audit.Path)
+	if err != nil {
+		log.Println("Error opening audit log:", err.Error())
+		os.Exit(1)
+	}
+	webhooks := api.NewWebhooks(storer)
 	a := api.API{
-		Storer: storer,
+		Storer:   storer,
+		Auth:     api.NewAuth(tokens),
+		Audit:    audit,
+		Webhooks: webhooks,
+		Limiter:  api.NewRateLimiter(config.RateLimit),
+
+		Retention:         config.SoftDelete.Retention,
+		RevisionRetention: config.SoftDelete.RevisionRetention,
+		MaxWait:           maxWait(config.Timeouts.Write),
+
+		ValidateRequests: config.OpenAPI.ValidateRequests,
+		VersionPolicies:  config.Versions,
+		Placement:        api.NewPlacement(config.Placement),
+	}
+	if config.Simulation.Chaos.Enabled {
+		a.Chaos = api.NewChaos(config.Simulation.Chaos)
+	}
+	a.Metrics = api.NewMetrics(storer)
+	a.Operations = api.NewOperations(storer, a.Metrics, config.Simulation.Operations)
+	a.Upgrades = api.NewUpgrades(storer, a.Operations, config.Simulation.Upgrades)
+	a.Migrations = api.NewMigrations(storer, a.Operations, config.Simulation.Migrations)
+	a.Autoscaler = api.NewAutoscaler(storer, config.Simulation.Autoscaling)
+	a.Scheduler = api.NewScheduler(storer, a.Autoscaler, config.Simulation.Scheduler)
+
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	go a.RunPurger(bgCtx, config.SoftDelete.PurgeInterval)
+	go webhooks.Run(bgCtx)
+	go a.Autoscaler.Run(bgCtx)
+	go a.Scheduler.Run(bgCtx)
+
+	srv := &http.Server{
+		Addr:              config.ListenAddress,
+		Handler:           a.Server(config.BasePath),
+		ReadTimeout:       config.Timeouts.Read,
+		ReadHeaderTimeout: config.Timeouts.Read,
+		WriteTimeout:      config.Timeouts.Write,
+		IdleTimeout:       config.Timeouts.Idle,
+		TLSConfig:         tlsConfig,
+		ConnContext:       api.ConnContext,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if tlsConfig != nil {
+			serveErr <- srv.ListenAndServeTLS("", "")
+			return
+		}
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP)
+
+	for {
+		select {
+		case err = <-serveErr:
+			log.Println("Error listening and serving:", err.Error())
+			os.Exit(1)
+		case sig := <-sigs:
+			if sig == syscall.SIGHUP {
+				config = reload(config, *configFile, fs, a)
+				continue
+			}
+			log.Printf("Received %s, failing readiness checks for %s, then draining for up to %s", sig, config.Timeouts.Grace, config.Timeouts.Drain)
+		}
+		break
 	}
 
-	http.Handle("/", a.Server(""))
-	err = http.ListenAndServe(":12345", nil)
+	// Fail readiness checks before closing the listener, so load balancers
+	// stop sending requests that would be refused.
+	err = storer.Close()
 	if err != nil {
-		log.Println("Error listening and serving:", err.Error())
+		log.Println("Error closing storer:", err.Error())
 		os.Exit(1)
 	}
+	time.Sleep(config.Timeouts.Grace)
+	ctx, cancel := context.WithTimeout(context.Background(), config.Timeouts.Drain)
+	defer cancel()
+	err = srv.Shutdown(ctx)
+	if err != nil {
+		log.Println("Error draining requests:", err.Error())
+	}
+	stopBackground()
+	err = audit.Close()
+	if err != nil {
+		log.Println("Error closing audit log:", err.Error())
+		os.Exit(1)
+	}
+}
+
+// maxWait keeps blocking queries short enough to answer before the write
+// timeout cuts the connection.
+func maxWait(writeTimeout time.Duration) time.Duration {
+	if writeTimeout <= 0 {
+		return 0
+	}
+	return writeTimeout * 9 / 10
+}
+
+// reload re-reads the configuration and applies the settings that are safe
+// to change while serving. If the new configuration is invalid, the current
+// one is kept.
+func reload(current Config, path string, fs *flag.FlagSet, a api.API) Config {
+	next, err := loadConfig(path, fs)
+	if err == nil {
+		err = next.Validate()
+	}
+	if err != nil {
+		log.Println("Error reloading config, keeping current config:", err.Error())
+		return current
+	}
+	tokens, err := next.tokens()
+	if err != nil {
+		log.Println("Error reloading tokens, keeping current config:", err.Error())
+		return current
+	}
+	if changed := current.unsafeChanges(next); len(changed) > 0 {
+		log.Println("Ignoring changes that require a restart:", strings.Join(changed, ", "))
+	}
+	a.Auth.SetTokens(tokens)
+	a.Limiter.SetDefault(next.RateLimit)
+	a.Storer.SetQuotas(next.Quotas)
+	a.Chaos.SetConfig(next.Simulation.Chaos)
+	a.Operations.SetConfig(next.Simulation.Operations)
+	a.Upgrades.SetConfig(next.Simulation.Upgrades)
+	a.Migrations.SetConfig(next.Simulation.Migrations)
+	a.Autoscaler.SetConfig(next.Simulation.Autoscaling)
+	a.Scheduler.SetConfig(next.Simulation.Scheduler)
+	a.Placement.SetConfig(next.Placement)
+	current.TokenFile = next.TokenFile
+	current.RateLimit = next.RateLimit
+	current.Quotas = next.Quotas
+	current.Simulation = next.Simulation
+	current.Placement = next.Placement
+	log.Println("Reloaded config")
+	return current
 }
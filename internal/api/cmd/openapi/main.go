@@ -0,0 +1,105 @@
+// Command openapi prints the OpenAPI document edisond serves at
+// /openapi.json. With -check, it instead verifies that the document
+// describes every route edisond serves, and that the client's types agree
+// with the document's schemas, exiting non-zero if they don't.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/rahoolp/terraform-provider-edison/internal/api"
+	edison "github.com/rahoolp/terraform-provider-edison/internal/client"
+)
+
+func main() {
+	basePath := flag.String("base-path", "", "path prefix for every route")
+	check := flag.Bool("check", false, "check the document against the API and client instead of printing it")
+	flag.Parse()
+
+	storer, err := api.NewStorer()
+	if err != nil {
+		log.Println("Error setting up storer:", err.Error())
+		os.Exit(1)
+	}
+	audit, err := api.NewAuditLog("")
+	if err != nil {
+		log.Println("Error setting up audit log:", err.Error())
+		os.Exit(1)
+	}
+	// Enable everything optional, so the whole API is described.
+	a := api.API{
+		Storer:   storer,
+		Chaos:    api.NewChaos(api.ChaosConfig{}),
+		Auth:     api.NewAuth(api.DefaultTokens),
+		Metrics:  api.NewMetrics(storer),
+		Audit:    audit,
+		Webhooks: api.NewWebhooks(storer),
+	}
+	a.Operations = api.NewOperations(storer, a.Metrics, api.OperationsConfig{})
+	a.Upgrades = api.NewUpgrades(storer, a.Operations, api.UpgradesConfig{})
+	a.Migrations = api.NewMigrations(storer, a.Operations, api.MigrationsConfig{})
+	a.Scheduler = api.NewScheduler(storer, nil, api.SchedulerConfig{})
+	doc := a.OpenAPI(*basePath)
+
+	if !*check {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(doc)
+		if err != nil {
+			log.Println("Error writing document:", err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	failed := false
+	err = a.CheckOpenAPI(doc)
+	if err != nil {
+		log.Println(err.Error())
+		failed = true
+	}
+	for name, v := range map[string]interface{}{
+		"EAStore":         edison.EAStore{},
+		"EHSCluster":      edison.EHSCluster{},
+		"AW":              edison.AW{},
+		"AV":              edison.AV{},
+		"AuditEvent":      edison.AuditEvent{},
+		"AuditChange":     edison.AuditChange{},
+		"Revision":        edison.Revision{},
+		"Webhook":         edison.Webhook{},
+		"Delivery":        edison.Delivery{},
+		"QuotaUsage":      edison.QuotaUsage{},
+		"RequestError":    edison.RequestError{},
+		"Capabilities":    edison.Capabilities{},
+		"Version":         edison.Version{},
+		"Operation":       edison.Operation{},
+		"Upgrade":         edison.Upgrade{},
+		"UpgradeNode":     edison.UpgradeNode{},
+		"Migration":       edison.Migration{},
+		"MigrationStep":   edison.MigrationStep{},
+		"Capacity":        edison.Capacity{},
+		"Release":         edison.Release{},
+		"Region":          edison.Region{},
+		"Profile":         edison.Profile{},
+		"InstanceSize":    edison.InstanceSize{},
+		"NodePool":        edison.NodePool{},
+		"Taint":           edison.Taint{},
+		"Autoscaling":     edison.Autoscaling{},
+		"AWSchedule":      edison.AWSchedule{},
+		"ScheduleRule":    edison.ScheduleRule{},
+		"ScheduledAction": edison.ScheduledAction{},
+	} {
+		err = api.CheckOpenAPISchema(doc, name, v)
+		if err != nil {
+			log.Println(err.Error())
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+	log.Println("OpenAPI document matches the API and client")
+}
@@ -0,0 +1,46 @@
+// Command webhook-receiver is a local endpoint for testing edisond webhooks.
+// It logs every delivery it receives, and rejects any whose signature doesn't
+// match the secret.
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/rahoolp/terraform-provider-edison/internal/api"
+)
+
+func main() {
+	listen := flag.String("listen", ":12346", "address to listen on")
+	secret := flag.String("secret", os.Getenv("EDISON_WEBHOOK_SECRET"), "secret the webhook was registered with")
+	fail := flag.Bool("fail", false, "respond to every delivery with an error, to exercise retries")
+	flag.Parse()
+
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			log.Println("Error reading delivery:", err.Error())
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if !api.VerifyWebhookSignature(*secret, body, r.Header.Get("X-Edison-Signature")) {
+			log.Printf("Rejected delivery %s: invalid signature", r.Header.Get("X-Edison-Delivery"))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		log.Printf("Received %s delivery %s: %s", r.Header.Get("X-Edison-Event"), r.Header.Get("X-Edison-Delivery"), body)
+		if *fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	err := http.ListenAndServe(*listen, nil)
+	if err != nil {
+		log.Println("Error listening and serving:", err.Error())
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errInvalidCron = errors.New("invalid cron expression")
+
+// cronHorizon is how far ahead a cron expression is checked for its next
+// time, so expressions that can never fire, like "0 0 30 2 *", give up.
+const cronHorizon = 5 * 366 * 24 * time.Hour
+
+// cronField is one of the five fields of a cron expression, as the bit set
+// of values it matches.
+type cronField struct {
+	min, max int
+	names    []string
+}
+
+var cronFields = []cronField{
+	{min: 0, max: 59},
+	{min: 0, max: 23},
+	{min: 1, max: 31},
+	{min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// cronExpr is a standard five field cron expression: minute, hour, day of
+// month, month and day of week. Fields can be "*", values, ranges, lists and
+// steps, like "*/15", "1-5" or "mon,wed,fri". Like cron, when both the day
+// of month and day of week are restricted, a day matching either matches.
+// Times skipped when the clocks go forward don't match, and times repeated
+// when they go back only match the first time.
+type cronExpr struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+func parseCron(expr string) (cronExpr, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return cronExpr{}, errInvalidCron
+	}
+	var sets [5]uint64
+	for i, field := range fields {
+		set, err := cronFields[i].parse(strings.ToLower(field))
+		if err != nil {
+			return cronExpr{}, err
+		}
+		sets[i] = set
+	}
+	// Sunday is 0 or 7.
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+	return cronExpr{
+		minute: sets[0],
+		hour:   sets[1],
+		dom:    sets[2],
+		month:  sets[3],
+		dow:    sets[4],
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}, nil
+}
+
+func (f cronField) parse(field string) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n < 1 {
+				return 0, errInvalidCron
+			}
+			step = n
+			part = part[:i]
+		}
+		lo, hi := f.min, f.max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			i := strings.Index(part, "-")
+			var err error
+			lo, err = f.value(part[:i])
+			if err != nil {
+				return 0, err
+			}
+			hi, err = f.value(part[i+1:])
+			if err != nil {
+				return 0, err
+			}
+			if hi < lo {
+				return 0, errInvalidCron
+			}
+		default:
+			v, err := f.value(part)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			hi = v
+			if step > 1 {
+				hi = f.max
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+func (f cronField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if s == name {
+			return f.min + i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, errInvalidCron
+	}
+	return v, nil
+}
+
+func (c cronExpr) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	}
+	return dom || dow
+}
+
+// next returns the first time after after, in after's location, that the
+// expression matches. It reports false if there isn't one within
+// cronHorizon.
+func (c cronExpr) next(after time.Time) (time.Time, bool) {
+	loc := after.Location()
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := after.Add(cronHorizon)
+	for t.Before(limit) {
+		var next time.Time
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			next = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.dayMatches(t):
+			next = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			next = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			next = t.Add(time.Minute)
+		case !wallClock(t).After(wallClock(after)):
+			// The clocks went back, and this time already came round
+			// before they did.
+			next = t.Add(time.Minute)
+		default:
+			return t, true
+		}
+		// time.Date picks a time before a DST gap for times in it, so
+		// step over the gap instead of going back.
+		if !next.After(t) {
+			next = t.Add(time.Hour)
+		}
+		t = next
+	}
+	return time.Time{}, false
+}
+
+// wallClock returns the time t's clock shows, to the minute, ignoring its
+// offset.
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+}
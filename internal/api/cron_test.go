@@ -0,0 +1,102 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"0 0 * * funday",
+		"a * * * *",
+	} {
+		if _, err := parseCron(expr); err != errInvalidCron {
+			t.Errorf("parseCron(%q): expected %v, got %v", expr, errInvalidCron, err)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	chicago, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		t.Skipf("Time zone data isn't available: %s", err)
+	}
+	utc := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, time.UTC)
+	}
+	cdt := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, time.FixedZone("CDT", -5*60*60))
+	}
+	cst := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, time.FixedZone("CST", -6*60*60))
+	}
+	tests := []struct {
+		name  string
+		expr  string
+		after time.Time
+		want  time.Time
+	}{
+		{"every minute", "* * * * *", utc(time.October, 19, 10, 7).Add(30 * time.Second), utc(time.October, 19, 10, 8)},
+		{"step", "*/15 * * * *", utc(time.October, 19, 10, 7), utc(time.October, 19, 10, 15)},
+		{"step from a value", "5/20 * * * *", utc(time.October, 19, 10, 30), utc(time.October, 19, 10, 45)},
+		{"step over a range", "0 9-17/4 * * *", utc(time.October, 19, 13, 0), utc(time.October, 19, 17, 0)},
+		{"step wraps to the next day", "0 */6 * * *", utc(time.October, 19, 18, 0), utc(time.October, 20, 0, 0)},
+		{"list", "0 8,12,18 * * *", utc(time.October, 19, 12, 0), utc(time.October, 19, 18, 0)},
+		{"weekdays by name", "0 7 * * mon-fri", utc(time.October, 17, 12, 0), utc(time.October, 19, 7, 0)},
+		{"sunday as 7", "0 0 * * 7", utc(time.October, 19, 0, 0), utc(time.October, 25, 0, 0)},
+		{"month by name", "0 0 1 jan *", utc(time.October, 19, 0, 0), time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"day of month skips short months", "0 0 31 * *", utc(time.October, 31, 0, 0), utc(time.December, 31, 0, 0)},
+		{"day of month or week, week first", "0 0 13 * fri", utc(time.October, 19, 0, 0), utc(time.October, 23, 0, 0)},
+		{"day of month or week, month first", "0 0 13 * fri", utc(time.November, 7, 0, 0), utc(time.November, 13, 0, 0)},
+		{"day of week only", "0 0 * * fri", utc(time.November, 7, 0, 0), utc(time.November, 13, 0, 0)},
+		{"skipped when the clocks go forward", "30 2 * * *", cst(time.March, 7, 12, 0), cdt(time.March, 9, 2, 30)},
+		{"after the clocks go forward", "0 7 * * *", cst(time.March, 8, 0, 0), cdt(time.March, 8, 7, 0)},
+		{"before the clocks go back", "30 1 * * *", cdt(time.October, 31, 12, 0), cdt(time.November, 1, 1, 30)},
+		{"not repeated when the clocks go back", "30 1 * * *", cdt(time.November, 1, 1, 30), cst(time.November, 2, 1, 30)},
+		{"steps not repeated when the clocks go back", "*/15 1 * * *", cdt(time.November, 1, 1, 45), cst(time.November, 2, 1, 0)},
+		{"from the repeated hour", "0 2 * * *", cst(time.November, 1, 1, 15), cst(time.November, 1, 2, 0)},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			expr, err := parseCron(test.expr)
+			if err != nil {
+				t.Fatalf("parseCron(%q): %s", test.expr, err)
+			}
+			after := test.after
+			if test.after.Location() != time.UTC {
+				after = test.after.In(chicago)
+			}
+			got, ok := expr.next(after)
+			if !ok {
+				t.Fatalf("next(%s): no time found", after)
+			}
+			if !got.Equal(test.want) {
+				t.Errorf("next(%s): expected %s, got %s", after, test.want.In(got.Location()), got)
+			}
+			if got.Location() != after.Location() {
+				t.Errorf("next(%s): expected a time in %s, got %s", after, after.Location(), got.Location())
+			}
+		})
+	}
+}
+
+func TestCronNextNever(t *testing.T) {
+	expr, err := parseCron("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("parseCron: %s", err)
+	}
+	if got, ok := expr.next(time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)); ok {
+		t.Errorf("expected no time, got %s", got)
+	}
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"net/http"
+	"time"
 
 	"darlinggo.co/api"
 	"darlinggo.co/trout/v2"
@@ -9,18 +10,30 @@ import (
 )
 
 type EAStore struct {
-	ID               string `json:"id,omitempty"`
-	PartitionSpaceTB int64  `json:"partition_space_tb"`
-	IPAddress        string `json:"ip_address,omitempty"`
-	IPPort           string `json:"ip_port,omitempty"`
-	AET              string `json:"aet,omitempty"`
-	AccountID        string `json:"account_id,omitempty"`
-	ServiceEP        string `json:"service_ep,omitempty"`
-	CreatedAt        string `json:"created_at,omitempty"`
-	UpdatedAt        string `json:"updated_at,omitempty"`
+	ID               string            `json:"id,omitempty"`
+	PartitionSpaceTB int64             `json:"partition_space_tb"`
+	IPAddress        string            `json:"ip_address,omitempty"`
+	IPPort           string            `json:"ip_port,omitempty"`
+	AET              string            `json:"aet,omitempty"`
+	AccountID        string            `json:"account_id,omitempty"`
+	ServiceEP        string            `json:"service_ep,omitempty"`
+	Labels           map[string]string `json:"labels,omitempty"`
+	CreatedAt        string            `json:"created_at,omitempty"`
+	UpdatedAt        string            `json:"updated_at,omitempty"`
+	DeletedAt        string            `json:"deleted_at,omitempty"`
 }
 
 func (a API) handleGetEAStore(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("revision") != "" {
+		var ap EAStore
+		if a.getRevision(w, r, "eastore", &ap) {
+			api.Encode(w, r, http.StatusOK, Response{EAStores: []EAStore{ap}})
+		}
+		return
+	}
+	if !a.blockingQuery(w, r, "eastore") {
+		return
+	}
 	ap, err := a.Storer.GetEAStore(trout.RequestVars(r).Get("id"))
 	if err != nil {
 		if err == ErrEAStoreNotFound {
@@ -40,17 +53,25 @@ func (a API) handlePostEAStore(w http.ResponseWriter, r *http.Request) {
 		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
 		return
 	}
+	if errs := validateLabels(ap.Labels); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
 	ap.ID, err = uuid.GenerateUUID()
 	if err != nil {
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
+	ap.DeletedAt = ""
 	err = a.Storer.CreateEAStore(ap)
 	if err != nil {
 		if err == ErrEAStoreAlreadyExists {
 			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrConflict}}})
 			return
 		}
+		if quotaExceeded(w, r, err) {
+			return
+		}
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
@@ -64,21 +85,58 @@ func (a API) handlePutEAStore(w http.ResponseWriter, r *http.Request) {
 		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
 		return
 	}
+	if errs := validateLabels(ap.Labels); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
 	ap.ID = trout.RequestVars(r).Get("id")
+	ap.DeletedAt = ""
 	err = a.Storer.UpdateEAStore(ap)
 	if err != nil {
 		if err == ErrEAStoreNotFound {
 			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
 			return
 		}
+		if quotaExceeded(w, r, err) {
+			return
+		}
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
 	api.Encode(w, r, http.StatusOK, Response{EAStores: []EAStore{ap}})
 }
 
+func (a API) handleListEAStores(w http.ResponseWriter, r *http.Request) {
+	filter, ok := parseDeletedFilter(w, r)
+	if !ok {
+		return
+	}
+	selector, ok := parseSelector(w, r)
+	if !ok {
+		return
+	}
+	aps, err := a.Storer.ListEAStores(selector)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	results := []EAStore{}
+	for _, ap := range aps {
+		if filter.matches(ap.DeletedAt) {
+			results = append(results, ap)
+		}
+	}
+	api.Encode(w, r, http.StatusOK, Response{EAStores: results})
+}
+
 func (a API) handleDeleteEAStore(w http.ResponseWriter, r *http.Request) {
-	ap, err := a.Storer.GetEAStore(trout.RequestVars(r).Get("id"))
+	var ap EAStore
+	var err error
+	if a.softDelete(r) {
+		ap, err = a.Storer.SoftDeleteEAStore(trout.RequestVars(r).Get("id"), time.Now())
+	} else {
+		ap, err = a.Storer.DeleteEAStore(trout.RequestVars(r).Get("id"))
+	}
 	if err != nil {
 		if err == ErrEAStoreNotFound {
 			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
@@ -87,12 +145,28 @@ func (a API) handleDeleteEAStore(w http.ResponseWriter, r *http.Request) {
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
-	err = a.Storer.DeleteEAStore(trout.RequestVars(r).Get("id"))
+	api.Encode(w, r, http.StatusOK, Response{EAStores: []EAStore{ap}})
+}
+
+func (a API) handleRestoreEAStore(w http.ResponseWriter, r *http.Request) {
+	id, action := splitAction(trout.RequestVars(r).Get("id"))
+	if action != "restore" {
+		api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+		return
+	}
+	ap, err := a.Storer.RestoreEAStore(id)
 	if err != nil {
 		if err == ErrEAStoreNotFound {
 			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
 			return
 		}
+		if err == ErrEAStoreNotDeleted {
+			api.Encode(w, r, http.StatusConflict, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrConflict}}})
+			return
+		}
+		if quotaExceeded(w, r, err) {
+			return
+		}
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"net/http"
+	"time"
 
 	"darlinggo.co/api"
 	"darlinggo.co/trout/v2"
@@ -9,19 +10,42 @@ import (
 )
 
 type EHSCluster struct {
-	ID                string `json:"id,omitempty"`
-	Region            string `json:"region"`
-	Profile           string `json:"profile"`
-	Release           string `json:"release"`
-	Tag               string `json:"tag"`
-	APIServerEndPoint string `json:"api_server_endpoint,omitempty"`
-	VPC               string `json:"vpc,omitempty"`
-	ClusterName       string `json:"cluster_name,omitempty"`
-	CreatedAt         string `json:"created_at,omitempty"`
-	UpdatedAt         string `json:"updated_at,omitempty"`
+	ID                string            `json:"id,omitempty"`
+	Region            string            `json:"region"`
+	Profile           string            `json:"profile"`
+	Release           string            `json:"release"`
+	Tag               string            `json:"tag"`
+	APIServerEndPoint string            `json:"api_server_endpoint,omitempty"`
+	VPC               string            `json:"vpc,omitempty"`
+	ClusterName       string            `json:"cluster_name,omitempty"`
+	AccountID         string            `json:"account_id,omitempty"`
+	Status            string            `json:"status,omitempty"`
+	TargetRelease     string            `json:"target_release,omitempty"`
+	UpgradeID         string            `json:"upgrade_id,omitempty"`
+	TargetProfile     string            `json:"target_profile,omitempty"`
+	OperationID       string            `json:"operation_id,omitempty"`
+	Autoscaling       *Autoscaling      `json:"autoscaling,omitempty"`
+	CurrentNodes      int               `json:"current_nodes,omitempty"`
+	DesiredNodes      int               `json:"desired_nodes,omitempty"`
+	LastScaledAt      string            `json:"last_scaled_at,omitempty"`
+	Capacity          *Capacity         `json:"capacity,omitempty"`
+	Labels            map[string]string `json:"labels,omitempty"`
+	CreatedAt         string            `json:"created_at,omitempty"`
+	UpdatedAt         string            `json:"updated_at,omitempty"`
+	DeletedAt         string            `json:"deleted_at,omitempty"`
 }
 
 func (a API) handleGetEHSCluster(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("revision") != "" {
+		var ap EHSCluster
+		if a.getRevision(w, r, "ehscluster", &ap) {
+			api.Encode(w, r, http.StatusOK, Response{EHSClusters: []EHSCluster{ap}})
+		}
+		return
+	}
+	if !a.blockingQuery(w, r, "ehscluster") {
+		return
+	}
 	ap, err := a.Storer.GetEHSCluster(trout.RequestVars(r).Get("id"))
 	if err != nil {
 		if err == ErrEHSClusterNotFound {
@@ -41,20 +65,43 @@ func (a API) handlePostEHSCluster(w http.ResponseWriter, r *http.Request) {
 		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
 		return
 	}
+	if errs := append(validateLabels(ap.Labels), validateAutoscaling(ap.Autoscaling)...); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	if release, ok := findRelease(ap.Release); ok && release.Status == ReleaseEOL {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/release", Slug: api.RequestErrInvalidValue}}})
+		return
+	}
 	ap.ID, err = uuid.GenerateUUID()
 	if err != nil {
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
+	ap.Status = EHSClusterReady
+	ap.TargetRelease = ""
+	ap.UpgradeID = ""
+	ap.TargetProfile = ""
+	ap.OperationID = ""
+	profile, _ := findProfile(ap.Profile)
+	ap.CurrentNodes = clampNodes(ap.Autoscaling, profile.Nodes)
+	ap.DesiredNodes = ap.CurrentNodes
+	ap.LastScaledAt = ""
+	ap.Capacity = clusterCapacity(ap.Profile, ap.CurrentNodes, Capacity{})
+	ap.DeletedAt = ""
 	err = a.Storer.CreateEHSCluster(ap)
 	if err != nil {
 		if err == ErrEHSClusterAlreadyExists {
 			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrConflict}}})
 			return
 		}
+		if quotaExceeded(w, r, err) {
+			return
+		}
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
+	a.wakeAutoscaler()
 	api.Encode(w, r, http.StatusCreated, Response{EHSClusters: []EHSCluster{ap}})
 }
 
@@ -65,8 +112,13 @@ func (a API) handlePutEHSCluster(w http.ResponseWriter, r *http.Request) {
 		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
 		return
 	}
+	if errs := append(validateLabels(ap.Labels), validateAutoscaling(ap.Autoscaling)...); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
 	ap.ID = trout.RequestVars(r).Get("id")
-	err = a.Storer.UpdateEHSCluster(ap)
+	ap.DeletedAt = ""
+	existing, err := a.Storer.GetEHSCluster(ap.ID)
 	if err != nil {
 		if err == ErrEHSClusterNotFound {
 			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
@@ -75,11 +127,90 @@ func (a API) handlePutEHSCluster(w http.ResponseWriter, r *http.Request) {
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
+	stored := existing
+	existing = a.settleEHSCluster(existing)
+	ap.Status = existing.Status
+	ap.TargetRelease = existing.TargetRelease
+	ap.UpgradeID = existing.UpgradeID
+	ap.TargetProfile = existing.TargetProfile
+	ap.OperationID = existing.OperationID
+	ap.CurrentNodes = existing.CurrentNodes
+	ap.DesiredNodes = existing.DesiredNodes
+	ap.LastScaledAt = existing.LastScaledAt
+	ap.Capacity = existing.Capacity
+	ap, ok := a.resizeEHSCluster(w, r, ap, existing)
+	if !ok {
+		return
+	}
+	ap, ok = a.upgradeEHSCluster(w, r, ap, existing)
+	if !ok {
+		return
+	}
+	upgrading := ap.UpgradeID != "" && ap.UpgradeID != existing.UpgradeID
+	resizing := ap.Status == EHSClusterResizing && ap.OperationID != existing.OperationID
+	err = a.Storer.SwapEHSCluster(stored, ap)
+	if err != nil && upgrading {
+		a.Upgrades.Abandon(ap.UpgradeID)
+	}
+	if err != nil && resizing {
+		a.Operations.Abandon(ap.OperationID)
+	}
+	if err != nil {
+		if err == ErrEHSClusterNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrEHSClusterChanged {
+			api.Encode(w, r, http.StatusConflict, Response{Errors: []api.RequestError{{Field: "/status", Slug: api.RequestErrConflict}}})
+			return
+		}
+		if quotaExceeded(w, r, err) {
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	if upgrading {
+		a.Upgrades.Begin(ap.UpgradeID)
+	}
+	if resizing {
+		a.Operations.Begin(ap.OperationID)
+	}
+	a.wakeAutoscaler()
 	api.Encode(w, r, http.StatusOK, Response{EHSClusters: []EHSCluster{ap}})
 }
 
+func (a API) handleListEHSClusters(w http.ResponseWriter, r *http.Request) {
+	filter, ok := parseDeletedFilter(w, r)
+	if !ok {
+		return
+	}
+	selector, ok := parseSelector(w, r)
+	if !ok {
+		return
+	}
+	aps, err := a.Storer.ListEHSClusters(selector)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	results := []EHSCluster{}
+	for _, ap := range aps {
+		if filter.matches(ap.DeletedAt) {
+			results = append(results, ap)
+		}
+	}
+	api.Encode(w, r, http.StatusOK, Response{EHSClusters: results})
+}
+
 func (a API) handleDeleteEHSCluster(w http.ResponseWriter, r *http.Request) {
-	ap, err := a.Storer.GetEHSCluster(trout.RequestVars(r).Get("id"))
+	var ap EHSCluster
+	var err error
+	if a.softDelete(r) {
+		ap, err = a.Storer.SoftDeleteEHSCluster(trout.RequestVars(r).Get("id"), time.Now())
+	} else {
+		ap, err = a.Storer.DeleteEHSCluster(trout.RequestVars(r).Get("id"))
+	}
 	if err != nil {
 		if err == ErrEHSClusterNotFound {
 			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
@@ -88,12 +219,28 @@ func (a API) handleDeleteEHSCluster(w http.ResponseWriter, r *http.Request) {
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
-	err = a.Storer.DeleteEHSCluster(trout.RequestVars(r).Get("id"))
+	api.Encode(w, r, http.StatusOK, Response{EHSClusters: []EHSCluster{ap}})
+}
+
+func (a API) handleRestoreEHSCluster(w http.ResponseWriter, r *http.Request) {
+	id, action := splitAction(trout.RequestVars(r).Get("id"))
+	if action != "restore" {
+		api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+		return
+	}
+	ap, err := a.Storer.RestoreEHSCluster(id)
 	if err != nil {
 		if err == ErrEHSClusterNotFound {
 			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
 			return
 		}
+		if err == ErrEHSClusterNotDeleted {
+			api.Encode(w, r, http.StatusConflict, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrConflict}}})
+			return
+		}
+		if quotaExceeded(w, r, err) {
+			return
+		}
 		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
 		return
 	}
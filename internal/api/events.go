@@ -0,0 +1,155 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"darlinggo.co/api"
+)
+
+const (
+	EventCreate       = "create"
+	EventUpdate       = "update"
+	EventDelete       = "delete"
+	EventPurge        = "purge"
+	EventStatusChange = "status_change"
+	EventScale        = "scale"
+	EventSchedule     = "schedule"
+)
+
+const eventsHeartbeat = 15 * time.Second
+
+// eventsWriteTimeout is how long each write to an event stream has to
+// finish, in place of the server's write timeout, which would otherwise end
+// every stream.
+const eventsWriteTimeout = 2 * eventsHeartbeat
+
+type connContextKey struct{}
+
+// ConnContext makes each request's connection available to handlers that
+// need to manage its deadlines. Set it as the http.Server's ConnContext.
+func ConnContext(ctx context.Context, c net.Conn) context.Context {
+	return context.WithValue(ctx, connContextKey{}, c)
+}
+
+// extendWriteDeadline gives the next write to the request's connection
+// eventsWriteTimeout to finish. HTTP/2 streams share their connection, so
+// their write timeout can't be lifted; clients resume those streams from
+// the last event they saw when they're cut.
+func extendWriteDeadline(r *http.Request) {
+	if r.ProtoMajor != 1 {
+		return
+	}
+	conn, ok := r.Context().Value(connContextKey{}).(net.Conn)
+	if !ok {
+		return
+	}
+	conn.SetWriteDeadline(time.Now().Add(eventsWriteTimeout)) //nolint:errcheck
+}
+
+type eventFilter struct {
+	resourceType string
+	resourceID   string
+	events       map[string]bool
+}
+
+func (f eventFilter) matches(rev Revision) bool {
+	if f.resourceType != "" && f.resourceType != rev.ResourceType {
+		return false
+	}
+	if f.resourceID != "" && !strings.EqualFold(f.resourceID, rev.ResourceID) {
+		return false
+	}
+	if len(f.events) > 0 && !f.events[rev.Event] {
+		return false
+	}
+	return true
+}
+
+// handleEvents streams every Revision recorded by the Storer as a
+// Server-Sent Event. Clients resume from where they left off by sending the
+// ID of the last event they saw in the Last-Event-ID header; without it, only
+// changes made after the request are sent.
+func (a API) handleEvents(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := eventFilter{
+		resourceType: q.Get("resource_type"),
+		resourceID:   q.Get("resource_id"),
+		events:       map[string]bool{},
+	}
+	for _, event := range q["event"] {
+		switch event {
+		case EventCreate, EventUpdate, EventDelete, EventPurge, EventStatusChange, EventScale, EventSchedule:
+			filter.events[event] = true
+		default:
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "event", Slug: api.RequestErrInvalidValue}}})
+			return
+		}
+	}
+	last := a.Storer.Index()
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		var err error
+		last, err = strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Header: "Last-Event-ID", Slug: api.RequestErrInvalidFormat}}})
+			return
+		}
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	extendWriteDeadline(r)
+	w.WriteHeader(http.StatusOK)
+	_, err := fmt.Fprintf(w, "id: %d\n\n", last)
+	if err != nil {
+		return
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		revs, watch, err := a.Storer.RevisionsSince(last)
+		if err != nil {
+			return
+		}
+		extendWriteDeadline(r)
+		for _, rev := range revs {
+			last = rev.Index
+			if !filter.matches(rev) {
+				continue
+			}
+			b, err := json.Marshal(rev)
+			if err != nil {
+				return
+			}
+			_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", rev.Index, rev.Event, b)
+			if err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+		select {
+		case <-r.Context().Done():
+			return
+		case <-watch:
+		case <-heartbeat.C:
+			extendWriteDeadline(r)
+			_, err = fmt.Fprint(w, ": heartbeat\n\n")
+			if err != nil {
+				return
+			}
+		}
+	}
+}
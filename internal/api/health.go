@@ -0,0 +1,25 @@
+package api
+
+import (
+	"net/http"
+
+	"darlinggo.co/api"
+)
+
+type HealthStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (a API) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	api.Encode(w, r, http.StatusOK, HealthStatus{Status: "ok"})
+}
+
+func (a API) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	err := a.Storer.Ping()
+	if err != nil {
+		api.Encode(w, r, http.StatusServiceUnavailable, HealthStatus{Status: "unavailable", Error: err.Error()})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, HealthStatus{Status: "ok"})
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"darlinggo.co/api"
+	"github.com/hashicorp/go-memdb"
+)
+
+var (
+	// labelKeyRE allows an optional DNS prefix, like example.com/team.
+	labelKeyRE   = regexp.MustCompile(`^([a-z0-9]([-a-z0-9.]{0,251}[a-z0-9])?/)?[A-Za-z0-9]([-A-Za-z0-9_.]{0,61}[A-Za-z0-9])?$`)
+	labelValueRE = regexp.MustCompile(`^([A-Za-z0-9]([-A-Za-z0-9_.]{0,61}[A-Za-z0-9])?)?$`)
+
+	errInvalidSelector = errors.New("invalid label selector")
+)
+
+type selectorOp string
+
+const (
+	selectorEquals    selectorOp = "="
+	selectorNotEquals selectorOp = "!="
+	selectorExists    selectorOp = "exists"
+	selectorNotExists selectorOp = "!exists"
+)
+
+type selectorRequirement struct {
+	key   string
+	op    selectorOp
+	value string
+}
+
+// Selector filters resources by their labels. Every requirement must match.
+// A nil Selector matches everything.
+type Selector []selectorRequirement
+
+// ParseSelector parses a comma separated list of requirements, each one of
+// key=value, key==value, key!=value, key (the label is set) or !key (the
+// label isn't set).
+func ParseSelector(s string) (Selector, error) {
+	var sel Selector
+	if strings.TrimSpace(s) == "" {
+		return sel, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		var req selectorRequirement
+		switch {
+		case strings.Contains(part, "!="):
+			kv := strings.SplitN(part, "!=", 2)
+			req = selectorRequirement{key: kv[0], op: selectorNotEquals, value: kv[1]}
+		case strings.Contains(part, "="):
+			kv := strings.SplitN(strings.Replace(part, "==", "=", 1), "=", 2)
+			req = selectorRequirement{key: kv[0], op: selectorEquals, value: kv[1]}
+		case strings.HasPrefix(part, "!"):
+			req = selectorRequirement{key: strings.TrimPrefix(part, "!"), op: selectorNotExists}
+		default:
+			req = selectorRequirement{key: part, op: selectorExists}
+		}
+		req.key = strings.TrimSpace(req.key)
+		req.value = strings.TrimSpace(req.value)
+		if !labelKeyRE.MatchString(req.key) || !labelValueRE.MatchString(req.value) {
+			return nil, errInvalidSelector
+		}
+		sel = append(sel, req)
+	}
+	return sel, nil
+}
+
+// Matches reports whether labels satisfy every requirement in the Selector.
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, req := range s {
+		v, ok := labels[req.key]
+		switch req.op {
+		case selectorEquals:
+			if !ok || v != req.value {
+				return false
+			}
+		case selectorNotEquals:
+			if ok && v == req.value {
+				return false
+			}
+		case selectorExists:
+			if !ok {
+				return false
+			}
+		case selectorNotExists:
+			if ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// iterate returns the rows of table that might match the Selector, using
+// the labels index for its first equality requirement if it has one. The
+// caller still needs to check each row with Matches.
+func (s Selector) iterate(txn *memdb.Txn, table string) (memdb.ResultIterator, error) {
+	for _, req := range s {
+		if req.op == selectorEquals {
+			return txn.Get(table, "labels", req.key, req.value)
+		}
+	}
+	return txn.Get(table, "id")
+}
+
+// parseSelector reads the selector query param. If it's invalid, an error
+// response is written and ok is false.
+func parseSelector(w http.ResponseWriter, r *http.Request) (sel Selector, ok bool) {
+	sel, err := ParseSelector(r.URL.Query().Get("selector"))
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "selector", Slug: api.RequestErrInvalidFormat}}})
+		return nil, false
+	}
+	return sel, true
+}
+
+// validateLabels returns a RequestError for every label with an invalid key
+// or value.
+func validateLabels(labels map[string]string) []api.RequestError {
+	var errs []api.RequestError
+	for k, v := range labels {
+		if !labelKeyRE.MatchString(k) || !labelValueRE.MatchString(v) {
+			errs = append(errs, api.RequestError{Field: "/labels/" + escapePointer(k), Slug: api.RequestErrInvalidValue})
+		}
+	}
+	return errs
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+type Metrics struct {
+	registry     *prometheus.Registry
+	requests     *prometheus.CounterVec
+	durations    *prometheus.HistogramVec
+	authFailures prometheus.Counter
+	operations   *prometheus.GaugeVec
+}
+
+func NewMetrics(storer *Storer) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "edison_http_requests_total",
+			Help: "Number of HTTP requests served, by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		durations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "edison_http_request_duration_seconds",
+			Help:    "Time taken to serve HTTP requests, by method, route and status code.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		authFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "edison_auth_failures_total",
+			Help: "Number of requests rejected because they weren't authenticated.",
+		}),
+		operations: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "edison_operations_in_flight",
+			Help: "Number of long-running operations in progress, by kind.",
+		}, []string{"kind"}),
+	}
+	m.registry.MustRegister(m.requests, m.durations, m.authFailures, m.operations)
+	m.registry.MustRegister(resourceCollector{storer: storer})
+	m.registry.MustRegister(collectors.NewGoCollector())
+	return m
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+func (m *Metrics) OperationStarted(kind string) {
+	m.operations.WithLabelValues(kind).Inc()
+}
+
+func (m *Metrics) OperationFinished(kind string) {
+	m.operations.WithLabelValues(kind).Dec()
+}
+
+// Middleware records every request against the first of routes that
+// matches its path, so labels stay bounded no matter what IDs are used.
+func (m *Metrics) Middleware(baseURL string, routes []string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(rec, r)
+
+		route := "unmatched"
+		p := strings.TrimPrefix(r.URL.Path, baseURL)
+		for _, pattern := range routes {
+			if matchRoute(pattern, p) {
+				route = pattern
+				break
+			}
+		}
+		status := strconv.Itoa(rec.status)
+		m.requests.WithLabelValues(r.Method, route, status).Inc()
+		m.durations.WithLabelValues(r.Method, route, status).Observe(time.Since(start).Seconds())
+		if rec.status == http.StatusUnauthorized {
+			m.authFailures.Inc()
+		}
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := s.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijacking not supported")
+	}
+	return hj.Hijack()
+}
+
+func (s *statusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+var resourcesDesc = prometheus.NewDesc(
+	"edison_resources",
+	"Number of stored resources, by type, status and account.",
+	[]string{"type", "status", "account"}, nil,
+)
+
+// resourceCollector counts resources in the Storer at scrape time.
+type resourceCollector struct {
+	storer *Storer
+}
+
+func (c resourceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- resourcesDesc
+}
+
+func (c resourceCollector) Collect(ch chan<- prometheus.Metric) {
+	type key struct {
+		typ, status, account string
+	}
+	counts := map[key]int{}
+
+	eastores, err := c.storer.ListEAStores(nil)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(resourcesDesc, err)
+		return
+	}
+	for _, r := range eastores {
+		counts[key{"eastore", resourceStatus(r.DeletedAt), r.AccountID}]++
+	}
+	clusters, err := c.storer.ListEHSClusters(nil)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(resourcesDesc, err)
+		return
+	}
+	accounts := map[string]string{}
+	for _, r := range clusters {
+		accounts[r.ID] = r.AccountID
+		counts[key{"ehscluster", resourceStatus(r.DeletedAt), r.AccountID}]++
+	}
+	aws, err := c.storer.ListAWs(nil)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(resourcesDesc, err)
+		return
+	}
+	for _, r := range aws {
+		// AWs belong to the account of the EHS cluster they run on.
+		counts[key{"aw", resourceStatus(r.DeletedAt), accounts[r.EHSClusterID]}]++
+	}
+	avs, err := c.storer.ListAVs(nil)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(resourcesDesc, err)
+		return
+	}
+	for _, r := range avs {
+		counts[key{"av", resourceStatus(r.DeletedAt), r.AccountID}]++
+	}
+
+	for k, n := range counts {
+		ch <- prometheus.MustNewConstMetric(resourcesDesc, prometheus.GaugeValue, float64(n), k.typ, k.status, k.account)
+	}
+}
+
+func resourceStatus(deletedAt string) string {
+	if deletedAt != "" {
+		return "deleted"
+	}
+	return "active"
+}
@@ -0,0 +1,450 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"darlinggo.co/api"
+	"darlinggo.co/trout/v2"
+	"github.com/hashicorp/go-uuid"
+)
+
+const (
+	AWReady     = "ready"
+	AWMigrating = "migrating"
+)
+
+const (
+	// MigrationCutOver drains the AW's sessions before it's provisioned on
+	// the new EHS cluster, so it's unavailable until DNS is switched.
+	MigrationCutOver = "cut_over"
+	// MigrationBlueGreen provisions the AW on the new EHS cluster before
+	// switching DNS to it, and only drains the old one afterwards.
+	MigrationBlueGreen = "blue_green"
+)
+
+// MigrationStrategies are the strategies AWs can be migrated with.
+var MigrationStrategies = []string{MigrationCutOver, MigrationBlueGreen}
+
+const (
+	MigrationRunning   = "running"
+	MigrationSucceeded = "succeeded"
+	MigrationFailed    = "failed"
+)
+
+const (
+	MigrationDrain     = "drain"
+	MigrationProvision = "provision"
+	MigrationSwitchDNS = "switch_dns"
+	MigrationTeardown  = "teardown"
+)
+
+const (
+	MigrationStepPending = "pending"
+	MigrationStepRunning = "running"
+	MigrationStepDone    = "done"
+	MigrationStepFailed  = "failed"
+)
+
+var ErrMigrationNotFound = errors.New("migration not found")
+
+// Migration is a move of an AW from one EHS cluster to another, one step at
+// a time. Its strategy decides the order of the steps. OperationID is the
+// operation clients can wait on for it to finish.
+type Migration struct {
+	ID               string          `json:"id"`
+	AWID             string          `json:"aw_id"`
+	FromEHSClusterID string          `json:"from_ehs_cluster_id"`
+	ToEHSClusterID   string          `json:"to_ehs_cluster_id"`
+	Strategy         string          `json:"strategy"`
+	Status           string          `json:"status"`
+	Steps            []MigrationStep `json:"steps"`
+	FromDNSEndPoint  string          `json:"from_dns_endpoint,omitempty"`
+	ToDNSEndPoint    string          `json:"to_dns_endpoint"`
+	OperationID      string          `json:"operation_id,omitempty"`
+	StartedAt        string          `json:"started_at"`
+	FinishedAt       string          `json:"finished_at,omitempty"`
+
+	index uint64
+}
+
+// MigrationStep is the state of one of the steps of a Migration.
+type MigrationStep struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// Done reports whether the migration has finished, one way or another.
+func (m Migration) Done() bool {
+	return m.Status == MigrationSucceeded || m.Status == MigrationFailed
+}
+
+func validMigrationStrategy(strategy string) bool {
+	return strategy == MigrationCutOver || strategy == MigrationBlueGreen
+}
+
+// migrationSteps returns the steps of a migration with the strategy, in
+// order.
+func migrationSteps(strategy string) []MigrationStep {
+	names := []string{MigrationDrain, MigrationProvision, MigrationSwitchDNS, MigrationTeardown}
+	if strategy == MigrationBlueGreen {
+		names = []string{MigrationProvision, MigrationSwitchDNS, MigrationDrain, MigrationTeardown}
+	}
+	steps := make([]MigrationStep, 0, len(names))
+	for _, name := range names {
+		steps = append(steps, MigrationStep{Name: name, Status: MigrationStepPending})
+	}
+	steps[0].Status = MigrationStepRunning
+	return steps
+}
+
+// progress is how far along the migration is, out of 100.
+func (m Migration) progress() int {
+	var done int
+	for _, step := range m.Steps {
+		if step.Status == MigrationStepDone {
+			done++
+		}
+	}
+	return done * 100 / len(m.Steps)
+}
+
+// current returns the index of the step that's running, or -1 if none is.
+func (m Migration) current() int {
+	for i, step := range m.Steps {
+		if step.Status == MigrationStepRunning {
+			return i
+		}
+	}
+	return -1
+}
+
+// awDNSEndPoint is the DNS endpoint the AW is served on while it's hosted
+// on the cluster.
+func awDNSEndPoint(awID, clusterID string) string {
+	short := func(id string) string {
+		id = strings.ReplaceAll(id, "-", "")
+		if len(id) > 8 {
+			return id[:8]
+		}
+		return id
+	}
+	return fmt.Sprintf("https://aw-%s.%s.ehs.edison.gehealthcare.com/", short(awID), short(clusterID))
+}
+
+// MigrationsConfig sets how long each step of a simulated migration takes.
+type MigrationsConfig struct {
+	StepDuration time.Duration `json:"step_duration" yaml:"step_duration"`
+}
+
+// Migrations runs migrations of AWs between EHS clusters in the
+// background, tracking each as an operation.
+type Migrations struct {
+	storer     *Storer
+	operations *Operations
+
+	mu     sync.Mutex
+	config MigrationsConfig
+}
+
+func NewMigrations(storer *Storer, operations *Operations, config MigrationsConfig) *Migrations {
+	return &Migrations{
+		storer:     storer,
+		operations: operations,
+		config:     config,
+	}
+}
+
+// SetConfig replaces the duration used for migrations from their next step
+// on.
+func (m *Migrations) SetConfig(config MigrationsConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.config = config
+}
+
+func (m *Migrations) stepDuration() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.config.StepDuration
+}
+
+// Start records a migration of aw to the cluster with the ID to, returning
+// the AW as it should be stored while the migration runs. The migration
+// doesn't run until Begin is called, once the AW has been stored, or
+// Abandon if it couldn't be.
+func (m *Migrations) Start(aw AW, to, strategy string) (AW, Migration, error) {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return AW{}, Migration{}, err
+	}
+	mig := Migration{
+		ID:               id,
+		AWID:             aw.ID,
+		FromEHSClusterID: aw.EHSClusterID,
+		ToEHSClusterID:   to,
+		Strategy:         strategy,
+		Status:           MigrationRunning,
+		Steps:            migrationSteps(strategy),
+		FromDNSEndPoint:  aw.DNSEndPoint,
+		ToDNSEndPoint:    awDNSEndPoint(aw.ID, to),
+		StartedAt:        time.Now().UTC().Format(time.RFC3339),
+	}
+	op, err := m.operations.Track(Operation{
+		Kind:         "aw.migrate",
+		ResourceType: "aw",
+		ResourceID:   aw.ID,
+	})
+	if err != nil {
+		return AW{}, Migration{}, err
+	}
+	mig.OperationID = op.ID
+	err = m.storer.PutMigration(mig)
+	if err != nil {
+		m.operations.Finish(op.ID, OperationFailed)
+		return AW{}, Migration{}, err
+	}
+	aw.Status = AWMigrating
+	aw.TargetEHSClusterID = to
+	aw.MigrationID = mig.ID
+	aw.OperationID = op.ID
+	return aw, mig, nil
+}
+
+// Begin runs a migration recorded by Start.
+func (m *Migrations) Begin(id string) {
+	go m.run(id)
+}
+
+// Abandon fails a migration recorded by Start whose AW couldn't be stored.
+func (m *Migrations) Abandon(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mig, err := m.storer.GetMigration(id)
+	if err != nil {
+		return
+	}
+	if i := mig.current(); i >= 0 {
+		mig.Steps[i].Status = MigrationStepFailed
+	}
+	mig.Status = MigrationFailed
+	mig.FinishedAt = time.Now().UTC().Format(time.RFC3339)
+	m.storer.PutMigration(mig) //nolint:errcheck
+	m.operations.Finish(mig.OperationID, OperationFailed)
+}
+
+// run finishes a step of the migration every StepDuration until it's done.
+func (m *Migrations) run(id string) {
+	for {
+		time.Sleep(m.stepDuration())
+		if m.advance(id) {
+			return
+		}
+	}
+}
+
+// advance finishes the migration's running step and starts the next one,
+// reporting whether the migration is done. Provisioning fails if the
+// target cluster has gone away or isn't ready, and a migration whose AW has
+// been deleted fails at its next step.
+func (m *Migrations) advance(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mig, err := m.storer.GetMigration(id)
+	if err != nil || mig.Done() {
+		return true
+	}
+	i := mig.current()
+	if i < 0 {
+		return true
+	}
+	aw, err := m.storer.GetAW(mig.AWID)
+	if err != nil || aw.DeletedAt != "" || aw.MigrationID != mig.ID {
+		mig.Steps[i].Status = MigrationStepFailed
+		m.finish(mig, MigrationFailed)
+		return true
+	}
+	switch mig.Steps[i].Name {
+	case MigrationProvision:
+		cluster, err := m.storer.GetEHSCluster(mig.ToEHSClusterID)
+		if err != nil || cluster.DeletedAt != "" || cluster.Status != EHSClusterReady {
+			mig.Steps[i].Status = MigrationStepFailed
+			m.finish(mig, MigrationFailed)
+			return true
+		}
+	case MigrationSwitchDNS:
+		err = m.storer.MoveAW(aw.ID, mig.ID, mig.ToEHSClusterID, mig.ToDNSEndPoint)
+		if err != nil {
+			mig.Steps[i].Status = MigrationStepFailed
+			m.finish(mig, MigrationFailed)
+			return true
+		}
+	}
+	mig.Steps[i].Status = MigrationStepDone
+	if i+1 < len(mig.Steps) {
+		mig.Steps[i+1].Status = MigrationStepRunning
+		m.storer.PutMigration(mig) //nolint:errcheck
+		m.operations.Progress(mig.OperationID, mig.progress())
+		return false
+	}
+	m.finish(mig, MigrationSucceeded)
+	return true
+}
+
+// finish records the migration as done and puts its AW back to ready. If
+// the migration failed before switching DNS, the AW stays where it was. It
+// must be called with mu held.
+func (m *Migrations) finish(mig Migration, status string) {
+	mig.Status = status
+	m.storer.FinishAWMigration(mig.AWID, mig.ID) //nolint:errcheck
+	mig.FinishedAt = time.Now().UTC().Format(time.RFC3339)
+	m.storer.PutMigration(mig) //nolint:errcheck
+	opStatus := OperationFailed
+	if status == MigrationSucceeded {
+		opStatus = OperationSucceeded
+	}
+	m.operations.Finish(mig.OperationID, opStatus)
+}
+
+// migrateAW starts migrating the AW being PUT to the EHS cluster it names,
+// if that's changed, instead of moving it directly. The cluster it's moving
+// to must already have been checked for room. If the change isn't allowed,
+// it writes an error response and returns false.
+func (a API) migrateAW(w http.ResponseWriter, r *http.Request, aw AW, existing AW) (AW, bool) {
+	if aw.MigrationStrategy == "" {
+		aw.MigrationStrategy = existing.MigrationStrategy
+	}
+	if aw.MigrationStrategy == "" {
+		aw.MigrationStrategy = MigrationCutOver
+	}
+	if !validMigrationStrategy(aw.MigrationStrategy) {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/migration_strategy", Slug: api.RequestErrInvalidValue}}})
+		return AW{}, false
+	}
+	aw.Status = existing.Status
+	aw.TargetEHSClusterID = existing.TargetEHSClusterID
+	aw.MigrationID = existing.MigrationID
+	aw.OperationID = existing.OperationID
+	if aw.DNSEndPoint == "" || existing.Status == AWMigrating {
+		aw.DNSEndPoint = existing.DNSEndPoint
+	}
+	if aw.EHSClusterID == existing.EHSClusterID || existing.EHSClusterID == "" {
+		return aw, true
+	}
+	if existing.Status == AWMigrating {
+		api.Encode(w, r, http.StatusConflict, Response{Errors: []api.RequestError{{Field: "/ehs_cluster_id", Slug: api.RequestErrConflict}}})
+		return AW{}, false
+	}
+	if a.Migrations == nil {
+		aw.DNSEndPoint = awDNSEndPoint(aw.ID, aw.EHSClusterID)
+		return aw, true
+	}
+	to := aw.EHSClusterID
+	aw.EHSClusterID = existing.EHSClusterID
+	aw.Region = existing.Region
+	aw.DNSEndPoint = existing.DNSEndPoint
+	aw, _, err := a.Migrations.Start(aw, to, aw.MigrationStrategy)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return AW{}, false
+	}
+	return aw, true
+}
+
+func (s *Storer) GetMigration(id string) (Migration, error) {
+	txn := s.db.Txn(false)
+	mig, err := txn.First("migration", "id", id)
+	if err != nil {
+		return Migration{}, err
+	}
+	if mig == nil {
+		return Migration{}, ErrMigrationNotFound
+	}
+	result := *mig.(*Migration)
+	result.Steps = append([]MigrationStep(nil), result.Steps...)
+	return result, nil
+}
+
+func (s *Storer) ListMigrations() ([]Migration, error) {
+	txn := s.db.Txn(false)
+	iter, err := txn.Get("migration", "id")
+	if err != nil {
+		return nil, err
+	}
+	var results []Migration
+	for obj := iter.Next(); obj != nil; obj = iter.Next() {
+		results = append(results, *obj.(*Migration))
+	}
+	return results, nil
+}
+
+// PutMigration creates or replaces mig, marking it as changed at a new
+// index.
+func (s *Storer) PutMigration(mig Migration) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	mig.index = s.nextIndex(txn)
+	mig.Steps = append([]MigrationStep(nil), mig.Steps...)
+	err := txn.Insert("migration", &mig)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// WaitForMigration blocks until the migration has changed since index, the
+// timeout elapses, or ctx is done.
+func (s *Storer) WaitForMigration(ctx context.Context, id string, index uint64, timeout time.Duration) error {
+	return s.waitForRow(ctx, "migration", id, index, timeout, func(obj interface{}) uint64 {
+		return obj.(*Migration).index
+	})
+}
+
+func (a API) handleListMigrations(w http.ResponseWriter, r *http.Request) {
+	migs, err := a.Storer.ListMigrations()
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	q := r.URL.Query()
+	results := []Migration{}
+	for _, mig := range migs {
+		if q.Get("aw_id") != "" && !strings.EqualFold(mig.AWID, q.Get("aw_id")) {
+			continue
+		}
+		if q.Get("ehs_cluster_id") != "" && !strings.EqualFold(mig.FromEHSClusterID, q.Get("ehs_cluster_id")) && !strings.EqualFold(mig.ToEHSClusterID, q.Get("ehs_cluster_id")) {
+			continue
+		}
+		if q.Get("status") != "" && mig.Status != q.Get("status") {
+			continue
+		}
+		results = append(results, mig)
+	}
+	api.Encode(w, r, http.StatusOK, Response{Migrations: results})
+}
+
+func (a API) handleGetMigration(w http.ResponseWriter, r *http.Request) {
+	id := trout.RequestVars(r).Get("id")
+	ok := a.block(w, r, func(ctx context.Context, index uint64, wait time.Duration) error {
+		return a.Storer.WaitForMigration(ctx, id, index, wait)
+	})
+	if !ok {
+		return
+	}
+	mig, err := a.Storer.GetMigration(id)
+	if err != nil {
+		if err == ErrMigrationNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{Migrations: []Migration{mig}})
+}
@@ -0,0 +1,645 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"darlinggo.co/api"
+	"darlinggo.co/trout/v2"
+	"github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/go-uuid"
+)
+
+const (
+	NodePoolReady   = "ready"
+	NodePoolScaling = "scaling"
+)
+
+const (
+	TaintNoSchedule       = "NoSchedule"
+	TaintPreferNoSchedule = "PreferNoSchedule"
+	TaintNoExecute        = "NoExecute"
+)
+
+// maxNodePoolSize is the most nodes a node pool can run.
+const maxNodePoolSize = 100
+
+var (
+	ErrNodePoolNotFound      = errors.New("node pool not found")
+	ErrNodePoolAlreadyExists = errors.New("node pool already exists")
+	ErrNodePoolNameTaken     = errors.New("node pool name already in use in the cluster")
+)
+
+// NodePool is a group of identically sized nodes in an EHS cluster, on top
+// of the nodes its profile runs. Labels are applied to the pool's nodes as
+// well as the pool, and Taints keep workloads that don't tolerate them off
+// its nodes, including AWs. CurrentCount is how many nodes are running; it catches up with
+// DesiredCount as the pool scales.
+type NodePool struct {
+	ID           string            `json:"id,omitempty"`
+	EHSClusterID string            `json:"ehs_cluster_id,omitempty"`
+	Name         string            `json:"name"`
+	InstanceSize string            `json:"instance_size"`
+	MinCount     int               `json:"min_count"`
+	MaxCount     int               `json:"max_count"`
+	DesiredCount int               `json:"desired_count"`
+	CurrentCount int               `json:"current_count"`
+	Status       string            `json:"status,omitempty"`
+	OperationID  string            `json:"operation_id,omitempty"`
+	Taints       []Taint           `json:"taints,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	CreatedAt    string            `json:"created_at,omitempty"`
+	UpdatedAt    string            `json:"updated_at,omitempty"`
+}
+
+// Taint keeps workloads that don't tolerate it off a node pool's nodes.
+type Taint struct {
+	Key    string `json:"key"`
+	Value  string `json:"value,omitempty"`
+	Effect string `json:"effect"`
+}
+
+// hostsAWs reports whether AWs can run on the pool's nodes. AWs don't
+// tolerate taints, so a NoSchedule or NoExecute taint keeps them off, but
+// PreferNoSchedule doesn't.
+func (p NodePool) hostsAWs() bool {
+	for _, taint := range p.Taints {
+		if taint.Effect == TaintNoSchedule || taint.Effect == TaintNoExecute {
+			return false
+		}
+	}
+	return true
+}
+
+// capacity is what the pool's running nodes add to its cluster. Pools that
+// can't host AWs don't add any AW seats.
+func (p NodePool) capacity() Capacity {
+	size, _ := findInstanceSize(p.InstanceSize)
+	capacity := Capacity{
+		Nodes:    p.CurrentCount,
+		VCPU:     p.CurrentCount * size.VCPU,
+		MemoryGB: p.CurrentCount * size.MemoryGB,
+		GPUs:     p.CurrentCount * size.GPUs,
+	}
+	if p.hostsAWs() {
+		capacity.MaxAWSeats = p.CurrentCount * size.AWSeats
+	}
+	return capacity
+}
+
+func validateNodePool(pool NodePool) []api.RequestError {
+	errs := validateLabels(pool.Labels)
+	if pool.Name == "" {
+		errs = append(errs, api.RequestError{Field: "/name", Slug: api.RequestErrMissing})
+	}
+	if _, ok := findInstanceSize(pool.InstanceSize); !ok {
+		errs = append(errs, api.RequestError{Field: "/instance_size", Slug: api.RequestErrInvalidValue})
+	}
+	if pool.MinCount < 0 {
+		errs = append(errs, api.RequestError{Field: "/min_count", Slug: api.RequestErrInvalidValue})
+	}
+	if pool.MaxCount < pool.MinCount || pool.MaxCount > maxNodePoolSize {
+		errs = append(errs, api.RequestError{Field: "/max_count", Slug: api.RequestErrInvalidValue})
+	}
+	if pool.DesiredCount < pool.MinCount || pool.DesiredCount > pool.MaxCount {
+		errs = append(errs, api.RequestError{Field: "/desired_count", Slug: api.RequestErrInvalidValue})
+	}
+	for i, taint := range pool.Taints {
+		if taint.Key == "" {
+			errs = append(errs, api.RequestError{Field: "/taints/" + strconv.Itoa(i) + "/key", Slug: api.RequestErrMissing})
+		}
+		switch taint.Effect {
+		case TaintNoSchedule, TaintPreferNoSchedule, TaintNoExecute:
+		default:
+			errs = append(errs, api.RequestError{Field: "/taints/" + strconv.Itoa(i) + "/effect", Slug: api.RequestErrInvalidValue})
+		}
+	}
+	return errs
+}
+
+// poolCapacity returns what the cluster's node pools, other than the one
+// with the ID except, add to its capacity.
+func (s *Storer) poolCapacity(clusterID, except string) (Capacity, error) {
+	pools, err := s.ListNodePools(clusterID, nil)
+	if err != nil {
+		return Capacity{}, err
+	}
+	var capacity Capacity
+	for _, pool := range pools {
+		if pool.ID != except {
+			capacity.add(pool.capacity())
+		}
+	}
+	return capacity, nil
+}
+
+// roomForAWs reports whether the cluster would still have room for the AWs
+// it hosts if its node pools added pools to its profile's capacity, with as
+// many of the profile's nodes as it can scale out to. Clusters with a
+// profile that isn't in the catalog aren't limited.
+func (a API) roomForAWs(cluster EHSCluster, pools Capacity) (bool, error) {
+	profile, ok := findProfile(cluster.Profile)
+	if !ok {
+		return true, nil
+	}
+	seats, err := a.Storer.hostedSeats(cluster.ID)
+	if err != nil {
+		return false, err
+	}
+	return seats <= profile.scaled(cluster.maxNodes(profile)).MaxAWSeats+pools.MaxAWSeats, nil
+}
+
+// refreshCapacity recalculates the cluster's capacity after its node pools
+// have changed.
+func (a API) refreshCapacity(clusterID string) error {
+	cluster, err := a.Storer.GetEHSCluster(clusterID)
+	if err == ErrEHSClusterNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	pools, err := a.Storer.poolCapacity(clusterID, "")
+	if err != nil {
+		return err
+	}
+	capacity := clusterCapacity(cluster.Profile, cluster.nodes(), pools)
+	if reflect.DeepEqual(capacity, cluster.Capacity) {
+		return nil
+	}
+	cluster.Capacity = capacity
+	err = a.Storer.UpdateEHSCluster(cluster)
+	if err != nil {
+		return err
+	}
+	a.wakeAutoscaler()
+	return nil
+}
+
+// scaleNodePool scales pool to its desired count if it's changed from
+// existing, as an operation if operations are enabled. A pool can't be
+// scaled in if its cluster would no longer have room for the AWs it hosts.
+// If the change isn't allowed, it writes an error response and returns
+// false.
+func (a API) scaleNodePool(w http.ResponseWriter, r *http.Request, cluster EHSCluster, pool NodePool, existing NodePool) (NodePool, bool) {
+	if pool.DesiredCount == existing.DesiredCount {
+		return pool, true
+	}
+	if existing.Status == NodePoolScaling {
+		api.Encode(w, r, http.StatusConflict, Response{Errors: []api.RequestError{{Field: "/desired_count", Slug: api.RequestErrConflict}}})
+		return NodePool{}, false
+	}
+	if pool.DesiredCount < pool.CurrentCount {
+		others, err := a.Storer.poolCapacity(cluster.ID, pool.ID)
+		if err != nil {
+			api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+			return NodePool{}, false
+		}
+		scaled := pool
+		scaled.CurrentCount = pool.DesiredCount
+		others.add(scaled.capacity())
+		ok, err := a.roomForAWs(cluster, others)
+		if err != nil {
+			api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+			return NodePool{}, false
+		}
+		if !ok {
+			api.Encode(w, r, http.StatusConflict, Response{Errors: []api.RequestError{{Field: "/desired_count", Slug: api.RequestErrInsufficient}}})
+			return NodePool{}, false
+		}
+	}
+	if a.Operations == nil {
+		pool.CurrentCount = pool.DesiredCount
+		return pool, true
+	}
+	desired := pool.DesiredCount
+	op, err := a.Operations.Prepare(r.Context(), Operation{Kind: "nodepool.scale", ResourceType: "nodepool", ResourceID: pool.ID}, func(ctx context.Context) (int, Response) {
+		return a.finishScale(pool.ID, desired)
+	}, func() {
+		a.cancelScale(pool.ID, desired)
+	})
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return NodePool{}, false
+	}
+	pool.Status = NodePoolScaling
+	pool.OperationID = op.ID
+	return pool, true
+}
+
+// finishScale brings the pool's running nodes up or down to desired, as
+// long as its cluster still has room for the AWs it hosts.
+func (a API) finishScale(id string, desired int) (int, Response) {
+	pool, err := a.Storer.GetNodePool(id)
+	if err == ErrNodePoolNotFound {
+		return http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "pool", Slug: api.RequestErrNotFound}}}
+	}
+	if err != nil {
+		return http.StatusInternalServerError, Response{Errors: api.ActOfGodError}
+	}
+	if pool.Status != NodePoolScaling || pool.DesiredCount != desired {
+		return http.StatusConflict, Response{Errors: []api.RequestError{{Field: "/desired_count", Slug: api.RequestErrConflict}}}
+	}
+	pool.Status = NodePoolReady
+	pool.OperationID = ""
+	if desired < pool.CurrentCount {
+		cluster, err := a.Storer.GetEHSCluster(pool.EHSClusterID)
+		if err != nil && err != ErrEHSClusterNotFound {
+			return http.StatusInternalServerError, Response{Errors: api.ActOfGodError}
+		}
+		others, err := a.Storer.poolCapacity(pool.EHSClusterID, pool.ID)
+		if err != nil {
+			return http.StatusInternalServerError, Response{Errors: api.ActOfGodError}
+		}
+		scaled := pool
+		scaled.CurrentCount = desired
+		others.add(scaled.capacity())
+		ok, err := a.roomForAWs(cluster, others)
+		if err != nil {
+			return http.StatusInternalServerError, Response{Errors: api.ActOfGodError}
+		}
+		if !ok {
+			// AWs were added while the scale-in was waiting, so it can't
+			// go ahead.
+			pool.DesiredCount = pool.CurrentCount
+			err = a.Storer.UpdateNodePool(pool)
+			if err != nil {
+				return http.StatusInternalServerError, Response{Errors: api.ActOfGodError}
+			}
+			return http.StatusConflict, Response{Errors: []api.RequestError{{Field: "/desired_count", Slug: api.RequestErrInsufficient}}}
+		}
+	}
+	pool.CurrentCount = desired
+	err = a.Storer.UpdateNodePool(pool)
+	if err != nil {
+		return http.StatusInternalServerError, Response{Errors: api.ActOfGodError}
+	}
+	err = a.refreshCapacity(pool.EHSClusterID)
+	if err != nil {
+		return http.StatusInternalServerError, Response{Errors: api.ActOfGodError}
+	}
+	return http.StatusOK, Response{NodePools: []NodePool{pool}}
+}
+
+// cancelScale leaves the pool running the nodes it has.
+func (a API) cancelScale(id string, desired int) {
+	pool, err := a.Storer.GetNodePool(id)
+	if err != nil || pool.Status != NodePoolScaling || pool.DesiredCount != desired {
+		return
+	}
+	pool.Status = NodePoolReady
+	pool.OperationID = ""
+	pool.DesiredCount = pool.CurrentCount
+	a.Storer.UpdateNodePool(pool) //nolint:errcheck
+}
+
+// nodePoolCluster returns the EHS cluster in the request path. If it
+// doesn't exist, it writes an error response and returns false.
+func (a API) nodePoolCluster(w http.ResponseWriter, r *http.Request) (EHSCluster, bool) {
+	cluster, err := a.Storer.GetEHSCluster(trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrEHSClusterNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return EHSCluster{}, false
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return EHSCluster{}, false
+	}
+	return cluster, true
+}
+
+// nodePool returns the node pool in the request path, if it's in cluster.
+// If it isn't, it writes an error response and returns false.
+func (a API) nodePool(w http.ResponseWriter, r *http.Request, cluster EHSCluster) (NodePool, bool) {
+	pool, err := a.Storer.GetNodePool(trout.RequestVars(r).Get("pool"))
+	if err == ErrNodePoolNotFound || (err == nil && pool.EHSClusterID != cluster.ID) {
+		api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "pool", Slug: api.RequestErrNotFound}}})
+		return NodePool{}, false
+	}
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return NodePool{}, false
+	}
+	return pool, true
+}
+
+func (a API) handleListNodePools(w http.ResponseWriter, r *http.Request) {
+	selector, ok := parseSelector(w, r)
+	if !ok {
+		return
+	}
+	cluster, ok := a.nodePoolCluster(w, r)
+	if !ok {
+		return
+	}
+	pools, err := a.Storer.ListNodePools(cluster.ID, selector)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	if pools == nil {
+		pools = []NodePool{}
+	}
+	api.Encode(w, r, http.StatusOK, Response{NodePools: pools})
+}
+
+func (a API) handleGetNodePool(w http.ResponseWriter, r *http.Request) {
+	if !a.block(w, r, func(ctx context.Context, index uint64, wait time.Duration) error {
+		return a.Storer.WaitForChange(ctx, "nodepool", trout.RequestVars(r).Get("pool"), index, wait)
+	}) {
+		return
+	}
+	cluster, ok := a.nodePoolCluster(w, r)
+	if !ok {
+		return
+	}
+	pool, ok := a.nodePool(w, r, cluster)
+	if !ok {
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{NodePools: []NodePool{pool}})
+}
+
+func (a API) handlePostNodePool(w http.ResponseWriter, r *http.Request) {
+	var pool NodePool
+	err := api.Decode(r, &pool)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	if errs := validateNodePool(pool); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	cluster, ok := a.nodePoolCluster(w, r)
+	if !ok {
+		return
+	}
+	pool.ID, err = uuid.GenerateUUID()
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	pool.EHSClusterID = cluster.ID
+	pool.Status = NodePoolReady
+	pool.OperationID = ""
+	pool.CurrentCount = 0
+	pool.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	pool.UpdatedAt = pool.CreatedAt
+	pool, ok = a.scaleNodePool(w, r, cluster, pool, NodePool{Status: NodePoolReady})
+	if !ok {
+		return
+	}
+	scaling := pool.OperationID != ""
+	err = a.Storer.CreateNodePool(pool)
+	if err != nil && scaling {
+		a.Operations.Abandon(pool.OperationID)
+	}
+	if err != nil {
+		if err == ErrNodePoolAlreadyExists {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrConflict}}})
+			return
+		}
+		if err == ErrNodePoolNameTaken {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrConflict}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	if scaling {
+		a.Operations.Begin(pool.OperationID)
+	}
+	err = a.refreshCapacity(cluster.ID)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusCreated, Response{NodePools: []NodePool{pool}})
+}
+
+func (a API) handlePutNodePool(w http.ResponseWriter, r *http.Request) {
+	var pool NodePool
+	err := api.Decode(r, &pool)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	if errs := validateNodePool(pool); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	cluster, ok := a.nodePoolCluster(w, r)
+	if !ok {
+		return
+	}
+	existing, ok := a.nodePool(w, r, cluster)
+	if !ok {
+		return
+	}
+	// Changing the size of a pool's nodes means replacing the pool.
+	if pool.InstanceSize != existing.InstanceSize {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/instance_size", Slug: api.RequestErrInvalidValue}}})
+		return
+	}
+	pool.ID = existing.ID
+	pool.EHSClusterID = existing.EHSClusterID
+	pool.CurrentCount = existing.CurrentCount
+	pool.Status = existing.Status
+	pool.OperationID = existing.OperationID
+	pool.CreatedAt = existing.CreatedAt
+	pool.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	pool, ok = a.scaleNodePool(w, r, cluster, pool, existing)
+	if !ok {
+		return
+	}
+	scaling := pool.OperationID != "" && pool.OperationID != existing.OperationID
+	err = a.Storer.UpdateNodePool(pool)
+	if err != nil && scaling {
+		a.Operations.Abandon(pool.OperationID)
+	}
+	if err != nil {
+		if err == ErrNodePoolNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "pool", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrNodePoolNameTaken {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/name", Slug: api.RequestErrConflict}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	if scaling {
+		a.Operations.Begin(pool.OperationID)
+	}
+	err = a.refreshCapacity(cluster.ID)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{NodePools: []NodePool{pool}})
+}
+
+func (a API) handleDeleteNodePool(w http.ResponseWriter, r *http.Request) {
+	cluster, ok := a.nodePoolCluster(w, r)
+	if !ok {
+		return
+	}
+	pool, ok := a.nodePool(w, r, cluster)
+	if !ok {
+		return
+	}
+	others, err := a.Storer.poolCapacity(cluster.ID, pool.ID)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	ok, err = a.roomForAWs(cluster, others)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	if !ok {
+		api.Encode(w, r, http.StatusConflict, Response{Errors: []api.RequestError{{Param: "pool", Slug: api.RequestErrInsufficient}}})
+		return
+	}
+	pool, err = a.Storer.DeleteNodePool(pool.ID)
+	if err != nil {
+		if err == ErrNodePoolNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "pool", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	err = a.refreshCapacity(cluster.ID)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{NodePools: []NodePool{pool}})
+}
+
+func (s *Storer) GetNodePool(id string) (NodePool, error) {
+	txn := s.db.Txn(false)
+	pool, err := txn.First("nodepool", "id", id)
+	if err != nil {
+		return NodePool{}, err
+	}
+	if pool == nil {
+		return NodePool{}, ErrNodePoolNotFound
+	}
+	return *pool.(*NodePool), nil
+}
+
+// ListNodePools returns every node pool in the cluster whose labels match
+// selector.
+func (s *Storer) ListNodePools(clusterID string, selector Selector) ([]NodePool, error) {
+	txn := s.db.Txn(false)
+	iter, err := txn.Get("nodepool", "ehscluster", clusterID)
+	if err != nil {
+		return nil, err
+	}
+	var results []NodePool
+	for obj := iter.Next(); obj != nil; obj = iter.Next() {
+		if selector.Matches(obj.(*NodePool).Labels) {
+			results = append(results, *obj.(*NodePool))
+		}
+	}
+	return results, nil
+}
+
+// checkNodePoolName returns ErrNodePoolNameTaken if another node pool in
+// the pool's cluster has its name, as seen by txn.
+func checkNodePoolName(txn *memdb.Txn, pool NodePool) error {
+	iter, err := txn.Get("nodepool", "ehscluster", pool.EHSClusterID)
+	if err != nil {
+		return err
+	}
+	for obj := iter.Next(); obj != nil; obj = iter.Next() {
+		other := obj.(*NodePool)
+		if other.ID != pool.ID && other.Name == pool.Name {
+			return ErrNodePoolNameTaken
+		}
+	}
+	return nil
+}
+
+func (s *Storer) CreateNodePool(pool NodePool) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	exists, err := txn.First("nodepool", "id", pool.ID)
+	if err != nil {
+		return err
+	}
+	if exists != nil {
+		return ErrNodePoolAlreadyExists
+	}
+	err = checkNodePoolName(txn, pool)
+	if err != nil {
+		return err
+	}
+	err = txn.Insert("nodepool", &pool)
+	if err != nil {
+		return err
+	}
+	err = s.recordRevision(txn, "nodepool", pool.ID, pool, EventCreate, false)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+func (s *Storer) UpdateNodePool(pool NodePool) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("nodepool", "id", pool.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrNodePoolNotFound
+	}
+	err = checkNodePoolName(txn, pool)
+	if err != nil {
+		return err
+	}
+	err = txn.Insert("nodepool", &pool)
+	if err != nil {
+		return err
+	}
+	err = s.recordRevision(txn, "nodepool", pool.ID, pool, EventUpdate, false)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+func (s *Storer) DeleteNodePool(id string) (NodePool, error) {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("nodepool", "id", id)
+	if err != nil {
+		return NodePool{}, err
+	}
+	if existing == nil {
+		return NodePool{}, ErrNodePoolNotFound
+	}
+	err = txn.Delete("nodepool", existing)
+	if err != nil {
+		return NodePool{}, err
+	}
+	err = s.recordRevision(txn, "nodepool", id, existing, EventDelete, true)
+	if err != nil {
+		return NodePool{}, err
+	}
+	txn.Commit()
+	return *existing.(*NodePool), nil
+}
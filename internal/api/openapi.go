@@ -0,0 +1,798 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"darlinggo.co/api"
+)
+
+// OpenAPIDocument is an OpenAPI 3.0 description of the API, served at
+// /openapi.json.
+type OpenAPIDocument struct {
+	OpenAPI    string                     `json:"openapi"`
+	Info       OpenAPIInfo                `json:"info"`
+	Servers    []OpenAPIServer            `json:"servers,omitempty"`
+	Security   []map[string][]string      `json:"security,omitempty"`
+	Paths      map[string]OpenAPIPathItem `json:"paths"`
+	Components OpenAPIComponents          `json:"components"`
+}
+
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type OpenAPIServer struct {
+	URL string `json:"url"`
+}
+
+// OpenAPIPathItem maps lowercase HTTP methods to the operation they perform
+// on a path.
+type OpenAPIPathItem map[string]*OpenAPIOperation
+
+type OpenAPIOperation struct {
+	OperationID string                     `json:"operationId"`
+	Summary     string                     `json:"summary,omitempty"`
+	Tags        []string                   `json:"tags,omitempty"`
+	Parameters  []OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]OpenAPIResponse `json:"responses"`
+	// Security is set to an empty list for operations that don't need a
+	// token.
+	Security *[]map[string][]string `json:"security,omitempty"`
+	// Servers is set for operations that aren't versioned.
+	Servers []OpenAPIServer `json:"servers,omitempty"`
+}
+
+type OpenAPIParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required,omitempty"`
+	Schema      *OpenAPISchema `json:"schema"`
+}
+
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]OpenAPIMediaType `json:"content"`
+}
+
+type OpenAPIMediaType struct {
+	Schema *OpenAPISchema `json:"schema"`
+}
+
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Headers     map[string]OpenAPIHeader    `json:"headers,omitempty"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+type OpenAPIHeader struct {
+	Description string         `json:"description,omitempty"`
+	Schema      *OpenAPISchema `json:"schema"`
+}
+
+type OpenAPIComponents struct {
+	Schemas         map[string]*OpenAPISchema        `json:"schemas"`
+	SecuritySchemes map[string]OpenAPISecurityScheme `json:"securitySchemes"`
+}
+
+type OpenAPISecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme"`
+}
+
+// OpenAPISchema is the subset of JSON Schema the API's bodies need. An
+// empty schema accepts any JSON value.
+type OpenAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Description          string                    `json:"description,omitempty"`
+	Enum                 []string                  `json:"enum,omitempty"`
+	Minimum              *float64                  `json:"minimum,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty"`
+	AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
+}
+
+const openAPISchemaPrefix = "#/components/schemas/"
+
+var requestErrorSlugs = []string{
+	api.RequestErrAccessDenied,
+	api.RequestErrInsufficient,
+	api.RequestErrOverflow,
+	api.RequestErrInvalidValue,
+	api.RequestErrInvalidFormat,
+	api.RequestErrMissing,
+	api.RequestErrNotFound,
+	api.RequestErrConflict,
+	api.RequestErrActOfGod,
+	RequestErrRateLimited,
+}
+
+var resourceTypes = []string{"eastore", "ehscluster", "aw", "av", "nodepool", "awschedule"}
+
+var releaseStatuses = []string{ReleasePreview, ReleaseGA, ReleaseDeprecated, ReleaseEOL}
+
+var revisionEvents = []string{EventCreate, EventUpdate, EventDelete, EventPurge, EventStatusChange, EventScale, EventSchedule}
+
+// openAPISchemas are the types documented under components/schemas.
+var openAPISchemas = map[string]interface{}{
+	"EAStore":         EAStore{},
+	"EHSCluster":      EHSCluster{},
+	"AW":              AW{},
+	"AV":              AV{},
+	"AuditEvent":      AuditEvent{},
+	"AuditChange":     AuditChange{},
+	"Revision":        Revision{},
+	"Webhook":         Webhook{},
+	"Delivery":        Delivery{},
+	"QuotaUsage":      QuotaUsage{},
+	"RequestError":    api.RequestError{},
+	"HealthStatus":    HealthStatus{},
+	"Capabilities":    Capabilities{},
+	"Version":         Version{},
+	"ChaosConfig":     ChaosConfig{},
+	"ChaosRule":       ChaosRule{},
+	"Operation":       Operation{},
+	"Upgrade":         Upgrade{},
+	"UpgradeNode":     UpgradeNode{},
+	"Migration":       Migration{},
+	"MigrationStep":   MigrationStep{},
+	"Capacity":        Capacity{},
+	"Release":         Release{},
+	"Region":          Region{},
+	"Profile":         Profile{},
+	"InstanceSize":    InstanceSize{},
+	"NodePool":        NodePool{},
+	"Taint":           Taint{},
+	"Autoscaling":     Autoscaling{},
+	"AWSchedule":      AWSchedule{},
+	"ScheduleRule":    ScheduleRule{},
+	"ScheduledAction": ScheduledAction{},
+}
+
+// openAPIEnums restricts string properties, keyed by schema and property,
+// to the values the API uses.
+var openAPIEnums = map[string][]string{
+	"RequestError.error":      requestErrorSlugs,
+	"Revision.event":          revisionEvents,
+	"Revision.resource_type":  resourceTypes,
+	"Webhook.events":          revisionEvents,
+	"Webhook.resource_types":  resourceTypes,
+	"Delivery.event":          append([]string{EventPing}, revisionEvents...),
+	"Delivery.status":         {DeliveryPending, DeliverySucceeded, DeliveryFailed},
+	"QuotaUsage.name":         {QuotaEHSClustersPerRegion, QuotaPartitionSpaceTB, QuotaConcurrentUsers, QuotaAVTenants},
+	"HealthStatus.status":     {"ok", "unavailable"},
+	"Version.name":            Versions,
+	"Operation.status":        {OperationRunning, OperationSucceeded, OperationFailed, OperationCancelled},
+	"Operation.resource_type": resourceTypes,
+	"EHSCluster.status":       {EHSClusterReady, EHSClusterUpgrading, EHSClusterResizing},
+	"Upgrade.status":          {UpgradeRunning, UpgradePaused, UpgradeRollingBack, UpgradeSucceeded, UpgradeRolledBack, UpgradeFailed},
+	"UpgradeNode.status":      {NodePending, NodeUpgrading, NodeUpgraded, NodeRollingBack, NodeRolledBack},
+	"AW.status":               {AWReady, AWMigrating},
+	"AW.migration_strategy":   MigrationStrategies,
+	"Migration.strategy":      MigrationStrategies,
+	"Migration.status":        {MigrationRunning, MigrationSucceeded, MigrationFailed},
+	"MigrationStep.name":      {MigrationDrain, MigrationProvision, MigrationSwitchDNS, MigrationTeardown},
+	"MigrationStep.status":    {MigrationStepPending, MigrationStepRunning, MigrationStepDone, MigrationStepFailed},
+	"Release.status":          releaseStatuses,
+	"NodePool.status":         {NodePoolReady, NodePoolScaling},
+	"NodePool.instance_size":  instanceSizeNames(),
+	"Taint.effect":            {TaintNoSchedule, TaintPreferNoSchedule, TaintNoExecute},
+	"ScheduledAction.status":  {ScheduledActionApplied, ScheduledActionFailed},
+}
+
+func instanceSizeNames() []string {
+	var names []string
+	for _, size := range InstanceSizes {
+		names = append(names, size.Name)
+	}
+	return names
+}
+
+var openAPIDescriptions = map[string]string{
+	"Revision.object":                "The resource as it was after this revision. Purged resources leave a tombstone revision without it.",
+	"AuditEvent.before":              "The resource before the call.",
+	"AuditEvent.after":               "The resource after the call.",
+	"AuditChange.before":             "The field's value before the call.",
+	"AuditChange.after":              "The field's value after the call.",
+	"Webhook.secret":                 "Used to sign deliveries. It's never returned.",
+	"EHSCluster.release":             "Changing it starts a rolling upgrade, and it keeps its old value until the upgrade succeeds. Clusters can't be created on, or upgraded to, an EOL release.",
+	"EHSCluster.profile":             "Changing it resizes the cluster in place, and it keeps its old value until the resize finishes.",
+	"EHSCluster.status":              "Set by the server.",
+	"EHSCluster.capacity":            "What the cluster's profile and node pools can host. Set by the server.",
+	"EHSCluster.autoscaling":         "Scales the number of the profile's nodes the cluster runs with the AW seats it hosts. Without it, the cluster runs the profile's usual number of nodes.",
+	"EHSCluster.current_nodes":       "How many of the profile's nodes are running. Set by the server, and catches up with desired_nodes as the cluster scales.",
+	"EHSCluster.desired_nodes":       "How many of the profile's nodes the autoscaler wants running. Set by the server.",
+	"EHSCluster.last_scaled_at":      "When desired_nodes last changed. Set by the server.",
+	"EHSCluster.operation_id":        "The operation tracking the cluster's resize or upgrade. Set by the server.",
+	"Autoscaling.target_utilization": "The percentage of the nodes' AW seats the hosted AWs should use.",
+	"Autoscaling.scale_in_cooldown":  "How long after the cluster last scaled before nodes can be removed, like \"10m\".",
+	"Release.regions":                "The regions the release is available in.",
+	"Profile.regions":                "The regions the profile is available in.",
+	"AW.ehs_cluster_id":              "The EHS cluster hosting the AW, which must have room for its concurrent users. If it's left out, the server places the AW on a ready cluster in its region and account with room for it. Changing it migrates the AW, and it keeps its old value until DNS is switched to the new cluster.",
+	"AW.dns_endpoint":                "Set by the server if it's left out, and switched to the new EHS cluster's when the AW is migrated.",
+	"AW.status":                      "Set by the server.",
+	"AW.migration_strategy":          "How the AW is migrated when its EHS cluster changes: cut_over drains its sessions before provisioning it on the new cluster, and blue_green provisions it on the new cluster before switching DNS and draining the old one. Defaults to cut_over.",
+	"AW.target_ehs_cluster_id":       "The EHS cluster the AW is being migrated to. Set by the server.",
+	"AW.migration_id":                "The migration moving the AW. Set by the server.",
+	"AW.operation_id":                "The operation tracking the AW's migration. Set by the server.",
+	"Upgrade.operation_id":           "The operation tracking the upgrade, which finishes when it does.",
+	"Migration.operation_id":         "The operation tracking the migration, which finishes when it does.",
+	"Operation.kind":                 "The resource type and what's being done to it, like \"ehscluster.create\". Upgrades and migrations are \"ehscluster.upgrade\" and \"aw.migrate\".",
+	"AW.region":                      "Set by the server from the AW's EHS cluster. If it's set, the AW is only placed on clusters in that region.",
+	"AW.concurrent_users":            "Set by the AW's schedule, if it has one, and can't be changed directly while it does.",
+	"AW.schedule_id":                 "The schedule setting the AW's concurrent users. Set by the server.",
+	"NodePool.labels":                "Applied to the pool's nodes as well as the pool.",
+	"NodePool.taints":                "Applied to the pool's nodes. AWs don't tolerate taints, so a pool with a NoSchedule or NoExecute taint doesn't add AW seats to its cluster.",
+	"NodePool.current_count":         "How many nodes are running. Set by the server, and catches up with desired_count as the pool scales.",
+	"NodePool.instance_size":         "Can't be changed once the pool is created.",
+	"AWSchedule.aw_id":               "The AW whose concurrent users the schedule sets. An AW can only have one schedule, and it can't be changed once the schedule is created.",
+	"AWSchedule.timezone":            "The IANA time zone the rules' cron expressions are in, like \"America/Chicago\". Defaults to UTC.",
+	"AWSchedule.next_action":         "The next change the schedule will make. Set by the server.",
+	"AWSchedule.last_action":         "The last change the schedule made, or tried to make. Set by the server.",
+	"ScheduleRule.cron":              "A five field cron expression: minute, hour, day of month, month and day of week, like \"0 7 * * mon-fri\".",
+	"ScheduleRule.concurrent_users":  "The AW's concurrent users from when the cron expression matches.",
+	"ScheduledAction.status":         "Whether the change was made. Changes fail if the AW's EHS cluster doesn't have room for it, or it would exceed a quota.",
+}
+
+type schemaGenerator struct {
+	names map[reflect.Type]string
+}
+
+func newSchemaGenerator() schemaGenerator {
+	g := schemaGenerator{names: map[reflect.Type]string{}}
+	for name, v := range openAPISchemas {
+		g.names[reflect.TypeOf(v)] = name
+	}
+	return g
+}
+
+// schema describes how t is encoded as JSON, referring to components for
+// named types.
+func (g schemaGenerator) schema(t reflect.Type) *OpenAPISchema {
+	if name, ok := g.names[t]; ok {
+		return &OpenAPISchema{Ref: openAPISchemaPrefix + name}
+	}
+	return g.inline(t, "")
+}
+
+func (g schemaGenerator) inline(t reflect.Type, name string) *OpenAPISchema {
+	switch t {
+	case reflect.TypeOf(time.Time{}):
+		return &OpenAPISchema{Type: "string", Format: "date-time"}
+	case reflect.TypeOf(json.RawMessage{}):
+		return &OpenAPISchema{}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.schema(t.Elem())
+	case reflect.String:
+		return &OpenAPISchema{Type: "string"}
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int64:
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &OpenAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := 0.0
+		return &OpenAPISchema{Type: "integer", Format: "int64", Minimum: &zero}
+	case reflect.Float32, reflect.Float64:
+		return &OpenAPISchema{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+		return &OpenAPISchema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &OpenAPISchema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		s := &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{}}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			prop, ok := jsonName(field)
+			if !ok {
+				continue
+			}
+			ps := g.schema(field.Type)
+			if enum, ok := openAPIEnums[name+"."+prop]; ok {
+				if ps.Type == "array" {
+					ps.Items.Enum = enum
+				} else {
+					ps.Enum = enum
+				}
+			}
+			if desc, ok := openAPIDescriptions[name+"."+prop]; ok {
+				ps.Description = desc
+			}
+			s.Properties[prop] = ps
+		}
+		return s
+	}
+	return &OpenAPISchema{}
+}
+
+// jsonName returns the name encoding/json uses for field, and whether it's
+// encoded at all.
+func jsonName(field reflect.StructField) (string, bool) {
+	if field.PkgPath != "" {
+		return "", false
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name := strings.Split(tag, ",")[0]
+	if name == "" {
+		name = field.Name
+	}
+	return name, true
+}
+
+// openAPIRoute documents one route. Result is the Response field the route
+// returns its results in, and Schema the component schema of each result.
+// Async routes can respond with an Operation instead.
+type openAPIRoute struct {
+	method  string
+	path    string
+	id      string
+	summary string
+	tag     string
+	params  []OpenAPIParameter
+	request string
+	result  string
+	schema  string
+	status  int
+	errors  []int
+	async   bool
+}
+
+func pathParam(name, description string) OpenAPIParameter {
+	return OpenAPIParameter{Name: name, In: "path", Required: true, Description: description, Schema: &OpenAPISchema{Type: "string"}}
+}
+
+func queryParam(name, description string, schema *OpenAPISchema) OpenAPIParameter {
+	return OpenAPIParameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+var (
+	idParam       = pathParam("id", "")
+	poolParam     = pathParam("pool", "The node pool's ID.")
+	deletedParam  = queryParam("deleted", "Whether to include soft deleted resources, or only list them.", &OpenAPISchema{Type: "string", Enum: []string{string(deletedInclude), string(deletedOnly)}})
+	forceParam    = queryParam("force", "Delete permanently, even if soft delete is enabled.", &OpenAPISchema{Type: "boolean"})
+	revisionParam = queryParam("revision", "Return the resource as of this revision number.", &OpenAPISchema{Type: "integer", Format: "int64"})
+	indexParam    = queryParam("index", "Block until the resource changes after this X-Edison-Index.", &OpenAPISchema{Type: "integer", Format: "int64"})
+	waitParam     = queryParam("wait", "How long to block for, as a Go duration like 30s. Capped by the server.", &OpenAPISchema{Type: "string", Format: "duration"})
+	selectorParam = queryParam("selector", "Only list resources whose labels match, like env=prod,team!=radiology. Requirements can be key=value, key!=value, key or !key.", &OpenAPISchema{Type: "string"})
+	preferParam   = OpenAPIParameter{Name: "Prefer", In: "header", Description: "Set to respond-async to make the change in the background, responding with an operation to poll.", Schema: &OpenAPISchema{Type: "string", Enum: []string{"respond-async"}}}
+)
+
+func resourceRoutes(collection, name string) []openAPIRoute {
+	tag := name + "s"
+	return []openAPIRoute{
+		{method: http.MethodGet, path: "/" + collection, id: "list" + name + "s", summary: "List " + name + "s", tag: tag, params: []OpenAPIParameter{deletedParam, selectorParam}, result: collection, schema: name, status: http.StatusOK, errors: []int{http.StatusBadRequest}},
+		{method: http.MethodPost, path: "/" + collection, id: "create" + name, summary: "Create a " + name, tag: tag, request: name, result: collection, schema: name, status: http.StatusCreated, errors: []int{http.StatusBadRequest, http.StatusForbidden}, async: true},
+		{method: http.MethodGet, path: "/" + collection + "/{id}", id: "get" + name, summary: "Get a " + name, tag: tag, params: []OpenAPIParameter{idParam, revisionParam, indexParam, waitParam}, result: collection, schema: name, status: http.StatusOK, errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+		{method: http.MethodPost, path: "/" + collection + "/{id}", id: "restore" + name, summary: "Restore a soft deleted " + name, tag: tag, params: []OpenAPIParameter{pathParam("id", "The ID followed by :restore.")}, result: collection, schema: name, status: http.StatusOK, errors: []int{http.StatusForbidden, http.StatusNotFound, http.StatusConflict}},
+		{method: http.MethodPut, path: "/" + collection + "/{id}", id: "update" + name, summary: "Replace a " + name, tag: tag, params: []OpenAPIParameter{idParam}, request: name, result: collection, schema: name, status: http.StatusOK, errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound}, async: true},
+		{method: http.MethodDelete, path: "/" + collection + "/{id}", id: "delete" + name, summary: "Delete a " + name, tag: tag, params: []OpenAPIParameter{idParam, forceParam}, result: collection, schema: name, status: http.StatusOK, errors: []int{http.StatusNotFound}, async: true},
+		{method: http.MethodGet, path: "/" + collection + "/{id}/revisions", id: "list" + name + "Revisions", summary: "List a " + name + "'s revisions", tag: tag, params: []OpenAPIParameter{idParam}, result: "revisions", schema: "Revision", status: http.StatusOK, errors: []int{http.StatusNotFound}},
+	}
+}
+
+// openAPIRoutes documents every route in routes.
+func (a API) openAPIRoutes() []openAPIRoute {
+	var routes []openAPIRoute
+	routes = append(routes, resourceRoutes("eastores", "EAStore")...)
+	routes = append(routes, resourceRoutes("ehsclusters", "EHSCluster")...)
+	routes = append(routes, resourceRoutes("aws", "AW")...)
+	routes = append(routes, resourceRoutes("avs", "AV")...)
+	routes = append(routes, openAPIRoute{
+		method: http.MethodGet, path: "/quotas", id: "listQuotaUsage", summary: "List quota usage", tag: "Quotas",
+		params: []OpenAPIParameter{queryParam("account_id", "Only return these accounts. Can be repeated.", &OpenAPISchema{Type: "array", Items: &OpenAPISchema{Type: "string"}})},
+		result: "quotas", schema: "QuotaUsage", status: http.StatusOK,
+	})
+	routes = append(routes, []openAPIRoute{
+		{
+			method: http.MethodGet, path: "/catalog/releases", id: "listReleases", summary: "List EHS releases", tag: "Catalog",
+			params: []OpenAPIParameter{
+				queryParam("region", "Only return releases available in this region.", &OpenAPISchema{Type: "string"}),
+				queryParam("status", "", &OpenAPISchema{Type: "string", Enum: releaseStatuses}),
+			},
+			result: "releases", schema: "Release", status: http.StatusOK,
+		},
+		{method: http.MethodGet, path: "/catalog/regions", id: "listRegions", summary: "List regions", tag: "Catalog", result: "regions", schema: "Region", status: http.StatusOK},
+		{
+			method: http.MethodGet, path: "/catalog/profiles", id: "listProfiles", summary: "List EHS cluster profiles", tag: "Catalog",
+			params: []OpenAPIParameter{queryParam("region", "Only return profiles available in this region.", &OpenAPISchema{Type: "string"})},
+			result: "profiles", schema: "Profile", status: http.StatusOK,
+		},
+		{method: http.MethodGet, path: "/catalog/instancesizes", id: "listInstanceSizes", summary: "List node pool instance sizes", tag: "Catalog", result: "instance_sizes", schema: "InstanceSize", status: http.StatusOK},
+	}...)
+	routes = append(routes, []openAPIRoute{
+		{method: http.MethodGet, path: "/ehsclusters/{id}/nodepools", id: "listNodePools", summary: "List an EHS cluster's node pools", tag: "NodePools", params: []OpenAPIParameter{idParam, selectorParam}, result: "nodepools", schema: "NodePool", status: http.StatusOK, errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+		{method: http.MethodPost, path: "/ehsclusters/{id}/nodepools", id: "createNodePool", summary: "Create a node pool", tag: "NodePools", params: []OpenAPIParameter{idParam}, request: "NodePool", result: "nodepools", schema: "NodePool", status: http.StatusCreated, errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+		{method: http.MethodGet, path: "/ehsclusters/{id}/nodepools/{pool}", id: "getNodePool", summary: "Get a node pool", tag: "NodePools", params: []OpenAPIParameter{idParam, poolParam, indexParam, waitParam}, result: "nodepools", schema: "NodePool", status: http.StatusOK, errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+		{method: http.MethodPut, path: "/ehsclusters/{id}/nodepools/{pool}", id: "updateNodePool", summary: "Replace a node pool", tag: "NodePools", params: []OpenAPIParameter{idParam, poolParam}, request: "NodePool", result: "nodepools", schema: "NodePool", status: http.StatusOK, errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict}},
+		{method: http.MethodDelete, path: "/ehsclusters/{id}/nodepools/{pool}", id: "deleteNodePool", summary: "Delete a node pool", tag: "NodePools", params: []OpenAPIParameter{idParam, poolParam}, result: "nodepools", schema: "NodePool", status: http.StatusOK, errors: []int{http.StatusNotFound, http.StatusConflict}},
+	}...)
+	if a.Webhooks != nil {
+		routes = append(routes, []openAPIRoute{
+			{method: http.MethodGet, path: "/webhooks", id: "listWebhooks", summary: "List webhooks", tag: "Webhooks", result: "webhooks", schema: "Webhook", status: http.StatusOK},
+			{method: http.MethodPost, path: "/webhooks", id: "createWebhook", summary: "Create a webhook", tag: "Webhooks", request: "Webhook", result: "webhooks", schema: "Webhook", status: http.StatusCreated, errors: []int{http.StatusBadRequest}},
+			{method: http.MethodGet, path: "/webhooks/{id}", id: "getWebhook", summary: "Get a webhook", tag: "Webhooks", params: []OpenAPIParameter{idParam}, result: "webhooks", schema: "Webhook", status: http.StatusOK, errors: []int{http.StatusNotFound}},
+			{method: http.MethodPost, path: "/webhooks/{id}", id: "pingWebhook", summary: "Send a test delivery", tag: "Webhooks", params: []OpenAPIParameter{pathParam("id", "The ID followed by :ping.")}, result: "deliveries", schema: "Delivery", status: http.StatusAccepted, errors: []int{http.StatusNotFound, http.StatusConflict}},
+			{method: http.MethodPut, path: "/webhooks/{id}", id: "updateWebhook", summary: "Replace a webhook", tag: "Webhooks", params: []OpenAPIParameter{idParam}, request: "Webhook", result: "webhooks", schema: "Webhook", status: http.StatusOK, errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+			{method: http.MethodDelete, path: "/webhooks/{id}", id: "deleteWebhook", summary: "Delete a webhook", tag: "Webhooks", params: []OpenAPIParameter{idParam}, result: "webhooks", schema: "Webhook", status: http.StatusOK, errors: []int{http.StatusNotFound}},
+			{method: http.MethodGet, path: "/webhooks/{id}/deliveries", id: "listDeliveries", summary: "List a webhook's deliveries", tag: "Webhooks", params: []OpenAPIParameter{idParam}, result: "deliveries", schema: "Delivery", status: http.StatusOK, errors: []int{http.StatusNotFound}},
+		}...)
+	}
+	if a.Operations != nil {
+		routes = append(routes, []openAPIRoute{
+			{
+				method: http.MethodGet, path: "/operations", id: "listOperations", summary: "List operations", tag: "Operations",
+				params: []OpenAPIParameter{
+					queryParam("resource_type", "", &OpenAPISchema{Type: "string", Enum: resourceTypes}),
+					queryParam("resource_id", "", &OpenAPISchema{Type: "string"}),
+					queryParam("status", "", &OpenAPISchema{Type: "string", Enum: []string{OperationRunning, OperationSucceeded, OperationFailed, OperationCancelled}}),
+				},
+				result: "operations", schema: "Operation", status: http.StatusOK,
+			},
+			{method: http.MethodGet, path: "/operations/{id}", id: "getOperation", summary: "Get an operation", tag: "Operations", params: []OpenAPIParameter{idParam, indexParam, waitParam}, result: "operations", schema: "Operation", status: http.StatusOK, errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+			{method: http.MethodPost, path: "/operations/{id}", id: "cancelOperation", summary: "Cancel a running operation", tag: "Operations", params: []OpenAPIParameter{pathParam("id", "The ID followed by :cancel.")}, result: "operations", schema: "Operation", status: http.StatusOK, errors: []int{http.StatusNotFound, http.StatusConflict}},
+		}...)
+	} else {
+		for i := range routes {
+			routes[i].async = false
+		}
+	}
+	for i := range routes {
+		// EHS clusters can't be resized or upgraded while another change
+		// is in progress, or changed while one starts or finishes, AWs
+		// can't be placed on a cluster without room, and can't be moved
+		// again while they're migrating.
+		switch routes[i].id {
+		case "updateEHSCluster", "createAW", "updateAW":
+			routes[i].errors = append(routes[i].errors, http.StatusConflict)
+		}
+	}
+	if a.Upgrades != nil {
+		routes = append(routes, []openAPIRoute{
+			{
+				method: http.MethodGet, path: "/upgrades", id: "listUpgrades", summary: "List EHS cluster upgrades", tag: "Upgrades",
+				params: []OpenAPIParameter{
+					queryParam("ehs_cluster_id", "", &OpenAPISchema{Type: "string"}),
+					queryParam("status", "", &OpenAPISchema{Type: "string", Enum: []string{UpgradeRunning, UpgradePaused, UpgradeRollingBack, UpgradeSucceeded, UpgradeRolledBack, UpgradeFailed}}),
+				},
+				result: "upgrades", schema: "Upgrade", status: http.StatusOK,
+			},
+			{method: http.MethodGet, path: "/upgrades/{id}", id: "getUpgrade", summary: "Get an EHS cluster upgrade", tag: "Upgrades", params: []OpenAPIParameter{idParam, indexParam, waitParam}, result: "upgrades", schema: "Upgrade", status: http.StatusOK, errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+			{method: http.MethodPost, path: "/upgrades/{id}", id: "controlUpgrade", summary: "Pause, resume or roll back an EHS cluster upgrade", tag: "Upgrades", params: []OpenAPIParameter{pathParam("id", "The ID followed by :pause, :resume or :rollback.")}, result: "upgrades", schema: "Upgrade", status: http.StatusOK, errors: []int{http.StatusNotFound, http.StatusConflict}},
+		}...)
+	}
+	if a.Migrations != nil {
+		routes = append(routes, []openAPIRoute{
+			{
+				method: http.MethodGet, path: "/migrations", id: "listMigrations", summary: "List AW migrations", tag: "Migrations",
+				params: []OpenAPIParameter{
+					queryParam("aw_id", "", &OpenAPISchema{Type: "string"}),
+					queryParam("ehs_cluster_id", "Migrations from or to the EHS cluster.", &OpenAPISchema{Type: "string"}),
+					queryParam("status", "", &OpenAPISchema{Type: "string", Enum: []string{MigrationRunning, MigrationSucceeded, MigrationFailed}}),
+				},
+				result: "migrations", schema: "Migration", status: http.StatusOK,
+			},
+			{method: http.MethodGet, path: "/migrations/{id}", id: "getMigration", summary: "Get an AW migration", tag: "Migrations", params: []OpenAPIParameter{idParam, indexParam, waitParam}, result: "migrations", schema: "Migration", status: http.StatusOK, errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+		}...)
+	}
+	if a.Scheduler != nil {
+		routes = append(routes, []openAPIRoute{
+			{method: http.MethodGet, path: "/awschedules", id: "listAWSchedules", summary: "List AW schedules", tag: "AWSchedules", params: []OpenAPIParameter{queryParam("aw_id", "", &OpenAPISchema{Type: "string"})}, result: "awschedules", schema: "AWSchedule", status: http.StatusOK},
+			{method: http.MethodPost, path: "/awschedules", id: "createAWSchedule", summary: "Create an AW schedule", tag: "AWSchedules", request: "AWSchedule", result: "awschedules", schema: "AWSchedule", status: http.StatusCreated, errors: []int{http.StatusBadRequest}},
+			{method: http.MethodGet, path: "/awschedules/{id}", id: "getAWSchedule", summary: "Get an AW schedule", tag: "AWSchedules", params: []OpenAPIParameter{idParam, indexParam, waitParam}, result: "awschedules", schema: "AWSchedule", status: http.StatusOK, errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+			{method: http.MethodPut, path: "/awschedules/{id}", id: "updateAWSchedule", summary: "Replace an AW schedule", tag: "AWSchedules", params: []OpenAPIParameter{idParam}, request: "AWSchedule", result: "awschedules", schema: "AWSchedule", status: http.StatusOK, errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+			{method: http.MethodDelete, path: "/awschedules/{id}", id: "deleteAWSchedule", summary: "Delete an AW schedule", tag: "AWSchedules", params: []OpenAPIParameter{idParam}, result: "awschedules", schema: "AWSchedule", status: http.StatusOK, errors: []int{http.StatusNotFound}},
+		}...)
+	}
+	if a.Audit != nil {
+		routes = append(routes, openAPIRoute{
+			method: http.MethodGet, path: "/audit", id: "listAuditEvents", summary: "List audit events", tag: "Audit",
+			params: []OpenAPIParameter{
+				queryParam("resource_type", "", &OpenAPISchema{Type: "string"}),
+				queryParam("resource_id", "", &OpenAPISchema{Type: "string"}),
+				queryParam("principal", "", &OpenAPISchema{Type: "string"}),
+				queryParam("since", "", &OpenAPISchema{Type: "string", Format: "date-time"}),
+				queryParam("until", "", &OpenAPISchema{Type: "string", Format: "date-time"}),
+			},
+			result: "audit_events", schema: "AuditEvent", status: http.StatusOK, errors: []int{http.StatusBadRequest},
+		})
+	}
+	return routes
+}
+
+var errorDescriptions = map[int]string{
+	http.StatusBadRequest:          "The request was invalid.",
+	http.StatusUnauthorized:        "No valid token was presented.",
+	http.StatusForbidden:           "The request would exceed a quota, which is returned in quotas.",
+	http.StatusNotFound:            "The resource doesn't exist.",
+	http.StatusConflict:            "The resource isn't in a state that allows this.",
+	http.StatusTooManyRequests:     "The token is rate limited for this route.",
+	http.StatusInternalServerError: "Something went wrong on the server.",
+}
+
+func jsonContent(schema *OpenAPISchema) map[string]OpenAPIMediaType {
+	return map[string]OpenAPIMediaType{"application/json": {Schema: schema}}
+}
+
+func refSchema(name string) *OpenAPISchema {
+	return &OpenAPISchema{Ref: openAPISchemaPrefix + name}
+}
+
+func errorResponse(status int) OpenAPIResponse {
+	resp := OpenAPIResponse{
+		Description: errorDescriptions[status],
+		Content:     jsonContent(refSchema("ErrorResponse")),
+	}
+	if status == http.StatusTooManyRequests {
+		resp.Headers = map[string]OpenAPIHeader{
+			"Retry-After": {Description: "Seconds to wait before retrying.", Schema: &OpenAPISchema{Type: "integer"}},
+		}
+	}
+	return resp
+}
+
+func (r openAPIRoute) operation() *OpenAPIOperation {
+	op := &OpenAPIOperation{
+		OperationID: r.id,
+		Summary:     r.summary,
+		Tags:        []string{r.tag},
+		Parameters:  r.params,
+		Responses:   map[string]OpenAPIResponse{},
+	}
+	if r.request != "" {
+		op.RequestBody = &OpenAPIRequestBody{Required: true, Content: jsonContent(refSchema(r.request))}
+	}
+	success := OpenAPIResponse{
+		Description: http.StatusText(r.status),
+		Content: jsonContent(&OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{
+			r.result: {Type: "array", Items: refSchema(r.schema)},
+		}}),
+		Headers: map[string]OpenAPIHeader{
+			"X-Edison-Index": {Description: "The index of the most recent change, for blocking queries.", Schema: &OpenAPISchema{Type: "integer", Format: "int64"}},
+		},
+	}
+	op.Responses[strconv.Itoa(r.status)] = success
+	if r.async {
+		op.Parameters = append(op.Parameters[:len(op.Parameters):len(op.Parameters)], preferParam)
+		op.Responses[strconv.Itoa(http.StatusAccepted)] = OpenAPIResponse{
+			Description: "The change is being made in the background.",
+			Content: jsonContent(&OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{
+				"operations": {Type: "array", Items: refSchema("Operation")},
+			}}),
+			Headers: map[string]OpenAPIHeader{
+				"Location":           {Description: "The operation to poll.", Schema: &OpenAPISchema{Type: "string"}},
+				"Preference-Applied": {Schema: &OpenAPISchema{Type: "string"}},
+			},
+		}
+	}
+	for _, status := range append(r.errors, http.StatusUnauthorized, http.StatusTooManyRequests, http.StatusInternalServerError) {
+		op.Responses[strconv.Itoa(status)] = errorResponse(status)
+	}
+	return op
+}
+
+// systemOperations documents the routes outside the main router. Only
+// /events is versioned; the rest are served from baseURL itself.
+func (a API) systemOperations(baseURL string) map[string]OpenAPIPathItem {
+	public := &[]map[string][]string{}
+	unversioned := []OpenAPIServer{{URL: path.Join("/", baseURL)}}
+	health := func(id, summary string) *OpenAPIOperation {
+		return &OpenAPIOperation{
+			OperationID: id, Summary: summary, Tags: []string{"Operations"}, Security: public, Servers: unversioned,
+			Responses: map[string]OpenAPIResponse{
+				"200": {Description: "Healthy.", Content: jsonContent(refSchema("HealthStatus"))},
+				"503": {Description: "Unhealthy.", Content: jsonContent(refSchema("HealthStatus"))},
+			},
+		}
+	}
+	items := map[string]OpenAPIPathItem{
+		"/healthz": {"get": health("getHealthz", "Check the server is running")},
+		"/readyz":  {"get": health("getReadyz", "Check the server can serve requests")},
+		"/openapi.json": {"get": &OpenAPIOperation{
+			OperationID: "getOpenAPI", Summary: "Get this document", Tags: []string{"Operations"}, Security: public, Servers: unversioned,
+			Responses: map[string]OpenAPIResponse{"200": {Description: "The OpenAPI document.", Content: jsonContent(&OpenAPISchema{Type: "object"})}},
+		}},
+		"/capabilities": {"get": &OpenAPIOperation{
+			OperationID: "getCapabilities", Summary: "List the API versions and features the server supports", Tags: []string{"Operations"}, Security: public, Servers: unversioned,
+			Responses: map[string]OpenAPIResponse{"200": {Description: "The server's capabilities.", Content: jsonContent(refSchema("Capabilities"))}},
+		}},
+		"/events": {"get": &OpenAPIOperation{
+			OperationID: "streamEvents", Summary: "Stream resource changes as server-sent events", Tags: []string{"Events"},
+			Parameters: []OpenAPIParameter{
+				queryParam("resource_type", "", &OpenAPISchema{Type: "string", Enum: resourceTypes}),
+				queryParam("resource_id", "", &OpenAPISchema{Type: "string"}),
+				queryParam("event", "Only stream these events. Can be repeated.", &OpenAPISchema{Type: "array", Items: &OpenAPISchema{Type: "string", Enum: revisionEvents}}),
+				{Name: "Last-Event-ID", In: "header", Description: "Resume after this event.", Schema: &OpenAPISchema{Type: "integer", Format: "int64"}},
+			},
+			Responses: map[string]OpenAPIResponse{
+				"200": {Description: "A stream of events, each carrying a Revision as its data.", Content: map[string]OpenAPIMediaType{"text/event-stream": {Schema: &OpenAPISchema{Type: "string"}}}},
+				"400": errorResponse(http.StatusBadRequest),
+				"401": errorResponse(http.StatusUnauthorized),
+			},
+		}},
+	}
+	if a.Metrics != nil {
+		items["/metrics"] = OpenAPIPathItem{"get": &OpenAPIOperation{
+			OperationID: "getMetrics", Summary: "Get Prometheus metrics", Tags: []string{"Operations"}, Security: public, Servers: unversioned,
+			Responses: map[string]OpenAPIResponse{"200": {Description: "Metrics in the Prometheus text format.", Content: map[string]OpenAPIMediaType{"text/plain": {Schema: &OpenAPISchema{Type: "string"}}}}},
+		}}
+	}
+	if a.Chaos != nil {
+		chaos := func(id, summary string, body bool) *OpenAPIOperation {
+			op := &OpenAPIOperation{
+				OperationID: id, Summary: summary, Tags: []string{"Admin"}, Servers: unversioned,
+				Responses: map[string]OpenAPIResponse{
+					"200": {Description: "The fault injection config.", Content: jsonContent(refSchema("ChaosConfig"))},
+					"401": errorResponse(http.StatusUnauthorized),
+				},
+			}
+			if body {
+				op.RequestBody = &OpenAPIRequestBody{Required: true, Content: jsonContent(refSchema("ChaosConfig"))}
+				op.Responses["400"] = errorResponse(http.StatusBadRequest)
+			}
+			return op
+		}
+		items["/admin/chaos"] = OpenAPIPathItem{
+			"get": chaos("getChaos", "Get the fault injection config", false),
+			"put": chaos("updateChaos", "Replace the fault injection config", true),
+		}
+	}
+	return items
+}
+
+// OpenAPI describes every route the API serves under baseURL, given how
+// it's configured.
+func (a API) OpenAPI(baseURL string) OpenAPIDocument {
+	doc := OpenAPIDocument{
+		OpenAPI: "3.0.3",
+		Info: OpenAPIInfo{
+			Title:       "edisond",
+			Description: "Manages Edison EA stores, EHS clusters, AWs and AVs. Every error response lists RequestErrors, whose error slug is one of " + strings.Join(requestErrorSlugs, ", ") + ". The same routes are served without the /" + CurrentVersion + " prefix for older clients, but are deprecated.",
+			Version:     "1.0.0",
+		},
+		Servers:  []OpenAPIServer{{URL: path.Join("/", baseURL, versionPath(CurrentVersion))}},
+		Security: []map[string][]string{{"bearer": {}}},
+		Paths:    a.systemOperations(baseURL),
+		Components: OpenAPIComponents{
+			Schemas: map[string]*OpenAPISchema{
+				"ErrorResponse": {Type: "object", Properties: map[string]*OpenAPISchema{
+					"errors": {Type: "array", Items: refSchema("RequestError")},
+					"quotas": {Type: "array", Items: refSchema("QuotaUsage")},
+				}},
+			},
+			SecuritySchemes: map[string]OpenAPISecurityScheme{
+				"bearer": {Type: "http", Scheme: "bearer"},
+			},
+		},
+	}
+	g := newSchemaGenerator()
+	for name, v := range openAPISchemas {
+		doc.Components.Schemas[name] = g.inline(reflect.TypeOf(v), name)
+	}
+	for _, r := range a.openAPIRoutes() {
+		item, ok := doc.Paths[r.path]
+		if !ok {
+			item = OpenAPIPathItem{}
+			doc.Paths[r.path] = item
+		}
+		item[strings.ToLower(r.method)] = r.operation()
+	}
+	return doc
+}
+
+func (a API) handleOpenAPI(doc OpenAPIDocument) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			api.Encode(w, r, http.StatusMethodNotAllowed, Response{Errors: api.ActOfGodError})
+			return
+		}
+		api.Encode(w, r, http.StatusOK, doc)
+	})
+}
+
+// CheckOpenAPI returns an error listing every route the API serves that doc
+// doesn't describe, and every route doc describes that the API doesn't
+// serve.
+func (a API) CheckOpenAPI(doc OpenAPIDocument) error {
+	var problems []string
+	served := map[string]bool{}
+	for _, rt := range a.routes() {
+		served[rt.method+" "+rt.pattern] = true
+		if doc.Paths[rt.pattern][strings.ToLower(rt.method)] == nil {
+			problems = append(problems, "undocumented route "+rt.method+" "+rt.pattern)
+		}
+	}
+	system := a.systemOperations("")
+	for p, item := range doc.Paths {
+		for method := range item {
+			if _, ok := system[p][method]; ok {
+				continue
+			}
+			if !served[strings.ToUpper(method)+" "+p] {
+				problems = append(problems, "documented route isn't served: "+strings.ToUpper(method)+" "+p)
+			}
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("OpenAPI document doesn't match the API:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// CheckOpenAPISchema returns an error if v, a struct used by a client of
+// the API, encodes to JSON that doesn't match the named schema in doc: if it
+// has properties the schema doesn't, or properties of a different type.
+func CheckOpenAPISchema(doc OpenAPIDocument, name string, v interface{}) error {
+	schema, ok := doc.Components.Schemas[name]
+	if !ok {
+		return fmt.Errorf("no schema named %q", name)
+	}
+	var problems []string
+	checkSchema(doc, schema, reflect.TypeOf(v), name, &problems)
+	if len(problems) > 0 {
+		return fmt.Errorf("%T doesn't match schema %s:\n  %s", v, name, strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+func checkSchema(doc OpenAPIDocument, schema *OpenAPISchema, t reflect.Type, path string, problems *[]string) {
+	schema = resolveSchema(doc, schema)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	got := newSchemaGenerator().inline(t, "")
+	if schema.Type != got.Type {
+		*problems = append(*problems, fmt.Sprintf("%s: schema has type %q, Go type %s encodes as %q", path, schema.Type, t, got.Type))
+		return
+	}
+	switch got.Type {
+	case "array":
+		checkSchema(doc, schema.Items, t.Elem(), path+"[]", problems)
+	case "object":
+		if t.Kind() != reflect.Struct {
+			return
+		}
+		for i := 0; i < t.NumField(); i++ {
+			prop, ok := jsonName(t.Field(i))
+			if !ok {
+				continue
+			}
+			ps, ok := schema.Properties[prop]
+			if !ok {
+				*problems = append(*problems, path+"."+prop+": not in schema")
+				continue
+			}
+			checkSchema(doc, ps, t.Field(i).Type, path+"."+prop, problems)
+		}
+	}
+}
+
+func resolveSchema(doc OpenAPIDocument, schema *OpenAPISchema) *OpenAPISchema {
+	for schema != nil && schema.Ref != "" {
+		schema = doc.Components.Schemas[strings.TrimPrefix(schema.Ref, openAPISchemaPrefix)]
+	}
+	if schema == nil {
+		return &OpenAPISchema{}
+	}
+	return schema
+}
@@ -0,0 +1,81 @@
+package api_test
+
+import (
+	"testing"
+
+	"github.com/rahoolp/terraform-provider-edison/internal/api"
+	edison "github.com/rahoolp/terraform-provider-edison/internal/client"
+)
+
+// TestOpenAPI checks the OpenAPI document against the routes the API serves
+// and the client's types, like cmd/openapi -check, so drift fails the
+// tests.
+func TestOpenAPI(t *testing.T) {
+	storer, err := api.NewStorer()
+	if err != nil {
+		t.Fatalf("Error setting up storer: %s", err)
+	}
+	audit, err := api.NewAuditLog("")
+	if err != nil {
+		t.Fatalf("Error setting up audit log: %s", err)
+	}
+	// Enable everything optional, so the whole API is described.
+	a := api.API{
+		Storer:   storer,
+		Chaos:    api.NewChaos(api.ChaosConfig{}),
+		Auth:     api.NewAuth(api.DefaultTokens),
+		Metrics:  api.NewMetrics(storer),
+		Audit:    audit,
+		Webhooks: api.NewWebhooks(storer),
+	}
+	a.Operations = api.NewOperations(storer, a.Metrics, api.OperationsConfig{})
+	a.Upgrades = api.NewUpgrades(storer, a.Operations, api.UpgradesConfig{})
+	a.Migrations = api.NewMigrations(storer, a.Operations, api.MigrationsConfig{})
+	a.Scheduler = api.NewScheduler(storer, nil, api.SchedulerConfig{})
+	doc := a.OpenAPI("")
+
+	err = a.CheckOpenAPI(doc)
+	if err != nil {
+		t.Error(err)
+	}
+
+	for name, v := range map[string]interface{}{
+		"EAStore":         edison.EAStore{},
+		"EHSCluster":      edison.EHSCluster{},
+		"AW":              edison.AW{},
+		"AV":              edison.AV{},
+		"AuditEvent":      edison.AuditEvent{},
+		"AuditChange":     edison.AuditChange{},
+		"Revision":        edison.Revision{},
+		"Webhook":         edison.Webhook{},
+		"Delivery":        edison.Delivery{},
+		"QuotaUsage":      edison.QuotaUsage{},
+		"RequestError":    edison.RequestError{},
+		"Capabilities":    edison.Capabilities{},
+		"Version":         edison.Version{},
+		"Operation":       edison.Operation{},
+		"Upgrade":         edison.Upgrade{},
+		"UpgradeNode":     edison.UpgradeNode{},
+		"Migration":       edison.Migration{},
+		"MigrationStep":   edison.MigrationStep{},
+		"Capacity":        edison.Capacity{},
+		"Release":         edison.Release{},
+		"Region":          edison.Region{},
+		"Profile":         edison.Profile{},
+		"InstanceSize":    edison.InstanceSize{},
+		"NodePool":        edison.NodePool{},
+		"Taint":           edison.Taint{},
+		"Autoscaling":     edison.Autoscaling{},
+		"AWSchedule":      edison.AWSchedule{},
+		"ScheduleRule":    edison.ScheduleRule{},
+		"ScheduledAction": edison.ScheduledAction{},
+	} {
+		name, v := name, v
+		t.Run(name, func(t *testing.T) {
+			err := api.CheckOpenAPISchema(doc, name, v)
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
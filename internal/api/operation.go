@@ -0,0 +1,613 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"darlinggo.co/api"
+	"darlinggo.co/trout/v2"
+	"github.com/hashicorp/go-uuid"
+)
+
+const (
+	OperationRunning   = "running"
+	OperationSucceeded = "succeeded"
+	OperationFailed    = "failed"
+	OperationCancelled = "cancelled"
+)
+
+// operationProgressPeriod is how often a running operation's progress is
+// updated.
+const operationProgressPeriod = time.Second
+
+var (
+	ErrOperationNotFound   = errors.New("operation not found")
+	ErrOperationNotRunning = errors.New("operation not running")
+)
+
+// Operation tracks a mutation edisond is making in the background. Kind is
+// the resource type and what's being done to it, like "ehscluster.create".
+// ResourceID is only known for creates once the operation has succeeded.
+type Operation struct {
+	ID           string             `json:"id"`
+	Kind         string             `json:"kind"`
+	ResourceType string             `json:"resource_type"`
+	ResourceID   string             `json:"resource_id,omitempty"`
+	Status       string             `json:"status"`
+	Progress     int                `json:"progress"`
+	StartedAt    string             `json:"started_at"`
+	FinishedAt   string             `json:"finished_at,omitempty"`
+	Errors       []api.RequestError `json:"errors,omitempty"`
+	Quotas       []QuotaUsage       `json:"quotas,omitempty"`
+
+	index uint64
+}
+
+// OperationsConfig sets how long simulated operations take. Kinds overrides
+// Duration for specific kinds of operation, like "ehscluster.create".
+// Finished operations are pruned once they're older than Retention, unless
+// it's 0.
+type OperationsConfig struct {
+	Duration  time.Duration            `json:"duration" yaml:"duration"`
+	Kinds     map[string]time.Duration `json:"kinds,omitempty" yaml:"kinds,omitempty"`
+	Retention time.Duration            `json:"retention" yaml:"retention"`
+}
+
+func (c OperationsConfig) duration(kind string) time.Duration {
+	if d, ok := c.Kinds[kind]; ok {
+		return d
+	}
+	return c.Duration
+}
+
+// Operations runs mutations in the background for clients that send
+// "Prefer: respond-async", responding straight away with an Operation they
+// can poll or cancel.
+type Operations struct {
+	storer  *Storer
+	metrics *Metrics
+
+	mu       sync.Mutex
+	config   OperationsConfig
+	cancels  map[string]context.CancelFunc
+	prepared map[string]preparedOperation
+}
+
+// preparedOperation is an operation that's been recorded by Prepare but
+// hasn't begun running.
+type preparedOperation struct {
+	ctx       context.Context
+	op        Operation
+	duration  time.Duration
+	run       func(ctx context.Context) (int, Response)
+	cancelled func()
+}
+
+func NewOperations(storer *Storer, metrics *Metrics, config OperationsConfig) *Operations {
+	return &Operations{
+		storer:   storer,
+		metrics:  metrics,
+		config:   config,
+		cancels:  map[string]context.CancelFunc{},
+		prepared: map[string]preparedOperation{},
+	}
+}
+
+// SetConfig replaces the durations used for operations started from now on.
+func (o *Operations) SetConfig(config OperationsConfig) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.config = config
+}
+
+// asyncRoutes maps the routes that can run as operations to the resource
+// type they change and what they do to it.
+var asyncRoutes = []struct {
+	method, pattern, resourceType, verb string
+}{
+	{http.MethodPost, "/eastores", "eastore", "create"},
+	{http.MethodPut, "/eastores/{id}", "eastore", "update"},
+	{http.MethodDelete, "/eastores/{id}", "eastore", "delete"},
+	{http.MethodPost, "/ehsclusters", "ehscluster", "create"},
+	{http.MethodPut, "/ehsclusters/{id}", "ehscluster", "update"},
+	{http.MethodDelete, "/ehsclusters/{id}", "ehscluster", "delete"},
+	{http.MethodPost, "/aws", "aw", "create"},
+	{http.MethodPut, "/aws/{id}", "aw", "update"},
+	{http.MethodDelete, "/aws/{id}", "aw", "delete"},
+	{http.MethodPost, "/avs", "av", "create"},
+	{http.MethodPut, "/avs/{id}", "av", "update"},
+	{http.MethodDelete, "/avs/{id}", "av", "delete"},
+}
+
+func prefersAsync(r *http.Request) bool {
+	for _, prefer := range r.Header.Values("Prefer") {
+		for _, pref := range strings.Split(prefer, ",") {
+			if strings.EqualFold(strings.TrimSpace(pref), "respond-async") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Middleware starts an Operation for requests that prefer to be handled
+// asynchronously, if their route can be, and responds with 202 Accepted.
+// h handles other requests. Asynchronous requests are replayed through
+// replay once the operation's simulated duration is up, so replay should be
+// h wrapped in anything that applies to the change itself, like Chaos.
+func (o *Operations) Middleware(baseURL string, h, replay http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !prefersAsync(r) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		p := strings.TrimPrefix(r.URL.Path, baseURL)
+		var op Operation
+		for _, route := range asyncRoutes {
+			if r.Method == route.method && matchRoute(route.pattern, p) {
+				op.Kind = route.resourceType + "." + route.verb
+				op.ResourceType = route.resourceType
+				if strings.HasSuffix(route.pattern, "{id}") {
+					op.ResourceID = path.Base(p)
+				}
+				break
+			}
+		}
+		if op.Kind == "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+			return
+		}
+		op, err = o.Start(r.Context(), op, func(ctx context.Context) (status int, resp Response) {
+			rec := &operationRecorder{header: http.Header{}, status: http.StatusOK}
+			req := r.Clone(ctx)
+			req.Body = ioutil.NopCloser(bytes.NewReader(body))
+			req.Header.Del("Prefer")
+			// There's no connection to abort, like Chaos does when it
+			// drops one, so fail the operation instead.
+			defer func() {
+				if err := recover(); err != nil {
+					if err != http.ErrAbortHandler {
+						panic(err)
+					}
+					status, resp = http.StatusInternalServerError, Response{Errors: api.ActOfGodError}
+				}
+			}()
+			replay.ServeHTTP(rec, req)
+			if err := json.Unmarshal(rec.body.Bytes(), &resp); err != nil && rec.status < 400 {
+				return http.StatusInternalServerError, Response{Errors: api.ActOfGodError}
+			}
+			return rec.status, resp
+		}, nil)
+		if err != nil {
+			api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+			return
+		}
+		w.Header().Set("Location", path.Join("/", baseURL, versionPath(CurrentVersion), "operations", op.ID))
+		w.Header().Set("Preference-Applied", "respond-async")
+		api.Encode(w, r, http.StatusAccepted, Response{Operations: []Operation{op}})
+	})
+}
+
+// Start records op as running and, once its kind's duration has passed,
+// calls run to make the change, unless it's been cancelled first, in which
+// case cancelled is called instead, if it's set. run's
+// response determines whether the operation succeeded. run's context has
+// the values of ctx, but isn't cancelled when ctx is.
+func (o *Operations) Start(ctx context.Context, op Operation, run func(ctx context.Context) (int, Response), cancelled func()) (Operation, error) {
+	op, err := o.Prepare(ctx, op, run, cancelled)
+	if err != nil {
+		return Operation{}, err
+	}
+	o.Begin(op.ID)
+	return op, nil
+}
+
+// Prepare records op as running, like Start, but doesn't start its clock
+// until Begin is called. Handlers that store a resource pointing at the
+// operation prepare it, then begin it once the resource has been stored,
+// or abandon it if it couldn't be.
+func (o *Operations) Prepare(ctx context.Context, op Operation, run func(ctx context.Context) (int, Response), cancelled func()) (Operation, error) {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return Operation{}, err
+	}
+	op.ID = id
+	op.Status = OperationRunning
+	op.StartedAt = time.Now().UTC().Format(time.RFC3339)
+	err = o.storer.PutOperation(op)
+	if err != nil {
+		return Operation{}, err
+	}
+	ctx, cancel := context.WithCancel(detachedContext{ctx})
+	o.mu.Lock()
+	o.cancels[op.ID] = cancel
+	o.prepared[op.ID] = preparedOperation{
+		ctx:       ctx,
+		op:        op,
+		duration:  o.config.duration(op.Kind),
+		run:       run,
+		cancelled: cancelled,
+	}
+	o.mu.Unlock()
+	return op, nil
+}
+
+// Begin runs an operation recorded by Prepare.
+func (o *Operations) Begin(id string) {
+	o.mu.Lock()
+	prepared, ok := o.prepared[id]
+	delete(o.prepared, id)
+	o.mu.Unlock()
+	if !ok {
+		return
+	}
+	if o.metrics != nil {
+		o.metrics.OperationStarted(prepared.op.Kind)
+	}
+	go o.run(prepared.ctx, prepared.op, prepared.duration, prepared.run, prepared.cancelled)
+}
+
+// Abandon fails an operation recorded by Prepare whose resource couldn't
+// be stored.
+func (o *Operations) Abandon(id string) {
+	o.mu.Lock()
+	prepared, ok := o.prepared[id]
+	delete(o.prepared, id)
+	o.mu.Unlock()
+	if !ok {
+		return
+	}
+	o.finish(prepared.op, OperationFailed, nil)
+}
+
+// Track records op as running for a change something else is making, like
+// an upgrade, so clients can wait for it like any other operation. The
+// change reports its progress with Progress and its outcome with Finish.
+// Tracked operations can't be cancelled.
+func (o *Operations) Track(op Operation) (Operation, error) {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return Operation{}, err
+	}
+	op.ID = id
+	op.Status = OperationRunning
+	op.StartedAt = time.Now().UTC().Format(time.RFC3339)
+	err = o.storer.PutOperation(op)
+	if err != nil {
+		return Operation{}, err
+	}
+	if o.metrics != nil {
+		o.metrics.OperationStarted(op.Kind)
+	}
+	return op, nil
+}
+
+// Progress updates how far along a tracked operation is, out of 100.
+func (o *Operations) Progress(id string, progress int) {
+	op, err := o.storer.GetOperation(id)
+	if err != nil || op.Status != OperationRunning || op.Progress == progress {
+		return
+	}
+	op.Progress = progress
+	o.storer.PutOperation(op) //nolint:errcheck
+}
+
+// Finish records the outcome of a tracked operation, which should be
+// OperationSucceeded or OperationFailed.
+func (o *Operations) Finish(id, status string) {
+	op, err := o.storer.GetOperation(id)
+	if err != nil || op.Status != OperationRunning {
+		return
+	}
+	if o.metrics != nil {
+		o.metrics.OperationFinished(op.Kind)
+	}
+	var resp *Response
+	if status == OperationSucceeded {
+		resp = &Response{}
+	}
+	o.finish(op, status, resp)
+}
+
+func (o *Operations) run(ctx context.Context, op Operation, duration time.Duration, run func(ctx context.Context) (int, Response), cancelled func()) {
+	if o.metrics != nil {
+		defer o.metrics.OperationFinished(op.Kind)
+	}
+	deadline := time.Now().Add(duration)
+	ticker := time.NewTicker(operationProgressPeriod)
+	defer ticker.Stop()
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			o.cancelled(op, cancelled)
+			return
+		case <-time.After(time.Until(deadline)):
+		case <-ticker.C:
+			// Progress moves in steps of 10, so long operations don't
+			// change every tick.
+			progress := int(9*(1-time.Until(deadline).Seconds()/duration.Seconds())) * 10
+			if progress == op.Progress {
+				continue
+			}
+			op.Progress = progress
+			o.storer.PutOperation(op) //nolint:errcheck
+		}
+	}
+	// Past this point the change is being made, so it can't be cancelled.
+	// Cancel removes the operation from cancels under the same lock, so if
+	// it's gone the operation was cancelled, even if ctx isn't done yet.
+	o.mu.Lock()
+	_, running := o.cancels[op.ID]
+	delete(o.cancels, op.ID)
+	o.mu.Unlock()
+	if !running || ctx.Err() != nil {
+		o.cancelled(op, cancelled)
+		return
+	}
+	status, resp := run(ctx)
+	if status >= 400 {
+		op.Errors = resp.Errors
+		op.Quotas = resp.Quotas
+		o.finish(op, OperationFailed, nil)
+		return
+	}
+	o.finish(op, OperationSucceeded, &resp)
+}
+
+func (o *Operations) cancelled(op Operation, cancelled func()) {
+	if cancelled != nil {
+		cancelled()
+	}
+	o.finish(op, OperationCancelled, nil)
+}
+
+func (o *Operations) finish(op Operation, status string, resp *Response) {
+	o.mu.Lock()
+	delete(o.cancels, op.ID)
+	o.mu.Unlock()
+	op.Status = status
+	op.FinishedAt = time.Now().UTC().Format(time.RFC3339)
+	if resp != nil {
+		op.Progress = 100
+		if id := resp.resourceID(); id != "" {
+			op.ResourceID = id
+		}
+	}
+	o.storer.PutOperation(op) //nolint:errcheck
+	o.prune()
+}
+
+// prune deletes finished operations older than the retention.
+func (o *Operations) prune() {
+	o.mu.Lock()
+	retention := o.config.Retention
+	o.mu.Unlock()
+	if retention <= 0 {
+		return
+	}
+	o.storer.PruneOperations(time.Now().Add(-retention)) //nolint:errcheck
+}
+
+// Cancel stops a running operation before it makes its change. Operations
+// that are already making their change can't be cancelled.
+func (o *Operations) Cancel(id string) (Operation, error) {
+	op, err := o.storer.GetOperation(id)
+	if err != nil {
+		return Operation{}, err
+	}
+	o.mu.Lock()
+	cancel, ok := o.cancels[id]
+	delete(o.cancels, id)
+	if ok {
+		cancel()
+	}
+	o.mu.Unlock()
+	if !ok {
+		return op, ErrOperationNotRunning
+	}
+	op.Status = OperationCancelled
+	return op, nil
+}
+
+// detachedContext keeps a request context's values, like the principal,
+// but not its deadline or cancellation, so work can carry on after the
+// response has been sent.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+// resourceID returns the ID of the resource in the response, if there's
+// exactly one.
+func (r Response) resourceID() string {
+	switch {
+	case len(r.EAStores) == 1:
+		return r.EAStores[0].ID
+	case len(r.EHSClusters) == 1:
+		return r.EHSClusters[0].ID
+	case len(r.AWs) == 1:
+		return r.AWs[0].ID
+	case len(r.AVs) == 1:
+		return r.AVs[0].ID
+	}
+	return ""
+}
+
+// operationRecorder captures the response to a request made for an
+// operation, so its outcome can be recorded.
+type operationRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *operationRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *operationRecorder) WriteHeader(status int) {
+	r.status = status
+}
+
+func (r *operationRecorder) Write(b []byte) (int, error) {
+	return r.body.Write(b)
+}
+
+func (s *Storer) GetOperation(id string) (Operation, error) {
+	txn := s.db.Txn(false)
+	op, err := txn.First("operation", "id", id)
+	if err != nil {
+		return Operation{}, err
+	}
+	if op == nil {
+		return Operation{}, ErrOperationNotFound
+	}
+	return *op.(*Operation), nil
+}
+
+func (s *Storer) ListOperations() ([]Operation, error) {
+	txn := s.db.Txn(false)
+	iter, err := txn.Get("operation", "id")
+	if err != nil {
+		return nil, err
+	}
+	var results []Operation
+	for obj := iter.Next(); obj != nil; obj = iter.Next() {
+		results = append(results, *obj.(*Operation))
+	}
+	return results, nil
+}
+
+// PutOperation creates or replaces op, marking it as changed at a new
+// index.
+func (s *Storer) PutOperation(op Operation) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	op.index = s.nextIndex(txn)
+	err := txn.Insert("operation", &op)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// PruneOperations deletes operations that finished before before, returning
+// how many it deleted.
+func (s *Storer) PruneOperations(before time.Time) (int, error) {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	iter, err := txn.Get("operation", "id")
+	if err != nil {
+		return 0, err
+	}
+	var pruned []*Operation
+	for obj := iter.Next(); obj != nil; obj = iter.Next() {
+		op := obj.(*Operation)
+		if op.FinishedAt == "" {
+			continue
+		}
+		finishedAt, err := time.Parse(time.RFC3339, op.FinishedAt)
+		if err != nil || !finishedAt.Before(before) {
+			continue
+		}
+		pruned = append(pruned, op)
+	}
+	for _, op := range pruned {
+		err = txn.Delete("operation", op)
+		if err != nil {
+			return 0, err
+		}
+	}
+	txn.Commit()
+	return len(pruned), nil
+}
+
+// WaitForOperation blocks until the operation has changed since index, the
+// timeout elapses, or ctx is done.
+func (s *Storer) WaitForOperation(ctx context.Context, id string, index uint64, timeout time.Duration) error {
+	return s.waitForRow(ctx, "operation", id, index, timeout, func(obj interface{}) uint64 {
+		return obj.(*Operation).index
+	})
+}
+
+func (a API) handleListOperations(w http.ResponseWriter, r *http.Request) {
+	ops, err := a.Storer.ListOperations()
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	q := r.URL.Query()
+	results := []Operation{}
+	for _, op := range ops {
+		if q.Get("resource_type") != "" && op.ResourceType != q.Get("resource_type") {
+			continue
+		}
+		if q.Get("resource_id") != "" && op.ResourceID != q.Get("resource_id") {
+			continue
+		}
+		if q.Get("status") != "" && op.Status != q.Get("status") {
+			continue
+		}
+		results = append(results, op)
+	}
+	api.Encode(w, r, http.StatusOK, Response{Operations: results})
+}
+
+func (a API) handleGetOperation(w http.ResponseWriter, r *http.Request) {
+	id := trout.RequestVars(r).Get("id")
+	ok := a.block(w, r, func(ctx context.Context, index uint64, wait time.Duration) error {
+		return a.Storer.WaitForOperation(ctx, id, index, wait)
+	})
+	if !ok {
+		return
+	}
+	op, err := a.Storer.GetOperation(id)
+	if err != nil {
+		if err == ErrOperationNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{Operations: []Operation{op}})
+}
+
+func (a API) handleCancelOperation(w http.ResponseWriter, r *http.Request) {
+	id, action := splitAction(trout.RequestVars(r).Get("id"))
+	if action != "cancel" {
+		api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+		return
+	}
+	op, err := a.Operations.Cancel(id)
+	if err != nil {
+		if err == ErrOperationNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrOperationNotRunning {
+			api.Encode(w, r, http.StatusConflict, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrConflict}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{Operations: []Operation{op}})
+}
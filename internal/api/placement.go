@@ -0,0 +1,245 @@
+package api
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+
+	"darlinggo.co/api"
+	"github.com/hashicorp/go-memdb"
+)
+
+const (
+	// PlacementBestFit places AWs on the cluster with the least room left
+	// that still fits them, packing clusters as full as they'll go.
+	PlacementBestFit = "best_fit"
+	// PlacementFirstFit places AWs on the oldest cluster that fits them.
+	PlacementFirstFit = "first_fit"
+	// PlacementWorstFit places AWs on the cluster with the most room left,
+	// spreading them across clusters.
+	PlacementWorstFit = "worst_fit"
+)
+
+var ErrNoRoom = errors.New("no EHS cluster has room")
+
+// PlacementStrategies are the strategies AWs can be placed with.
+var PlacementStrategies = []string{PlacementBestFit, PlacementFirstFit, PlacementWorstFit}
+
+// PlacementConfig sets how AWs created without an EHS cluster are placed on
+// one.
+type PlacementConfig struct {
+	Strategy string `json:"strategy" yaml:"strategy"`
+}
+
+// Placement places AWs created without an EHS cluster on a ready cluster
+// in their region and account with room for their concurrent users.
+type Placement struct {
+	mu     sync.Mutex
+	config PlacementConfig
+}
+
+func NewPlacement(config PlacementConfig) *Placement {
+	return &Placement{config: config}
+}
+
+// SetConfig replaces the strategy used for the next AW placed.
+func (p *Placement) SetConfig(config PlacementConfig) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.config = config
+}
+
+func (p *Placement) strategy() string {
+	if p == nil {
+		return PlacementBestFit
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.config.Strategy == "" {
+		return PlacementBestFit
+	}
+	return p.config.Strategy
+}
+
+// clusterSeats returns the AW seats hosted on the cluster in txn, including
+// AWs being migrated onto it but not the AW with the ID except, and how many
+// seats it has room for, with its node pools and as many of its profile's
+// nodes as it can scale out to. Clusters with a profile that isn't in the
+// catalog aren't limited, and have room for math.MaxInt32 seats.
+func clusterSeats(txn *memdb.Txn, cluster EHSCluster, except string) (hosted, room int, err error) {
+	room = math.MaxInt32
+	if profile, ok := findProfile(cluster.Profile); ok {
+		iter, err := txn.Get("nodepool", "ehscluster", cluster.ID)
+		if err != nil {
+			return 0, 0, err
+		}
+		var pools Capacity
+		for obj := iter.Next(); obj != nil; obj = iter.Next() {
+			pools.add(obj.(*NodePool).capacity())
+		}
+		room = profile.scaled(cluster.maxNodes(profile)).MaxAWSeats + pools.MaxAWSeats
+	}
+	iter, err := txn.Get("aw", "id")
+	if err != nil {
+		return 0, 0, err
+	}
+	for obj := iter.Next(); obj != nil; obj = iter.Next() {
+		aw := obj.(*AW)
+		if aw.ID == except || aw.DeletedAt != "" {
+			continue
+		}
+		if aw.EHSClusterID == cluster.ID || aw.TargetEHSClusterID == cluster.ID {
+			hosted += aw.ConcurrentUsers
+		}
+	}
+	return hosted, room, nil
+}
+
+// freeSeats returns how many more AW seats the cluster has room for, not
+// counting the AW with the ID except.
+func (s *Storer) freeSeats(cluster EHSCluster, except string) (int, error) {
+	hosted, room, err := clusterSeats(s.db.Txn(false), cluster, except)
+	if err != nil {
+		return 0, err
+	}
+	return room - hosted, nil
+}
+
+// roomGuard snapshots the AW seats hosted on the EHS clusters with the IDs
+// in txn, and returns a function that, once a write has been made in txn,
+// returns ErrNoRoom if the write took any of them over the seats they have
+// room for. Checking in the same txn as the write means concurrent writes
+// can't both take a cluster's last seats.
+func roomGuard(txn *memdb.Txn, clusterIDs ...string) (func() error, error) {
+	var clusters []EHSCluster
+	before := map[string]int{}
+	for _, id := range clusterIDs {
+		if id == "" {
+			continue
+		}
+		obj, err := txn.First("ehscluster", "id", id)
+		if err != nil {
+			return nil, err
+		}
+		if obj == nil {
+			continue
+		}
+		cluster := *obj.(*EHSCluster)
+		hosted, _, err := clusterSeats(txn, cluster, "")
+		if err != nil {
+			return nil, err
+		}
+		clusters = append(clusters, cluster)
+		before[cluster.ID] = hosted
+	}
+	return func() error {
+		for _, cluster := range clusters {
+			hosted, room, err := clusterSeats(txn, cluster, "")
+			if err != nil {
+				return err
+			}
+			if hosted > room && hosted > before[cluster.ID] {
+				return ErrNoRoom
+			}
+		}
+		return nil
+	}, nil
+}
+
+// placeAW picks the ready cluster to host aw, by the configured strategy,
+// from those in its region, if it has one, and its account. Clusters that
+// aren't tied to an account can host any AW. It returns ErrNoRoom if none
+// of them have room.
+func (a API) placeAW(aw AW) (EHSCluster, error) {
+	clusters, err := a.Storer.ListEHSClusters(nil)
+	if err != nil {
+		return EHSCluster{}, err
+	}
+	type candidate struct {
+		cluster EHSCluster
+		free    int
+	}
+	var candidates []candidate
+	for _, cluster := range clusters {
+		if cluster.DeletedAt != "" || cluster.Status != EHSClusterReady {
+			continue
+		}
+		if aw.Region != "" && cluster.Region != aw.Region {
+			continue
+		}
+		if cluster.AccountID != "" && cluster.AccountID != aw.EAAccountID {
+			continue
+		}
+		free, err := a.Storer.freeSeats(cluster, aw.ID)
+		if err != nil {
+			return EHSCluster{}, err
+		}
+		if free < aw.ConcurrentUsers {
+			continue
+		}
+		candidates = append(candidates, candidate{cluster: cluster, free: free})
+	}
+	if len(candidates) < 1 {
+		return EHSCluster{}, ErrNoRoom
+	}
+	strategy := a.Placement.strategy()
+	sort.SliceStable(candidates, func(i, j int) bool {
+		ci, cj := candidates[i], candidates[j]
+		switch {
+		case strategy == PlacementBestFit && ci.free != cj.free:
+			return ci.free < cj.free
+		case strategy == PlacementWorstFit && ci.free != cj.free:
+			return ci.free > cj.free
+		case ci.cluster.CreatedAt != cj.cluster.CreatedAt:
+			return ci.cluster.CreatedAt < cj.cluster.CreatedAt
+		}
+		return ci.cluster.ID < cj.cluster.ID
+	})
+	return candidates[0].cluster, nil
+}
+
+// assignEHSCluster makes sure aw is on an EHS cluster with room for it,
+// placing it on one if it doesn't name one. If it can't, it writes an error
+// response and returns false.
+func (a API) assignEHSCluster(w http.ResponseWriter, r *http.Request, aw AW) (AW, bool) {
+	if aw.EHSClusterID == "" {
+		cluster, err := a.placeAW(aw)
+		if err == ErrNoRoom {
+			api.Encode(w, r, http.StatusConflict, Response{Errors: []api.RequestError{{Field: "/ehs_cluster_id", Slug: api.RequestErrInsufficient}}})
+			return AW{}, false
+		}
+		if err != nil {
+			api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+			return AW{}, false
+		}
+		aw.EHSClusterID = cluster.ID
+		aw.Region = cluster.Region
+		return aw, true
+	}
+	cluster, err := a.Storer.GetEHSCluster(aw.EHSClusterID)
+	if err == ErrEHSClusterNotFound {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/ehs_cluster_id", Slug: api.RequestErrInvalidValue}}})
+		return AW{}, false
+	}
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return AW{}, false
+	}
+	if aw.Region != "" && aw.Region != cluster.Region {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/region", Slug: api.RequestErrInvalidValue}}})
+		return AW{}, false
+	}
+	aw.Region = cluster.Region
+	free, err := a.Storer.freeSeats(cluster, aw.ID)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return AW{}, false
+	}
+	if free < aw.ConcurrentUsers {
+		api.Encode(w, r, http.StatusConflict, Response{Errors: []api.RequestError{{Field: "/concurrent_users", Slug: api.RequestErrInsufficient}}})
+		return AW{}, false
+	}
+	return aw, true
+}
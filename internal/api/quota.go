@@ -0,0 +1,336 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"darlinggo.co/api"
+	"github.com/hashicorp/go-memdb"
+)
+
+const (
+	QuotaEHSClustersPerRegion = "ehs_clusters_per_region"
+	QuotaPartitionSpaceTB     = "partition_space_tb"
+	QuotaConcurrentUsers      = "concurrent_users"
+	QuotaAVTenants            = "av_tenants"
+)
+
+// Quota limits what a single account can have at once. A limit of 0 means
+// unlimited. Soft deleted resources don't count, and resources without an
+// account aren't limited.
+type Quota struct {
+	EHSClustersPerRegion int64 `json:"ehs_clusters_per_region,omitempty" yaml:"ehs_clusters_per_region,omitempty"`
+	PartitionSpaceTB     int64 `json:"partition_space_tb,omitempty" yaml:"partition_space_tb,omitempty"`
+	ConcurrentUsers      int64 `json:"concurrent_users,omitempty" yaml:"concurrent_users,omitempty"`
+	AVTenants            int64 `json:"av_tenants,omitempty" yaml:"av_tenants,omitempty"`
+}
+
+// QuotaConfig applies Default to every account, unless the account has its
+// own Quota in Accounts.
+type QuotaConfig struct {
+	Default  Quota            `json:"default" yaml:"default"`
+	Accounts map[string]Quota `json:"accounts,omitempty" yaml:"accounts,omitempty"`
+}
+
+func (c QuotaConfig) forAccount(account string) Quota {
+	if q, ok := c.Accounts[account]; ok {
+		return q
+	}
+	return c.Default
+}
+
+// QuotaUsage is how much of one quota an account is using. Region is only
+// set for per-region quotas.
+type QuotaUsage struct {
+	AccountID string `json:"account_id"`
+	Name      string `json:"name"`
+	Region    string `json:"region,omitempty"`
+	Limit     int64  `json:"limit"`
+	Used      int64  `json:"used"`
+}
+
+// QuotaExceededError is returned by the Storer when a write would take an
+// account over one of its quotas.
+type QuotaExceededError struct {
+	Usage QuotaUsage
+}
+
+func (e QuotaExceededError) Error() string {
+	return fmt.Sprintf("account %q would exceed quota %s: %d of %d", e.Usage.AccountID, e.Usage.Name, e.Usage.Used, e.Usage.Limit)
+}
+
+// field is the request field responsible for the quota being exceeded.
+func (e QuotaExceededError) field() string {
+	switch e.Usage.Name {
+	case QuotaEHSClustersPerRegion:
+		return "/region"
+	case QuotaPartitionSpaceTB:
+		return "/partition_space_tb"
+	case QuotaConcurrentUsers:
+		return "/concurrent_users"
+	case QuotaAVTenants:
+		return "/tenant_id"
+	}
+	return "/"
+}
+
+// quotaExceeded writes a response describing err, if it's a
+// QuotaExceededError, and reports whether it did.
+func quotaExceeded(w http.ResponseWriter, r *http.Request, err error) bool {
+	var quotaErr QuotaExceededError
+	if !errors.As(err, &quotaErr) {
+		return false
+	}
+	api.Encode(w, r, http.StatusForbidden, Response{
+		Errors: []api.RequestError{{Field: quotaErr.field(), Slug: api.RequestErrOverflow}},
+		Quotas: []QuotaUsage{quotaErr.Usage},
+	})
+	return true
+}
+
+type accountUsage struct {
+	clustersByRegion map[string]int64
+	partitionSpaceTB int64
+	concurrentUsers  int64
+	avTenants        int64
+}
+
+func (u *accountUsage) report(account string, quota Quota) []QuotaUsage {
+	var results []QuotaUsage
+	if u == nil {
+		u = &accountUsage{}
+	}
+	if len(u.clustersByRegion) < 1 {
+		results = append(results, QuotaUsage{Name: QuotaEHSClustersPerRegion})
+	}
+	for region, used := range u.clustersByRegion {
+		results = append(results, QuotaUsage{Name: QuotaEHSClustersPerRegion, Region: region, Used: used})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Region < results[j].Region
+	})
+	results = append(results,
+		QuotaUsage{Name: QuotaPartitionSpaceTB, Used: u.partitionSpaceTB},
+		QuotaUsage{Name: QuotaConcurrentUsers, Used: u.concurrentUsers},
+		QuotaUsage{Name: QuotaAVTenants, Used: u.avTenants},
+	)
+	for i := range results {
+		results[i].AccountID = account
+		switch results[i].Name {
+		case QuotaEHSClustersPerRegion:
+			results[i].Limit = quota.EHSClustersPerRegion
+		case QuotaPartitionSpaceTB:
+			results[i].Limit = quota.PartitionSpaceTB
+		case QuotaConcurrentUsers:
+			results[i].Limit = quota.ConcurrentUsers
+		case QuotaAVTenants:
+			results[i].Limit = quota.AVTenants
+		}
+	}
+	return results
+}
+
+// usage totals what every account is using, as seen by txn. AWs count
+// against the account of the EHSCluster they run on.
+func usage(txn *memdb.Txn) (map[string]*accountUsage, error) {
+	results := map[string]*accountUsage{}
+	get := func(account string) *accountUsage {
+		u, ok := results[account]
+		if !ok {
+			u = &accountUsage{clustersByRegion: map[string]int64{}}
+			results[account] = u
+		}
+		return u
+	}
+	clusterAccounts := map[string]string{}
+	iter, err := txn.Get("ehscluster", "id")
+	if err != nil {
+		return nil, err
+	}
+	for obj := iter.Next(); obj != nil; obj = iter.Next() {
+		cluster := obj.(*EHSCluster)
+		clusterAccounts[cluster.ID] = cluster.AccountID
+		if cluster.DeletedAt == "" {
+			get(cluster.AccountID).clustersByRegion[cluster.Region]++
+		}
+	}
+	iter, err = txn.Get("eastore", "id")
+	if err != nil {
+		return nil, err
+	}
+	for obj := iter.Next(); obj != nil; obj = iter.Next() {
+		store := obj.(*EAStore)
+		if store.DeletedAt == "" {
+			get(store.AccountID).partitionSpaceTB += store.PartitionSpaceTB
+		}
+	}
+	iter, err = txn.Get("aw", "id")
+	if err != nil {
+		return nil, err
+	}
+	for obj := iter.Next(); obj != nil; obj = iter.Next() {
+		aw := obj.(*AW)
+		if aw.DeletedAt == "" {
+			get(clusterAccounts[aw.EHSClusterID]).concurrentUsers += int64(aw.ConcurrentUsers)
+		}
+	}
+	iter, err = txn.Get("av", "id")
+	if err != nil {
+		return nil, err
+	}
+	for obj := iter.Next(); obj != nil; obj = iter.Next() {
+		av := obj.(*AV)
+		if av.DeletedAt == "" {
+			get(av.AccountID).avTenants++
+		}
+	}
+	return results, nil
+}
+
+// usageForAccount totals what the account is using, as seen by txn, using
+// the account indexes so only its resources are read.
+func usageForAccount(txn *memdb.Txn, account string) (*accountUsage, error) {
+	u := &accountUsage{clustersByRegion: map[string]int64{}}
+	var clusterIDs []string
+	iter, err := txn.Get("ehscluster", "account", account)
+	if err != nil {
+		return nil, err
+	}
+	for obj := iter.Next(); obj != nil; obj = iter.Next() {
+		cluster := obj.(*EHSCluster)
+		clusterIDs = append(clusterIDs, cluster.ID)
+		if cluster.DeletedAt == "" {
+			u.clustersByRegion[cluster.Region]++
+		}
+	}
+	iter, err = txn.Get("eastore", "account", account)
+	if err != nil {
+		return nil, err
+	}
+	for obj := iter.Next(); obj != nil; obj = iter.Next() {
+		store := obj.(*EAStore)
+		if store.DeletedAt == "" {
+			u.partitionSpaceTB += store.PartitionSpaceTB
+		}
+	}
+	for _, id := range clusterIDs {
+		iter, err = txn.Get("aw", "ehscluster", id)
+		if err != nil {
+			return nil, err
+		}
+		for obj := iter.Next(); obj != nil; obj = iter.Next() {
+			aw := obj.(*AW)
+			if aw.DeletedAt == "" {
+				u.concurrentUsers += int64(aw.ConcurrentUsers)
+			}
+		}
+	}
+	iter, err = txn.Get("av", "account", account)
+	if err != nil {
+		return nil, err
+	}
+	for obj := iter.Next(); obj != nil; obj = iter.Next() {
+		av := obj.(*AV)
+		if av.DeletedAt == "" {
+			u.avTenants++
+		}
+	}
+	return u, nil
+}
+
+func (s *Storer) quotaConfig() QuotaConfig {
+	config, _ := s.quotas.Load().(QuotaConfig)
+	return config
+}
+
+// SetQuotas replaces the quotas enforced on writes. Accounts already over a
+// lowered quota keep what they have, but can't grow.
+func (s *Storer) SetQuotas(config QuotaConfig) {
+	s.quotas.Store(config)
+}
+
+// ListQuotaUsage returns the usage of every quota for the given accounts
+// or, if none are given, for every account that has resources or a quota of
+// its own.
+func (s *Storer) ListQuotaUsage(accounts ...string) ([]QuotaUsage, error) {
+	txn := s.db.Txn(false)
+	usages, err := usage(txn)
+	if err != nil {
+		return nil, err
+	}
+	config := s.quotaConfig()
+	if len(accounts) < 1 {
+		for account := range usages {
+			if account == "" {
+				continue
+			}
+			accounts = append(accounts, account)
+		}
+		for account := range config.Accounts {
+			if _, ok := usages[account]; !ok {
+				accounts = append(accounts, account)
+			}
+		}
+		sort.Strings(accounts)
+	}
+	var results []QuotaUsage
+	for _, account := range accounts {
+		results = append(results, usages[account].report(account, config.forAccount(account))...)
+	}
+	return results, nil
+}
+
+// quotaGuard snapshots the account's usage in txn, and returns a function
+// that, once a write has been made in txn, returns a QuotaExceededError if
+// the write took any of the account's usage over its limit. Writes without
+// an account aren't checked.
+func (s *Storer) quotaGuard(txn *memdb.Txn, account string) (func() error, error) {
+	if account == "" {
+		return func() error { return nil }, nil
+	}
+	quota := s.quotaConfig().forAccount(account)
+	usage, err := usageForAccount(txn, account)
+	if err != nil {
+		return nil, err
+	}
+	before := map[string]int64{}
+	for _, u := range usage.report(account, quota) {
+		before[u.Name+"/"+u.Region] = u.Used
+	}
+	return func() error {
+		usage, err := usageForAccount(txn, account)
+		if err != nil {
+			return err
+		}
+		for _, u := range usage.report(account, quota) {
+			if u.Limit > 0 && u.Used > u.Limit && u.Used > before[u.Name+"/"+u.Region] {
+				return QuotaExceededError{Usage: u}
+			}
+		}
+		return nil
+	}, nil
+}
+
+// awAccount returns the account an AW running on the given EHSCluster
+// counts against.
+func awAccount(txn *memdb.Txn, clusterID string) (string, error) {
+	cluster, err := txn.First("ehscluster", "id", clusterID)
+	if err != nil || cluster == nil {
+		return "", err
+	}
+	return cluster.(*EHSCluster).AccountID, nil
+}
+
+func (a API) handleListQuotas(w http.ResponseWriter, r *http.Request) {
+	usages, err := a.Storer.ListQuotaUsage(r.URL.Query()["account_id"]...)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	if usages == nil {
+		usages = []QuotaUsage{}
+	}
+	api.Encode(w, r, http.StatusOK, Response{Quotas: usages})
+}
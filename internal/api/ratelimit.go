@@ -0,0 +1,119 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"darlinggo.co/api"
+)
+
+// RequestErrRateLimited is returned, with a 429 status and a Retry-After
+// header, when a principal has exceeded its rate limit for a route.
+const RequestErrRateLimited = "rate_limited"
+
+// RateLimit is a token bucket: RequestsPerSecond are allowed on average,
+// with bursts of up to Burst requests. A RequestsPerSecond of 0 means
+// unlimited.
+type RateLimit struct {
+	RequestsPerSecond float64 `json:"requests_per_second" yaml:"requests_per_second"`
+	Burst             int     `json:"burst" yaml:"burst"`
+}
+
+func (l RateLimit) unlimited() bool {
+	return l.RequestsPerSecond <= 0
+}
+
+func (l RateLimit) burst() float64 {
+	if l.Burst < 1 {
+		return 1
+	}
+	return float64(l.Burst)
+}
+
+type tokenBucket struct {
+	limit  RateLimit
+	tokens float64
+	last   time.Time
+}
+
+// take removes a token from the bucket, returning how long to wait before
+// trying again if there wasn't one.
+func (b *tokenBucket) take(now time.Time) (bool, time.Duration) {
+	b.tokens = math.Min(b.limit.burst(), b.tokens+now.Sub(b.last).Seconds()*b.limit.RequestsPerSecond)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := (1 - b.tokens) / b.limit.RequestsPerSecond
+	return false, time.Duration(wait * float64(time.Second))
+}
+
+// RateLimiter keeps a token bucket for every principal and route, so one
+// client hammering one endpoint doesn't starve everyone else.
+type RateLimiter struct {
+	mu      sync.Mutex
+	def     RateLimit
+	buckets map[string]*tokenBucket
+}
+
+func NewRateLimiter(def RateLimit) *RateLimiter {
+	return &RateLimiter{
+		def:     def,
+		buckets: map[string]*tokenBucket{},
+	}
+}
+
+// SetDefault replaces the limit used for tokens that don't set their own.
+func (l *RateLimiter) SetDefault(def RateLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.def = def
+}
+
+func (l *RateLimiter) allow(key string, limit *RateLimit, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lim := l.def
+	if limit != nil {
+		lim = *limit
+	}
+	if lim.unlimited() {
+		delete(l.buckets, key)
+		return true, 0
+	}
+	b, ok := l.buckets[key]
+	if !ok || b.limit != lim {
+		b = &tokenBucket{limit: lim, tokens: lim.burst(), last: now}
+		l.buckets[key] = b
+	}
+	return b.take(now)
+}
+
+// Middleware rejects requests from principals that have exceeded their
+// limit for the route, as matched against routes. It must run after
+// authentication.
+func (l *RateLimiter) Middleware(baseURL string, routes []string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t, _ := tokenFromContext(r.Context())
+		route := "unmatched"
+		p := strings.TrimPrefix(r.URL.Path, baseURL)
+		for _, pattern := range routes {
+			if matchRoute(pattern, p) {
+				route = pattern
+				break
+			}
+		}
+		ok, wait := l.allow(t.Principal+" "+r.Method+" "+route, t.RateLimit, time.Now())
+		if !ok {
+			w.Header().Set("Retry-After", strconv.FormatInt(int64(math.Ceil(wait.Seconds())), 10))
+			api.Encode(w, r, http.StatusTooManyRequests, Response{Errors: []api.RequestError{{Header: "Authorization", Slug: RequestErrRateLimited}}})
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
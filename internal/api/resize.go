@@ -0,0 +1,130 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"darlinggo.co/api"
+)
+
+// settleEHSCluster returns the cluster as ready if the upgrade or resize it
+// was waiting on has finished without updating it, which happens if it was
+// deleted and restored while the change ran.
+func (a API) settleEHSCluster(cluster EHSCluster) EHSCluster {
+	switch cluster.Status {
+	case EHSClusterUpgrading:
+		up, err := a.Storer.GetUpgrade(cluster.UpgradeID)
+		if err != ErrUpgradeNotFound && (err != nil || !up.Done()) {
+			return cluster
+		}
+	case EHSClusterResizing:
+		op, err := a.Storer.GetOperation(cluster.OperationID)
+		if err != ErrOperationNotFound && (err != nil || op.Status == OperationRunning) {
+			return cluster
+		}
+	}
+	cluster.Status = EHSClusterReady
+	cluster.TargetRelease = ""
+	cluster.UpgradeID = ""
+	cluster.TargetProfile = ""
+	cluster.OperationID = ""
+	return cluster
+}
+
+// hostedSeats returns the total concurrent users of the AWs hosted on the
+// cluster, including AWs being migrated onto it.
+func (s *Storer) hostedSeats(clusterID string) (int, error) {
+	aws, err := s.ListAWs(nil)
+	if err != nil {
+		return 0, err
+	}
+	var seats int
+	for _, aw := range aws {
+		if aw.DeletedAt == "" && (aw.EHSClusterID == clusterID || aw.TargetEHSClusterID == clusterID) {
+			seats += aw.ConcurrentUsers
+		}
+	}
+	return seats, nil
+}
+
+// resizeEHSCluster resizes the cluster in place if the cluster being PUT
+// changes its profile, as an operation if operations are enabled. A cluster
+// can't be resized to a profile without room, alongside its node pools, for
+// the AWs it hosts. If the
+// change isn't allowed, it writes an error response and returns false.
+func (a API) resizeEHSCluster(w http.ResponseWriter, r *http.Request, cluster EHSCluster, existing EHSCluster) (EHSCluster, bool) {
+	if cluster.Profile == existing.Profile {
+		return cluster, true
+	}
+	if existing.Status == EHSClusterResizing && cluster.Profile == existing.TargetProfile {
+		cluster.Profile = existing.Profile
+		return cluster, true
+	}
+	if existing.Status != EHSClusterReady || (a.Upgrades != nil && cluster.Release != existing.Release) {
+		api.Encode(w, r, http.StatusConflict, Response{Errors: []api.RequestError{{Field: "/profile", Slug: api.RequestErrConflict}}})
+		return EHSCluster{}, false
+	}
+	profile, ok := findProfile(cluster.Profile)
+	if !ok {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/profile", Slug: api.RequestErrInvalidValue}}})
+		return EHSCluster{}, false
+	}
+	pools, err := a.Storer.poolCapacity(existing.ID, "")
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return EHSCluster{}, false
+	}
+	room, err := a.roomForAWs(cluster, pools)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return EHSCluster{}, false
+	}
+	if !room {
+		api.Encode(w, r, http.StatusConflict, Response{Errors: []api.RequestError{{Field: "/profile", Slug: api.RequestErrInsufficient}}})
+		return EHSCluster{}, false
+	}
+	if a.Operations == nil {
+		cluster.CurrentNodes = cluster.resizedNodes(profile)
+		cluster.DesiredNodes = cluster.CurrentNodes
+		cluster.Capacity = clusterCapacity(profile.Name, cluster.CurrentNodes, pools)
+		return cluster, true
+	}
+	cluster.Profile = existing.Profile
+	cluster.Status = EHSClusterResizing
+	cluster.TargetProfile = profile.Name
+	op, err := a.Operations.Prepare(r.Context(), Operation{Kind: "ehscluster.resize", ResourceType: "ehscluster", ResourceID: existing.ID}, func(ctx context.Context) (int, Response) {
+		return a.finishResize(existing.ID, profile)
+	}, func() {
+		a.cancelResize(existing.ID, profile)
+	})
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return EHSCluster{}, false
+	}
+	cluster.OperationID = op.ID
+	return cluster, true
+}
+
+// finishResize moves the cluster onto profile, as long as it still has room
+// for the AWs the cluster hosts.
+func (a API) finishResize(id string, profile Profile) (int, Response) {
+	cluster, err := a.Storer.FinishEHSClusterResize(id, profile)
+	switch err {
+	case nil:
+	case ErrEHSClusterNotFound:
+		return http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}}
+	case ErrEHSClusterChanged:
+		return http.StatusConflict, Response{Errors: []api.RequestError{{Field: "/profile", Slug: api.RequestErrConflict}}}
+	case ErrNoRoom:
+		// AWs were added while the resize was waiting, so it can't go ahead.
+		return http.StatusConflict, Response{Errors: []api.RequestError{{Field: "/profile", Slug: api.RequestErrInsufficient}}}
+	default:
+		return http.StatusInternalServerError, Response{Errors: api.ActOfGodError}
+	}
+	return http.StatusOK, Response{EHSClusters: []EHSCluster{cluster}}
+}
+
+// cancelResize leaves the cluster on its current profile.
+func (a API) cancelResize(id string, profile Profile) {
+	a.Storer.CancelEHSClusterResize(id, profile.Name) //nolint:errcheck
+}
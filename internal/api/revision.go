@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"darlinggo.co/api"
+	"darlinggo.co/trout/v2"
+)
+
+// Revision is the state of a resource after a create, update or delete.
+// Revisions are numbered from 1 for each resource, and indexed across every
+// resource in the order they happened.
+type Revision struct {
+	Index        uint64          `json:"index"`
+	Event        string          `json:"event"`
+	ResourceType string          `json:"resource_type"`
+	ResourceID   string          `json:"resource_id"`
+	Number       int             `json:"revision"`
+	Time         time.Time       `json:"time"`
+	Deleted      bool            `json:"deleted,omitempty"`
+	Object       json.RawMessage `json:"object,omitempty"`
+}
+
+func (a API) handleListRevisions(resourceType string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		revs, err := a.Storer.ListRevisions(resourceType, trout.RequestVars(r).Get("id"))
+		if err != nil {
+			api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+			return
+		}
+		if len(revs) < 1 {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusOK, Response{Revisions: revs})
+	})
+}
+
+// getRevision decodes the revision of the requested resource named by the
+// revision query param into obj. If it can't, it writes an error response
+// and returns false.
+func (a API) getRevision(w http.ResponseWriter, r *http.Request, resourceType string, obj interface{}) bool {
+	number, err := strconv.Atoi(r.URL.Query().Get("revision"))
+	if err != nil || number < 1 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "revision", Slug: api.RequestErrInvalidFormat}}})
+		return false
+	}
+	rev, err := a.Storer.GetRevision(resourceType, trout.RequestVars(r).Get("id"), number)
+	if err == nil && rev.Object == nil {
+		// Purged resources only leave a tombstone behind.
+		err = ErrRevisionNotFound
+	}
+	if err != nil {
+		if err == ErrRevisionNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "revision", Slug: api.RequestErrNotFound}}})
+			return false
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return false
+	}
+	err = json.Unmarshal(rev.Object, obj)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,681 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"darlinggo.co/api"
+	"darlinggo.co/trout/v2"
+	"github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/go-uuid"
+)
+
+const (
+	ScheduledActionApplied = "applied"
+	ScheduledActionFailed  = "failed"
+)
+
+var (
+	ErrAWScheduleNotFound      = errors.New("AW schedule not found")
+	ErrAWScheduleAlreadyExists = errors.New("AW schedule already exists")
+	ErrAWAlreadyScheduled      = errors.New("AW already has a schedule")
+	ErrAWMigrating             = errors.New("AW is migrating")
+)
+
+// AWSchedule changes an AW's concurrent users at the times its rules' cron
+// expressions match, in Timezone. While an AW has a schedule, its concurrent
+// users can only be changed by the schedule.
+type AWSchedule struct {
+	ID         string           `json:"id,omitempty"`
+	AWID       string           `json:"aw_id"`
+	Timezone   string           `json:"timezone,omitempty"`
+	Rules      []ScheduleRule   `json:"rules"`
+	NextAction *ScheduledAction `json:"next_action,omitempty"`
+	LastAction *ScheduledAction `json:"last_action,omitempty"`
+	CreatedAt  string           `json:"created_at,omitempty"`
+	UpdatedAt  string           `json:"updated_at,omitempty"`
+}
+
+// ScheduleRule sets the AW to ConcurrentUsers seats whenever Cron, a five
+// field cron expression like "0 7 * * mon-fri", matches.
+type ScheduleRule struct {
+	Cron            string `json:"cron"`
+	ConcurrentUsers int    `json:"concurrent_users"`
+}
+
+// ScheduledAction is a change to an AW's seats a schedule's rule makes at
+// At. Status is only set once the action has been taken.
+type ScheduledAction struct {
+	At              string `json:"at"`
+	Cron            string `json:"cron"`
+	ConcurrentUsers int    `json:"concurrent_users"`
+	Status          string `json:"status,omitempty"`
+}
+
+func validateAWSchedule(sched AWSchedule) []api.RequestError {
+	var errs []api.RequestError
+	if sched.AWID == "" {
+		errs = append(errs, api.RequestError{Field: "/aw_id", Slug: api.RequestErrMissing})
+	}
+	if _, err := time.LoadLocation(sched.Timezone); err != nil {
+		errs = append(errs, api.RequestError{Field: "/timezone", Slug: api.RequestErrInvalidValue})
+	}
+	if len(sched.Rules) < 1 {
+		errs = append(errs, api.RequestError{Field: "/rules", Slug: api.RequestErrMissing})
+	}
+	for i, rule := range sched.Rules {
+		if rule.Cron == "" {
+			errs = append(errs, api.RequestError{Field: "/rules/" + strconv.Itoa(i) + "/cron", Slug: api.RequestErrMissing})
+		} else if _, err := parseCron(rule.Cron); err != nil {
+			errs = append(errs, api.RequestError{Field: "/rules/" + strconv.Itoa(i) + "/cron", Slug: api.RequestErrInvalidValue})
+		}
+		if rule.ConcurrentUsers < 0 {
+			errs = append(errs, api.RequestError{Field: "/rules/" + strconv.Itoa(i) + "/concurrent_users", Slug: api.RequestErrInvalidValue})
+		}
+	}
+	return errs
+}
+
+// nextAction returns the first action the schedule's rules make after
+// after, in its timezone, or nil if they never match again. When rules
+// match at the same time, the first of them wins.
+func (sched AWSchedule) nextAction(after time.Time) *ScheduledAction {
+	loc, err := time.LoadLocation(sched.Timezone)
+	if err != nil {
+		return nil
+	}
+	after = after.In(loc)
+	var next *ScheduledAction
+	var nextAt time.Time
+	for _, rule := range sched.Rules {
+		expr, err := parseCron(rule.Cron)
+		if err != nil {
+			continue
+		}
+		at, ok := expr.next(after)
+		if !ok || (next != nil && !at.Before(nextAt)) {
+			continue
+		}
+		nextAt = at
+		next = &ScheduledAction{
+			At:              at.Format(time.RFC3339),
+			Cron:            rule.Cron,
+			ConcurrentUsers: rule.ConcurrentUsers,
+		}
+	}
+	return next
+}
+
+// SchedulerConfig sets how often the scheduler checks for AW schedule
+// actions that are due. Actions are taken at the first check after they're
+// due.
+type SchedulerConfig struct {
+	Interval time.Duration `json:"interval" yaml:"interval"`
+}
+
+// Scheduler takes the actions AW schedules make when they're due, in the
+// background. Every change to an AW's seats is recorded as a schedule
+// event.
+type Scheduler struct {
+	storer     *Storer
+	autoscaler *Autoscaler
+	wake       chan struct{}
+
+	mu     sync.Mutex
+	config SchedulerConfig
+}
+
+func NewScheduler(storer *Storer, autoscaler *Autoscaler, config SchedulerConfig) *Scheduler {
+	return &Scheduler{
+		storer:     storer,
+		autoscaler: autoscaler,
+		wake:       make(chan struct{}, 1),
+		config:     config,
+	}
+}
+
+// SetConfig replaces the interval from the next check on.
+func (s *Scheduler) SetConfig(config SchedulerConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config = config
+}
+
+func (s *Scheduler) interval() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.config.Interval
+}
+
+// Wake asks for a check straight away, instead of at the next interval.
+func (s *Scheduler) Wake() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run checks every AW schedule each interval, and whenever it's woken,
+// until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	for {
+		timer := time.NewTimer(s.interval())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		case <-s.wake:
+			timer.Stop()
+		}
+		err := s.Reconcile(time.Now())
+		if err != nil {
+			log.Println("Error running AW schedules:", err.Error())
+		}
+	}
+}
+
+// Reconcile takes the action each AW schedule has due at now, and works out
+// its next one. If more than one action came due since the last check, only
+// the latest is taken. A schedule that can't be reconciled is logged and
+// skipped, so it doesn't hold up the others.
+func (s *Scheduler) Reconcile(now time.Time) error {
+	scheds, err := s.storer.ListAWSchedules("")
+	if err != nil {
+		return err
+	}
+	for _, sched := range scheds {
+		err = s.reconcile(sched, now)
+		if err != nil {
+			log.Printf("Error running AW schedule %s: %s", sched.ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *Scheduler) reconcile(sched AWSchedule, now time.Time) error {
+	last := sched.LastAction
+	due := sched.NextAction
+	for due != nil {
+		at, err := time.Parse(time.RFC3339, due.At)
+		if err != nil || at.After(now) {
+			due = nil
+			break
+		}
+		next := sched.nextAction(at)
+		if next == nil {
+			break
+		}
+		nextAt, err := time.Parse(time.RFC3339, next.At)
+		if err != nil || nextAt.After(now) {
+			break
+		}
+		due = next
+	}
+	if due != nil {
+		status, err := s.apply(sched.AWID, due.ConcurrentUsers)
+		if err != nil {
+			return err
+		}
+		if status == "" {
+			return nil
+		}
+		action := *due
+		action.Status = status
+		last = &action
+	}
+	next := sched.nextAction(now)
+	if reflect.DeepEqual(last, sched.LastAction) && reflect.DeepEqual(next, sched.NextAction) {
+		return nil
+	}
+	err := s.storer.UpdateScheduledActions(sched, last, next)
+	if err != nil && err != ErrAWScheduleNotFound {
+		return err
+	}
+	return nil
+}
+
+// apply sets the AW to seats concurrent users, as long as its EHS cluster
+// has room for them, returning the status of the action. AWs that are
+// migrating are left until they're done, with an empty status.
+func (s *Scheduler) apply(awID string, seats int) (string, error) {
+	aw, err := s.storer.GetAW(awID)
+	if err == ErrAWNotFound {
+		return ScheduledActionFailed, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if aw.Status == AWMigrating {
+		return "", nil
+	}
+	if seats == aw.ConcurrentUsers {
+		return ScheduledActionApplied, nil
+	}
+	if seats > aw.ConcurrentUsers {
+		cluster, err := s.storer.GetEHSCluster(aw.EHSClusterID)
+		if err == ErrEHSClusterNotFound {
+			return ScheduledActionFailed, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		free, err := s.storer.freeSeats(cluster, aw.ID)
+		if err != nil {
+			return "", err
+		}
+		if free < seats {
+			return ScheduledActionFailed, nil
+		}
+	}
+	err = s.storer.ScheduleAW(aw.ID, seats)
+	var quotaErr QuotaExceededError
+	switch {
+	case err == ErrAWMigrating:
+		return "", nil
+	case err == ErrAWNotFound || err == ErrNoRoom || errors.As(err, &quotaErr):
+		return ScheduledActionFailed, nil
+	case err != nil:
+		return "", err
+	}
+	if s.autoscaler != nil {
+		s.autoscaler.Wake()
+	}
+	return ScheduledActionApplied, nil
+}
+
+// wakeScheduler asks the scheduler, if there is one, to check the AW
+// schedules now that one has changed.
+func (a API) wakeScheduler() {
+	if a.Scheduler != nil {
+		a.Scheduler.Wake()
+	}
+}
+
+func (a API) handleListAWSchedules(w http.ResponseWriter, r *http.Request) {
+	scheds, err := a.Storer.ListAWSchedules(r.URL.Query().Get("aw_id"))
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	if scheds == nil {
+		scheds = []AWSchedule{}
+	}
+	api.Encode(w, r, http.StatusOK, Response{AWSchedules: scheds})
+}
+
+func (a API) handleGetAWSchedule(w http.ResponseWriter, r *http.Request) {
+	id := trout.RequestVars(r).Get("id")
+	if !a.block(w, r, func(ctx context.Context, index uint64, wait time.Duration) error {
+		return a.Storer.WaitForChange(ctx, "awschedule", id, index, wait)
+	}) {
+		return
+	}
+	sched, err := a.Storer.GetAWSchedule(id)
+	if err != nil {
+		if err == ErrAWScheduleNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{AWSchedules: []AWSchedule{sched}})
+}
+
+func (a API) handlePostAWSchedule(w http.ResponseWriter, r *http.Request) {
+	var sched AWSchedule
+	err := api.Decode(r, &sched)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	if sched.Timezone == "" {
+		sched.Timezone = "UTC"
+	}
+	if errs := validateAWSchedule(sched); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	sched.ID, err = uuid.GenerateUUID()
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	now := time.Now()
+	sched.LastAction = nil
+	sched.NextAction = sched.nextAction(now)
+	sched.CreatedAt = now.UTC().Format(time.RFC3339)
+	sched.UpdatedAt = sched.CreatedAt
+	err = a.Storer.CreateAWSchedule(sched)
+	if err != nil {
+		switch err {
+		case ErrAWScheduleAlreadyExists:
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrConflict}}})
+		case ErrAWNotFound:
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/aw_id", Slug: api.RequestErrInvalidValue}}})
+		case ErrAWAlreadyScheduled:
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/aw_id", Slug: api.RequestErrConflict}}})
+		default:
+			api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		}
+		return
+	}
+	a.wakeScheduler()
+	api.Encode(w, r, http.StatusCreated, Response{AWSchedules: []AWSchedule{sched}})
+}
+
+func (a API) handlePutAWSchedule(w http.ResponseWriter, r *http.Request) {
+	var sched AWSchedule
+	err := api.Decode(r, &sched)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	if sched.Timezone == "" {
+		sched.Timezone = "UTC"
+	}
+	if errs := validateAWSchedule(sched); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	existing, err := a.Storer.GetAWSchedule(trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrAWScheduleNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	// Moving a schedule to another AW means replacing it.
+	if !strings.EqualFold(sched.AWID, existing.AWID) {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/aw_id", Slug: api.RequestErrInvalidValue}}})
+		return
+	}
+	now := time.Now()
+	sched.ID = existing.ID
+	sched.AWID = existing.AWID
+	sched.LastAction = existing.LastAction
+	sched.NextAction = sched.nextAction(now)
+	sched.CreatedAt = existing.CreatedAt
+	sched.UpdatedAt = now.UTC().Format(time.RFC3339)
+	err = a.Storer.UpdateAWSchedule(sched)
+	if err != nil {
+		if err == ErrAWScheduleNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	a.wakeScheduler()
+	api.Encode(w, r, http.StatusOK, Response{AWSchedules: []AWSchedule{sched}})
+}
+
+func (a API) handleDeleteAWSchedule(w http.ResponseWriter, r *http.Request) {
+	sched, err := a.Storer.DeleteAWSchedule(trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrAWScheduleNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{AWSchedules: []AWSchedule{sched}})
+}
+
+func (s *Storer) GetAWSchedule(id string) (AWSchedule, error) {
+	txn := s.db.Txn(false)
+	sched, err := txn.First("awschedule", "id", id)
+	if err != nil {
+		return AWSchedule{}, err
+	}
+	if sched == nil {
+		return AWSchedule{}, ErrAWScheduleNotFound
+	}
+	return *sched.(*AWSchedule), nil
+}
+
+// ListAWSchedules returns the schedule of the AW or, if awID is empty,
+// every schedule.
+func (s *Storer) ListAWSchedules(awID string) ([]AWSchedule, error) {
+	txn := s.db.Txn(false)
+	var iter memdb.ResultIterator
+	var err error
+	if awID != "" {
+		iter, err = txn.Get("awschedule", "aw", awID)
+	} else {
+		iter, err = txn.Get("awschedule", "id")
+	}
+	if err != nil {
+		return nil, err
+	}
+	var results []AWSchedule
+	for obj := iter.Next(); obj != nil; obj = iter.Next() {
+		results = append(results, *obj.(*AWSchedule))
+	}
+	return results, nil
+}
+
+// CreateAWSchedule stores sched and ties it to its AW, which can only have
+// one schedule.
+func (s *Storer) CreateAWSchedule(sched AWSchedule) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	exists, err := txn.First("awschedule", "id", sched.ID)
+	if err != nil {
+		return err
+	}
+	if exists != nil {
+		return ErrAWScheduleAlreadyExists
+	}
+	existing, err := txn.First("aw", "id", sched.AWID)
+	if err != nil {
+		return err
+	}
+	if existing == nil || existing.(*AW).DeletedAt != "" {
+		return ErrAWNotFound
+	}
+	scheduled, err := txn.First("awschedule", "aw", sched.AWID)
+	if err != nil {
+		return err
+	}
+	if scheduled != nil {
+		return ErrAWAlreadyScheduled
+	}
+	aw := *existing.(*AW)
+	sched.AWID = aw.ID
+	aw.ScheduleID = sched.ID
+	err = txn.Insert("aw", &aw)
+	if err != nil {
+		return err
+	}
+	err = s.recordRevision(txn, "aw", aw.ID, aw, EventUpdate, false)
+	if err != nil {
+		return err
+	}
+	err = txn.Insert("awschedule", &sched)
+	if err != nil {
+		return err
+	}
+	err = s.recordRevision(txn, "awschedule", sched.ID, sched, EventCreate, false)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+func (s *Storer) UpdateAWSchedule(sched AWSchedule) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("awschedule", "id", sched.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrAWScheduleNotFound
+	}
+	err = txn.Insert("awschedule", &sched)
+	if err != nil {
+		return err
+	}
+	err = s.recordRevision(txn, "awschedule", sched.ID, sched, EventUpdate, false)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// UpdateScheduledActions stores the last and next actions the scheduler has
+// worked out for sched. If its rules or timezone have changed since, it's
+// left for the next check.
+func (s *Storer) UpdateScheduledActions(sched AWSchedule, last, next *ScheduledAction) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("awschedule", "id", sched.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrAWScheduleNotFound
+	}
+	updated := *existing.(*AWSchedule)
+	if updated.Timezone != sched.Timezone || !reflect.DeepEqual(updated.Rules, sched.Rules) {
+		return nil
+	}
+	updated.LastAction = last
+	updated.NextAction = next
+	err = txn.Insert("awschedule", &updated)
+	if err != nil {
+		return err
+	}
+	err = s.recordRevision(txn, "awschedule", updated.ID, updated, EventUpdate, false)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+func (s *Storer) DeleteAWSchedule(id string) (AWSchedule, error) {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("awschedule", "id", id)
+	if err != nil {
+		return AWSchedule{}, err
+	}
+	if existing == nil {
+		return AWSchedule{}, ErrAWScheduleNotFound
+	}
+	err = txn.Delete("awschedule", existing)
+	if err != nil {
+		return AWSchedule{}, err
+	}
+	err = s.recordRevision(txn, "awschedule", id, existing, EventDelete, true)
+	if err != nil {
+		return AWSchedule{}, err
+	}
+	aw, err := txn.First("aw", "id", existing.(*AWSchedule).AWID)
+	if err != nil {
+		return AWSchedule{}, err
+	}
+	if aw != nil && aw.(*AW).ScheduleID == id {
+		unscheduled := *aw.(*AW)
+		unscheduled.ScheduleID = ""
+		err = txn.Insert("aw", &unscheduled)
+		if err != nil {
+			return AWSchedule{}, err
+		}
+		err = s.recordRevision(txn, "aw", unscheduled.ID, unscheduled, EventUpdate, false)
+		if err != nil {
+			return AWSchedule{}, err
+		}
+	}
+	txn.Commit()
+	return *existing.(*AWSchedule), nil
+}
+
+// deleteAWSchedules deletes the schedule of the AW being deleted, as part of
+// txn.
+func (s *Storer) deleteAWSchedules(txn *memdb.Txn, awID string) error {
+	scheds, err := txn.Get("awschedule", "aw", awID)
+	if err != nil {
+		return err
+	}
+	var deleted []interface{}
+	for sched := scheds.Next(); sched != nil; sched = scheds.Next() {
+		deleted = append(deleted, sched)
+	}
+	for _, sched := range deleted {
+		err = txn.Delete("awschedule", sched)
+		if err != nil {
+			return err
+		}
+		err = s.recordRevision(txn, "awschedule", sched.(*AWSchedule).ID, sched, EventDelete, true)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ScheduleAW sets the AW to seats concurrent users, recording a schedule
+// event. AWs that are migrating are left alone, returning ErrAWMigrating.
+func (s *Storer) ScheduleAW(id string, seats int) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("aw", "id", id)
+	if err != nil {
+		return err
+	}
+	if existing == nil || existing.(*AW).DeletedAt != "" {
+		return ErrAWNotFound
+	}
+	scheduled := *existing.(*AW)
+	if scheduled.Status == AWMigrating {
+		return ErrAWMigrating
+	}
+	account, err := awAccount(txn, scheduled.EHSClusterID)
+	if err != nil {
+		return err
+	}
+	checkQuota, err := s.quotaGuard(txn, account)
+	if err != nil {
+		return err
+	}
+	checkRoom, err := roomGuard(txn, scheduled.EHSClusterID)
+	if err != nil {
+		return err
+	}
+	scheduled.ConcurrentUsers = seats
+	scheduled.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	err = txn.Insert("aw", &scheduled)
+	if err != nil {
+		return err
+	}
+	err = checkQuota()
+	if err != nil {
+		return err
+	}
+	err = checkRoom()
+	if err != nil {
+		return err
+	}
+	err = s.recordRevision(txn, "aw", scheduled.ID, scheduled, EventSchedule, false)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
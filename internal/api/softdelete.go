@@ -0,0 +1,91 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"darlinggo.co/api"
+)
+
+type deletedFilter string
+
+const (
+	deletedExclude deletedFilter = ""
+	deletedInclude deletedFilter = "include"
+	deletedOnly    deletedFilter = "only"
+)
+
+func (f deletedFilter) matches(deletedAt string) bool {
+	switch f {
+	case deletedInclude:
+		return true
+	case deletedOnly:
+		return deletedAt != ""
+	}
+	return deletedAt == ""
+}
+
+// parseDeletedFilter reads the deleted query param, which can be "include"
+// or "only". If it's invalid, an error response is written and ok is false.
+func parseDeletedFilter(w http.ResponseWriter, r *http.Request) (filter deletedFilter, ok bool) {
+	filter = deletedFilter(r.URL.Query().Get("deleted"))
+	switch filter {
+	case deletedExclude, deletedInclude, deletedOnly:
+		return filter, true
+	}
+	api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Param: "deleted", Slug: api.RequestErrInvalidValue}}})
+	return filter, false
+}
+
+// softDelete reports whether a DELETE request should keep the resource
+// around for the retention window, rather than removing it immediately.
+func (a API) softDelete(r *http.Request) bool {
+	return a.Retention > 0 && r.URL.Query().Get("force") != "true"
+}
+
+// splitAction splits a custom method off a resource ID, turning
+// "{id}:restore" into "{id}" and "restore".
+func splitAction(id string) (string, string) {
+	pos := strings.LastIndex(id, ":")
+	if pos < 0 {
+		return id, ""
+	}
+	return id[:pos], id[pos+1:]
+}
+
+// RunPurger permanently removes soft-deleted resources once they're older
+// than the retention window, and revisions once they're older than the
+// revision retention, checking every interval until ctx is done.
+func (a API) RunPurger(ctx context.Context, interval time.Duration) {
+	if a.Retention <= 0 && a.RevisionRetention <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if a.Retention > 0 {
+				n, err := a.Storer.Purge(time.Now().Add(-a.Retention))
+				if err != nil {
+					log.Println("Error purging deleted resources:", err.Error())
+				} else if n > 0 {
+					log.Printf("Purged %d deleted resources", n)
+				}
+			}
+			if a.RevisionRetention > 0 {
+				n, err := a.Storer.PruneRevisions(time.Now().Add(-a.RevisionRetention))
+				if err != nil {
+					log.Println("Error pruning revisions:", err.Error())
+				} else if n > 0 {
+					log.Printf("Pruned %d revisions", n)
+				}
+			}
+		}
+	}
+}
@@ -1,7 +1,12 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"sort"
+	"sync/atomic"
+	"time"
 
 	"github.com/hashicorp/go-memdb"
 )
@@ -15,10 +20,29 @@ var (
 	ErrAWAlreadyExists         = errors.New("AW already exists")
 	ErrAVNotFound              = errors.New("AV not found")
 	ErrAVAlreadyExists         = errors.New("AV already exists")
+	ErrEAStoreNotDeleted       = errors.New("EAStore not deleted")
+	ErrEHSClusterNotDeleted    = errors.New("EHSCluster not deleted")
+	ErrEHSClusterChanged       = errors.New("EHSCluster changed")
+	ErrAWNotDeleted            = errors.New("AW not deleted")
+	ErrAWChanged               = errors.New("AW changed")
+	ErrAVNotDeleted            = errors.New("AV not deleted")
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrWebhookAlreadyExists    = errors.New("webhook already exists")
+	ErrTooManyDeliveries       = errors.New("too many pending deliveries")
+	ErrRevisionNotFound        = errors.New("revision not found")
+	ErrStorerClosed            = errors.New("storer is closed")
+	ErrWaitTimeout             = errors.New("timed out waiting for change")
 )
 
 type Storer struct {
-	db *memdb.MemDB
+	db     *memdb.MemDB
+	closed int32
+	// next is the last index handed to a write transaction and index the
+	// last one whose transaction has committed. Index reports index, so a
+	// change is never visible by index before it's visible in the tables.
+	next   uint64
+	index  uint64
+	quotas atomic.Value
 }
 
 func NewStorer() (*Storer, error) {
@@ -32,6 +56,16 @@ func NewStorer() (*Storer, error) {
 						Unique:  true,
 						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
 					},
+					"labels": {
+						Name:         "labels",
+						AllowMissing: true,
+						Indexer:      &memdb.StringMapFieldIndex{Field: "Labels"},
+					},
+					"account": {
+						Name:         "account",
+						AllowMissing: true,
+						Indexer:      &memdb.StringFieldIndex{Field: "AccountID"},
+					},
 				},
 			},
 			"ehscluster": {
@@ -42,6 +76,16 @@ func NewStorer() (*Storer, error) {
 						Unique:  true,
 						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
 					},
+					"labels": {
+						Name:         "labels",
+						AllowMissing: true,
+						Indexer:      &memdb.StringMapFieldIndex{Field: "Labels"},
+					},
+					"account": {
+						Name:         "account",
+						AllowMissing: true,
+						Indexer:      &memdb.StringFieldIndex{Field: "AccountID"},
+					},
 				},
 			},
 			"aw": {
@@ -52,6 +96,16 @@ func NewStorer() (*Storer, error) {
 						Unique:  true,
 						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
 					},
+					"labels": {
+						Name:         "labels",
+						AllowMissing: true,
+						Indexer:      &memdb.StringMapFieldIndex{Field: "Labels"},
+					},
+					"ehscluster": {
+						Name:         "ehscluster",
+						AllowMissing: true,
+						Indexer:      &memdb.StringFieldIndex{Field: "EHSClusterID", Lowercase: true},
+					},
 				},
 			},
 			"av": {
@@ -62,6 +116,143 @@ func NewStorer() (*Storer, error) {
 						Unique:  true,
 						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
 					},
+					"labels": {
+						Name:         "labels",
+						AllowMissing: true,
+						Indexer:      &memdb.StringMapFieldIndex{Field: "Labels"},
+					},
+					"account": {
+						Name:         "account",
+						AllowMissing: true,
+						Indexer:      &memdb.StringFieldIndex{Field: "AccountID"},
+					},
+				},
+			},
+			"nodepool": {
+				Name: "nodepool",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
+					},
+					"ehscluster": {
+						Name:    "ehscluster",
+						Indexer: &memdb.StringFieldIndex{Field: "EHSClusterID", Lowercase: true},
+					},
+				},
+			},
+			"awschedule": {
+				Name: "awschedule",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
+					},
+					"aw": {
+						Name:    "aw",
+						Indexer: &memdb.StringFieldIndex{Field: "AWID", Lowercase: true},
+					},
+				},
+			},
+			"webhook": {
+				Name: "webhook",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
+					},
+				},
+			},
+			"delivery": {
+				Name: "delivery",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
+					},
+					"webhook": {
+						Name:    "webhook",
+						Indexer: &memdb.StringFieldIndex{Field: "WebhookID", Lowercase: true},
+					},
+				},
+			},
+			"operation": {
+				Name: "operation",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
+					},
+				},
+			},
+			"upgrade": {
+				Name: "upgrade",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
+					},
+				},
+			},
+			"migration": {
+				Name: "migration",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "ID", Lowercase: true},
+					},
+				},
+			},
+			"revision": {
+				Name: "revision",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:   "id",
+						Unique: true,
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.StringFieldIndex{Field: "ResourceType"},
+								&memdb.StringFieldIndex{Field: "ResourceID", Lowercase: true},
+								&memdb.IntFieldIndex{Field: "Number"},
+							},
+						},
+					},
+					"resource": {
+						Name: "resource",
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.StringFieldIndex{Field: "ResourceType"},
+								&memdb.StringFieldIndex{Field: "ResourceID", Lowercase: true},
+							},
+						},
+					},
+					"index": {
+						Name:    "index",
+						Unique:  true,
+						Indexer: &memdb.UintFieldIndex{Field: "Index"},
+					},
+				},
+			},
+			"revisioncount": {
+				Name: "revisioncount",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:   "id",
+						Unique: true,
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.StringFieldIndex{Field: "ResourceType"},
+								&memdb.StringFieldIndex{Field: "ResourceID", Lowercase: true},
+							},
+						},
+					},
 				},
 			},
 		},
@@ -74,18 +265,52 @@ func NewStorer() (*Storer, error) {
 	}, nil
 }
 
+// Ping reports whether the Storer can serve requests.
+func (s *Storer) Ping() error {
+	if atomic.LoadInt32(&s.closed) != 0 {
+		return ErrStorerClosed
+	}
+	txn := s.db.Txn(false)
+	_, err := txn.First("eastore", "id", "")
+	return err
+}
+
+// Close marks the Storer as no longer ready, so readiness checks fail while
+// the server drains. It carries on serving reads and writes, so in-flight
+// requests can finish.
+func (s *Storer) Close() error {
+	atomic.StoreInt32(&s.closed, 1)
+	return nil
+}
+
 func (s *Storer) GetEAStore(id string) (EAStore, error) {
 	txn := s.db.Txn(false)
 	ap, err := txn.First("eastore", "id", id)
 	if err != nil {
 		return EAStore{}, err
 	}
-	if ap == nil {
+	if ap == nil || ap.(*EAStore).DeletedAt != "" {
 		return EAStore{}, ErrEAStoreNotFound
 	}
 	return *ap.(*EAStore), nil
 }
 
+// ListEAStores returns every EAStore whose labels match selector.
+func (s *Storer) ListEAStores(selector Selector) ([]EAStore, error) {
+	txn := s.db.Txn(false)
+	iter, err := selector.iterate(txn, "eastore")
+	if err != nil {
+		return nil, err
+	}
+	var results []EAStore
+	for obj := iter.Next(); obj != nil; obj = iter.Next() {
+		if selector.Matches(obj.(*EAStore).Labels) {
+			results = append(results, *obj.(*EAStore))
+		}
+	}
+	return results, nil
+}
+
 func (s *Storer) CreateEAStore(ap EAStore) error {
 	txn := s.db.Txn(true)
 	defer txn.Abort()
@@ -96,10 +321,22 @@ func (s *Storer) CreateEAStore(ap EAStore) error {
 	if exists != nil {
 		return ErrEAStoreAlreadyExists
 	}
+	checkQuota, err := s.quotaGuard(txn, ap.AccountID)
+	if err != nil {
+		return err
+	}
 	err = txn.Insert("eastore", &ap)
 	if err != nil {
 		return err
 	}
+	err = checkQuota()
+	if err != nil {
+		return err
+	}
+	err = s.recordRevision(txn, "eastore", ap.ID, ap, EventCreate, false)
+	if err != nil {
+		return err
+	}
 	txn.Commit()
 	return nil
 }
@@ -111,33 +348,110 @@ func (s *Storer) UpdateEAStore(ap EAStore) error {
 	if err != nil {
 		return err
 	}
-	if existing == nil {
+	if existing == nil || existing.(*EAStore).DeletedAt != "" {
 		return ErrEAStoreNotFound
 	}
+	checkQuota, err := s.quotaGuard(txn, ap.AccountID)
+	if err != nil {
+		return err
+	}
 	err = txn.Insert("eastore", &ap)
 	if err != nil {
 		return err
 	}
+	err = checkQuota()
+	if err != nil {
+		return err
+	}
+	err = s.recordRevision(txn, "eastore", ap.ID, ap, EventUpdate, false)
+	if err != nil {
+		return err
+	}
 	txn.Commit()
 	return nil
 }
 
-func (s *Storer) DeleteEAStore(id string) error {
+func (s *Storer) DeleteEAStore(id string) (EAStore, error) {
 	txn := s.db.Txn(true)
 	defer txn.Abort()
 	existing, err := txn.First("eastore", "id", id)
 	if err != nil {
-		return err
+		return EAStore{}, err
 	}
 	if existing == nil {
-		return ErrEAStoreNotFound
+		return EAStore{}, ErrEAStoreNotFound
 	}
 	err = txn.Delete("eastore", existing)
 	if err != nil {
-		return err
+		return EAStore{}, err
+	}
+	err = s.recordRevision(txn, "eastore", existing.(*EAStore).ID, existing, EventDelete, true)
+	if err != nil {
+		return EAStore{}, err
 	}
 	txn.Commit()
-	return nil
+	return *existing.(*EAStore), nil
+}
+
+// SoftDeleteEAStore marks the EAStore as deleted at the given time. It's hidden
+// from GetEAStore until it's restored or purged.
+func (s *Storer) SoftDeleteEAStore(id string, at time.Time) (EAStore, error) {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("eastore", "id", id)
+	if err != nil {
+		return EAStore{}, err
+	}
+	if existing == nil || existing.(*EAStore).DeletedAt != "" {
+		return EAStore{}, ErrEAStoreNotFound
+	}
+	ap := *existing.(*EAStore)
+	ap.DeletedAt = at.UTC().Format(time.RFC3339)
+	err = txn.Insert("eastore", &ap)
+	if err != nil {
+		return EAStore{}, err
+	}
+	err = s.recordRevision(txn, "eastore", ap.ID, ap, EventDelete, true)
+	if err != nil {
+		return EAStore{}, err
+	}
+	txn.Commit()
+	return ap, nil
+}
+
+func (s *Storer) RestoreEAStore(id string) (EAStore, error) {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("eastore", "id", id)
+	if err != nil {
+		return EAStore{}, err
+	}
+	if existing == nil {
+		return EAStore{}, ErrEAStoreNotFound
+	}
+	if existing.(*EAStore).DeletedAt == "" {
+		return EAStore{}, ErrEAStoreNotDeleted
+	}
+	ap := *existing.(*EAStore)
+	ap.DeletedAt = ""
+	checkQuota, err := s.quotaGuard(txn, ap.AccountID)
+	if err != nil {
+		return EAStore{}, err
+	}
+	err = txn.Insert("eastore", &ap)
+	if err != nil {
+		return EAStore{}, err
+	}
+	err = checkQuota()
+	if err != nil {
+		return EAStore{}, err
+	}
+	err = s.recordRevision(txn, "eastore", ap.ID, ap, EventStatusChange, false)
+	if err != nil {
+		return EAStore{}, err
+	}
+	txn.Commit()
+	return ap, nil
 }
 
 func (s *Storer) GetEHSCluster(id string) (EHSCluster, error) {
@@ -146,12 +460,28 @@ func (s *Storer) GetEHSCluster(id string) (EHSCluster, error) {
 	if err != nil {
 		return EHSCluster{}, err
 	}
-	if ap == nil {
+	if ap == nil || ap.(*EHSCluster).DeletedAt != "" {
 		return EHSCluster{}, ErrEHSClusterNotFound
 	}
 	return *ap.(*EHSCluster), nil
 }
 
+// ListEHSClusters returns every EHSCluster whose labels match selector.
+func (s *Storer) ListEHSClusters(selector Selector) ([]EHSCluster, error) {
+	txn := s.db.Txn(false)
+	iter, err := selector.iterate(txn, "ehscluster")
+	if err != nil {
+		return nil, err
+	}
+	var results []EHSCluster
+	for obj := iter.Next(); obj != nil; obj = iter.Next() {
+		if selector.Matches(obj.(*EHSCluster).Labels) {
+			results = append(results, *obj.(*EHSCluster))
+		}
+	}
+	return results, nil
+}
+
 func (s *Storer) CreateEHSCluster(ap EHSCluster) error {
 	txn := s.db.Txn(true)
 	defer txn.Abort()
@@ -162,73 +492,135 @@ func (s *Storer) CreateEHSCluster(ap EHSCluster) error {
 	if exists != nil {
 		return ErrEHSClusterAlreadyExists
 	}
+	checkQuota, err := s.quotaGuard(txn, ap.AccountID)
+	if err != nil {
+		return err
+	}
 	err = txn.Insert("ehscluster", &ap)
 	if err != nil {
 		return err
 	}
+	err = checkQuota()
+	if err != nil {
+		return err
+	}
+	err = s.recordRevision(txn, "ehscluster", ap.ID, ap, EventCreate, false)
+	if err != nil {
+		return err
+	}
 	txn.Commit()
 	return nil
 }
 
 func (s *Storer) UpdateEHSCluster(ap EHSCluster) error {
+	return s.updateEHSCluster(ap, nil)
+}
+
+// SwapEHSCluster replaces the cluster with ap, as long as its status,
+// upgrade and operation are still those of from, the cluster as it was
+// read. Otherwise it returns ErrEHSClusterChanged.
+func (s *Storer) SwapEHSCluster(from, ap EHSCluster) error {
+	return s.updateEHSCluster(ap, &from)
+}
+
+func (s *Storer) updateEHSCluster(ap EHSCluster, from *EHSCluster) error {
 	txn := s.db.Txn(true)
 	defer txn.Abort()
 	existing, err := txn.First("ehscluster", "id", ap.ID)
 	if err != nil {
 		return err
 	}
-	if existing == nil {
+	if existing == nil || existing.(*EHSCluster).DeletedAt != "" {
 		return ErrEHSClusterNotFound
 	}
+	if from != nil {
+		stored := existing.(*EHSCluster)
+		if stored.Status != from.Status || stored.UpgradeID != from.UpgradeID || stored.OperationID != from.OperationID {
+			return ErrEHSClusterChanged
+		}
+	}
+	checkQuota, err := s.quotaGuard(txn, ap.AccountID)
+	if err != nil {
+		return err
+	}
 	err = txn.Insert("ehscluster", &ap)
 	if err != nil {
 		return err
 	}
+	err = checkQuota()
+	if err != nil {
+		return err
+	}
+	err = s.recordRevision(txn, "ehscluster", ap.ID, ap, updateEvent(existing.(*EHSCluster).Status, ap.Status), false)
+	if err != nil {
+		return err
+	}
 	txn.Commit()
 	return nil
 }
 
-func (s *Storer) DeleteEHSCluster(id string) error {
+// ScaleEHSCluster stores the node counts and capacity the autoscaler has
+// worked out for the cluster, recording a scale event. If the cluster has
+// changed profile or stopped being ready since, it's left alone.
+func (s *Storer) ScaleEHSCluster(ap EHSCluster) error {
 	txn := s.db.Txn(true)
 	defer txn.Abort()
-	existing, err := txn.First("ehscluster", "id", id)
+	existing, err := txn.First("ehscluster", "id", ap.ID)
 	if err != nil {
 		return err
 	}
-	if existing == nil {
+	if existing == nil || existing.(*EHSCluster).DeletedAt != "" {
 		return ErrEHSClusterNotFound
 	}
-	err = txn.Delete("ehscluster", existing)
+	scaled := *existing.(*EHSCluster)
+	if scaled.Profile != ap.Profile || scaled.Status != EHSClusterReady {
+		return nil
+	}
+	scaled.CurrentNodes = ap.CurrentNodes
+	scaled.DesiredNodes = ap.DesiredNodes
+	scaled.LastScaledAt = ap.LastScaledAt
+	scaled.Capacity = ap.Capacity
+	err = txn.Insert("ehscluster", &scaled)
 	if err != nil {
 		return err
 	}
-	txn.Commit()
-	return nil
-}
-
-func (s *Storer) GetAW(id string) (AW, error) {
-	txn := s.db.Txn(false)
-	ap, err := txn.First("aw", "id", id)
+	err = s.recordRevision(txn, "ehscluster", scaled.ID, scaled, EventScale, false)
 	if err != nil {
-		return AW{}, err
-	}
-	if ap == nil {
-		return AW{}, ErrAWNotFound
+		return err
 	}
-	return *ap.(*AW), nil
+	txn.Commit()
+	return nil
 }
 
-func (s *Storer) CreateAW(ap AW) error {
+// FinishEHSClusterUpgrade puts the cluster back to ready once the upgrade
+// has finished, on release if it's set. If the cluster is no longer being
+// upgraded by upgradeID, it's left alone.
+func (s *Storer) FinishEHSClusterUpgrade(id, upgradeID, release string) error {
 	txn := s.db.Txn(true)
 	defer txn.Abort()
-	exists, err := txn.First("aw", "id", ap.ID)
+	existing, err := txn.First("ehscluster", "id", id)
 	if err != nil {
 		return err
 	}
-	if exists != nil {
-		return ErrAWAlreadyExists
+	if existing == nil || existing.(*EHSCluster).DeletedAt != "" {
+		return ErrEHSClusterNotFound
 	}
-	err = txn.Insert("aw", &ap)
+	finished := *existing.(*EHSCluster)
+	if finished.UpgradeID != upgradeID {
+		return nil
+	}
+	if release != "" {
+		finished.Release = release
+	}
+	finished.Status = EHSClusterReady
+	finished.TargetRelease = ""
+	finished.UpgradeID = ""
+	finished.OperationID = ""
+	err = txn.Insert("ehscluster", &finished)
+	if err != nil {
+		return err
+	}
+	err = s.recordRevision(txn, "ehscluster", finished.ID, finished, EventStatusChange, false)
 	if err != nil {
 		return err
 	}
@@ -236,40 +628,501 @@ func (s *Storer) CreateAW(ap AW) error {
 	return nil
 }
 
-func (s *Storer) UpdateAW(ap AW) error {
+// FinishEHSClusterResize moves the cluster onto profile once its resize
+// has finished, as long as it still has room, alongside its node pools, for
+// the AWs it hosts. The room is checked in the same txn as the write, so
+// AWs can't be placed on the cluster in between. If they no longer fit,
+// the cluster is put back to ready on its current profile and ErrNoRoom is
+// returned. If the cluster is no longer being resized onto profile, it
+// returns ErrEHSClusterChanged.
+func (s *Storer) FinishEHSClusterResize(id string, profile Profile) (EHSCluster, error) {
 	txn := s.db.Txn(true)
 	defer txn.Abort()
-	existing, err := txn.First("aw", "id", ap.ID)
+	existing, err := txn.First("ehscluster", "id", id)
 	if err != nil {
-		return err
+		return EHSCluster{}, err
 	}
-	if existing == nil {
-		return ErrAWNotFound
+	if existing == nil || existing.(*EHSCluster).DeletedAt != "" {
+		return EHSCluster{}, ErrEHSClusterNotFound
 	}
-	err = txn.Insert("aw", &ap)
+	finished := *existing.(*EHSCluster)
+	if finished.Status != EHSClusterResizing || finished.TargetProfile != profile.Name {
+		return EHSCluster{}, ErrEHSClusterChanged
+	}
+	finished.Status = EHSClusterReady
+	finished.TargetProfile = ""
+	finished.OperationID = ""
+	resized := finished
+	resized.Profile = profile.Name
+	hosted, room, err := clusterSeats(txn, resized, "")
 	if err != nil {
-		return err
+		return EHSCluster{}, err
+	}
+	if hosted <= room {
+		iter, err := txn.Get("nodepool", "ehscluster", id)
+		if err != nil {
+			return EHSCluster{}, err
+		}
+		var pools Capacity
+		for obj := iter.Next(); obj != nil; obj = iter.Next() {
+			pools.add(obj.(*NodePool).capacity())
+		}
+		resized.CurrentNodes = resized.resizedNodes(profile)
+		resized.DesiredNodes = resized.CurrentNodes
+		resized.Capacity = clusterCapacity(profile.Name, resized.CurrentNodes, pools)
+		finished = resized
+	}
+	err = txn.Insert("ehscluster", &finished)
+	if err != nil {
+		return EHSCluster{}, err
+	}
+	err = s.recordRevision(txn, "ehscluster", finished.ID, finished, EventStatusChange, false)
+	if err != nil {
+		return EHSCluster{}, err
 	}
 	txn.Commit()
-	return nil
+	if hosted > room {
+		return finished, ErrNoRoom
+	}
+	return finished, nil
 }
 
-func (s *Storer) DeleteAW(id string) error {
+// CancelEHSClusterResize puts the cluster back to ready on its current
+// profile. If the cluster is no longer being resized onto profileName, it's
+// left alone.
+func (s *Storer) CancelEHSClusterResize(id, profileName string) error {
 	txn := s.db.Txn(true)
 	defer txn.Abort()
-	existing, err := txn.First("aw", "id", id)
+	existing, err := txn.First("ehscluster", "id", id)
 	if err != nil {
 		return err
 	}
-	if existing == nil {
-		return ErrAWNotFound
+	if existing == nil || existing.(*EHSCluster).DeletedAt != "" {
+		return ErrEHSClusterNotFound
 	}
-	err = txn.Delete("aw", existing)
+	cancelled := *existing.(*EHSCluster)
+	if cancelled.Status != EHSClusterResizing || cancelled.TargetProfile != profileName {
+		return nil
+	}
+	cancelled.Status = EHSClusterReady
+	cancelled.TargetProfile = ""
+	cancelled.OperationID = ""
+	err = txn.Insert("ehscluster", &cancelled)
+	if err != nil {
+		return err
+	}
+	err = s.recordRevision(txn, "ehscluster", cancelled.ID, cancelled, EventStatusChange, false)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+func (s *Storer) DeleteEHSCluster(id string) (EHSCluster, error) {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("ehscluster", "id", id)
+	if err != nil {
+		return EHSCluster{}, err
+	}
+	if existing == nil {
+		return EHSCluster{}, ErrEHSClusterNotFound
+	}
+	err = txn.Delete("ehscluster", existing)
+	if err != nil {
+		return EHSCluster{}, err
+	}
+	// Its node pools go with it.
+	pools, err := txn.Get("nodepool", "ehscluster", id)
+	if err != nil {
+		return EHSCluster{}, err
+	}
+	var deleted []interface{}
+	for pool := pools.Next(); pool != nil; pool = pools.Next() {
+		deleted = append(deleted, pool)
+	}
+	for _, pool := range deleted {
+		err = txn.Delete("nodepool", pool)
+		if err != nil {
+			return EHSCluster{}, err
+		}
+		err = s.recordRevision(txn, "nodepool", pool.(*NodePool).ID, pool, EventDelete, true)
+		if err != nil {
+			return EHSCluster{}, err
+		}
+	}
+	err = s.recordRevision(txn, "ehscluster", existing.(*EHSCluster).ID, existing, EventDelete, true)
+	if err != nil {
+		return EHSCluster{}, err
+	}
+	txn.Commit()
+	return *existing.(*EHSCluster), nil
+}
+
+// SoftDeleteEHSCluster marks the EHSCluster as deleted at the given time. It's hidden
+// from GetEHSCluster until it's restored or purged.
+func (s *Storer) SoftDeleteEHSCluster(id string, at time.Time) (EHSCluster, error) {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("ehscluster", "id", id)
+	if err != nil {
+		return EHSCluster{}, err
+	}
+	if existing == nil || existing.(*EHSCluster).DeletedAt != "" {
+		return EHSCluster{}, ErrEHSClusterNotFound
+	}
+	ap := *existing.(*EHSCluster)
+	ap.DeletedAt = at.UTC().Format(time.RFC3339)
+	err = txn.Insert("ehscluster", &ap)
+	if err != nil {
+		return EHSCluster{}, err
+	}
+	err = s.recordRevision(txn, "ehscluster", ap.ID, ap, EventDelete, true)
+	if err != nil {
+		return EHSCluster{}, err
+	}
+	txn.Commit()
+	return ap, nil
+}
+
+func (s *Storer) RestoreEHSCluster(id string) (EHSCluster, error) {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("ehscluster", "id", id)
+	if err != nil {
+		return EHSCluster{}, err
+	}
+	if existing == nil {
+		return EHSCluster{}, ErrEHSClusterNotFound
+	}
+	if existing.(*EHSCluster).DeletedAt == "" {
+		return EHSCluster{}, ErrEHSClusterNotDeleted
+	}
+	ap := *existing.(*EHSCluster)
+	ap.DeletedAt = ""
+	checkQuota, err := s.quotaGuard(txn, ap.AccountID)
+	if err != nil {
+		return EHSCluster{}, err
+	}
+	err = txn.Insert("ehscluster", &ap)
+	if err != nil {
+		return EHSCluster{}, err
+	}
+	err = checkQuota()
+	if err != nil {
+		return EHSCluster{}, err
+	}
+	err = s.recordRevision(txn, "ehscluster", ap.ID, ap, EventStatusChange, false)
+	if err != nil {
+		return EHSCluster{}, err
+	}
+	txn.Commit()
+	return ap, nil
+}
+
+func (s *Storer) GetAW(id string) (AW, error) {
+	txn := s.db.Txn(false)
+	ap, err := txn.First("aw", "id", id)
+	if err != nil {
+		return AW{}, err
+	}
+	if ap == nil || ap.(*AW).DeletedAt != "" {
+		return AW{}, ErrAWNotFound
+	}
+	return *ap.(*AW), nil
+}
+
+// ListAWs returns every AW whose labels match selector.
+func (s *Storer) ListAWs(selector Selector) ([]AW, error) {
+	txn := s.db.Txn(false)
+	iter, err := selector.iterate(txn, "aw")
+	if err != nil {
+		return nil, err
+	}
+	var results []AW
+	for obj := iter.Next(); obj != nil; obj = iter.Next() {
+		if selector.Matches(obj.(*AW).Labels) {
+			results = append(results, *obj.(*AW))
+		}
+	}
+	return results, nil
+}
+
+func (s *Storer) CreateAW(ap AW) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	exists, err := txn.First("aw", "id", ap.ID)
+	if err != nil {
+		return err
+	}
+	if exists != nil {
+		return ErrAWAlreadyExists
+	}
+	account, err := awAccount(txn, ap.EHSClusterID)
+	if err != nil {
+		return err
+	}
+	checkQuota, err := s.quotaGuard(txn, account)
+	if err != nil {
+		return err
+	}
+	checkRoom, err := roomGuard(txn, ap.EHSClusterID)
+	if err != nil {
+		return err
+	}
+	err = txn.Insert("aw", &ap)
+	if err != nil {
+		return err
+	}
+	err = checkQuota()
+	if err != nil {
+		return err
+	}
+	err = checkRoom()
+	if err != nil {
+		return err
+	}
+	err = s.recordRevision(txn, "aw", ap.ID, ap, EventCreate, false)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+func (s *Storer) UpdateAW(ap AW) error {
+	return s.updateAW(ap, nil)
+}
+
+// SwapAW replaces the AW with ap, as long as its status, migration and EHS
+// cluster are still those of from, the AW as it was read. Otherwise it
+// returns ErrAWChanged.
+func (s *Storer) SwapAW(from, ap AW) error {
+	return s.updateAW(ap, &from)
+}
+
+func (s *Storer) updateAW(ap AW, from *AW) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("aw", "id", ap.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil || existing.(*AW).DeletedAt != "" {
+		return ErrAWNotFound
+	}
+	if from != nil {
+		stored := existing.(*AW)
+		if stored.Status != from.Status || stored.MigrationID != from.MigrationID || stored.EHSClusterID != from.EHSClusterID {
+			return ErrAWChanged
+		}
+	}
+	account, err := awAccount(txn, ap.EHSClusterID)
+	if err != nil {
+		return err
+	}
+	checkQuota, err := s.quotaGuard(txn, account)
+	if err != nil {
+		return err
+	}
+	checkRoom, err := roomGuard(txn, ap.EHSClusterID, ap.TargetEHSClusterID)
+	if err != nil {
+		return err
+	}
+	err = txn.Insert("aw", &ap)
+	if err != nil {
+		return err
+	}
+	err = checkQuota()
+	if err != nil {
+		return err
+	}
+	err = checkRoom()
+	if err != nil {
+		return err
+	}
+	err = s.recordRevision(txn, "aw", ap.ID, ap, updateEvent(existing.(*AW).Status, ap.Status), false)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// MoveAW switches the AW being migrated by migrationID over to the EHS
+// cluster with the ID clusterID, served on dnsEndPoint. It returns
+// ErrAWChanged if the AW is no longer being migrated by migrationID.
+func (s *Storer) MoveAW(id, migrationID, clusterID, dnsEndPoint string) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("aw", "id", id)
+	if err != nil {
+		return err
+	}
+	if existing == nil || existing.(*AW).DeletedAt != "" {
+		return ErrAWNotFound
+	}
+	moved := *existing.(*AW)
+	if moved.MigrationID != migrationID {
+		return ErrAWChanged
+	}
+	cluster, err := txn.First("ehscluster", "id", clusterID)
+	if err != nil {
+		return err
+	}
+	if cluster != nil {
+		moved.Region = cluster.(*EHSCluster).Region
+	}
+	moved.EHSClusterID = clusterID
+	moved.DNSEndPoint = dnsEndPoint
+	account, err := awAccount(txn, clusterID)
+	if err != nil {
+		return err
+	}
+	checkQuota, err := s.quotaGuard(txn, account)
+	if err != nil {
+		return err
+	}
+	err = txn.Insert("aw", &moved)
+	if err != nil {
+		return err
+	}
+	err = checkQuota()
+	if err != nil {
+		return err
+	}
+	err = s.recordRevision(txn, "aw", moved.ID, moved, EventUpdate, false)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// FinishAWMigration puts the AW back to ready once the migration with the
+// ID migrationID has finished. If the AW is no longer being migrated by it,
+// it's left alone.
+func (s *Storer) FinishAWMigration(id, migrationID string) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("aw", "id", id)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrAWNotFound
+	}
+	finished := *existing.(*AW)
+	if finished.MigrationID != migrationID {
+		return nil
+	}
+	finished.Status = AWReady
+	finished.TargetEHSClusterID = ""
+	finished.MigrationID = ""
+	finished.OperationID = ""
+	err = txn.Insert("aw", &finished)
+	if err != nil {
+		return err
+	}
+	err = s.recordRevision(txn, "aw", finished.ID, finished, EventStatusChange, false)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+func (s *Storer) DeleteAW(id string) (AW, error) {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("aw", "id", id)
+	if err != nil {
+		return AW{}, err
+	}
+	if existing == nil {
+		return AW{}, ErrAWNotFound
+	}
+	err = txn.Delete("aw", existing)
+	if err != nil {
+		return AW{}, err
+	}
+	// Its schedule goes with it.
+	err = s.deleteAWSchedules(txn, existing.(*AW).ID)
+	if err != nil {
+		return AW{}, err
+	}
+	err = s.recordRevision(txn, "aw", existing.(*AW).ID, existing, EventDelete, true)
+	if err != nil {
+		return AW{}, err
+	}
+	txn.Commit()
+	return *existing.(*AW), nil
+}
+
+// SoftDeleteAW marks the AW as deleted at the given time. It's hidden
+// from GetAW until it's restored or purged.
+func (s *Storer) SoftDeleteAW(id string, at time.Time) (AW, error) {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("aw", "id", id)
+	if err != nil {
+		return AW{}, err
+	}
+	if existing == nil || existing.(*AW).DeletedAt != "" {
+		return AW{}, ErrAWNotFound
+	}
+	ap := *existing.(*AW)
+	ap.DeletedAt = at.UTC().Format(time.RFC3339)
+	err = txn.Insert("aw", &ap)
+	if err != nil {
+		return AW{}, err
+	}
+	err = s.recordRevision(txn, "aw", ap.ID, ap, EventDelete, true)
+	if err != nil {
+		return AW{}, err
+	}
+	txn.Commit()
+	return ap, nil
+}
+
+func (s *Storer) RestoreAW(id string) (AW, error) {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("aw", "id", id)
+	if err != nil {
+		return AW{}, err
+	}
+	if existing == nil {
+		return AW{}, ErrAWNotFound
+	}
+	if existing.(*AW).DeletedAt == "" {
+		return AW{}, ErrAWNotDeleted
+	}
+	ap := *existing.(*AW)
+	ap.DeletedAt = ""
+	account, err := awAccount(txn, ap.EHSClusterID)
+	if err != nil {
+		return AW{}, err
+	}
+	checkQuota, err := s.quotaGuard(txn, account)
 	if err != nil {
-		return err
+		return AW{}, err
+	}
+	err = txn.Insert("aw", &ap)
+	if err != nil {
+		return AW{}, err
+	}
+	err = checkQuota()
+	if err != nil {
+		return AW{}, err
+	}
+	err = s.recordRevision(txn, "aw", ap.ID, ap, EventStatusChange, false)
+	if err != nil {
+		return AW{}, err
 	}
 	txn.Commit()
-	return nil
+	return ap, nil
 }
 
 func (s *Storer) GetAV(id string) (AV, error) {
@@ -278,12 +1131,28 @@ func (s *Storer) GetAV(id string) (AV, error) {
 	if err != nil {
 		return AV{}, err
 	}
-	if ap == nil {
-		return AV{}, ErrAWNotFound
+	if ap == nil || ap.(*AV).DeletedAt != "" {
+		return AV{}, ErrAVNotFound
 	}
 	return *ap.(*AV), nil
 }
 
+// ListAVs returns every AV whose labels match selector.
+func (s *Storer) ListAVs(selector Selector) ([]AV, error) {
+	txn := s.db.Txn(false)
+	iter, err := selector.iterate(txn, "av")
+	if err != nil {
+		return nil, err
+	}
+	var results []AV
+	for obj := iter.Next(); obj != nil; obj = iter.Next() {
+		if selector.Matches(obj.(*AV).Labels) {
+			results = append(results, *obj.(*AV))
+		}
+	}
+	return results, nil
+}
+
 func (s *Storer) CreateAV(ap AV) error {
 	txn := s.db.Txn(true)
 	defer txn.Abort()
@@ -292,12 +1161,24 @@ func (s *Storer) CreateAV(ap AV) error {
 		return err
 	}
 	if exists != nil {
-		return ErrAWAlreadyExists
+		return ErrAVAlreadyExists
+	}
+	checkQuota, err := s.quotaGuard(txn, ap.AccountID)
+	if err != nil {
+		return err
 	}
 	err = txn.Insert("av", &ap)
 	if err != nil {
 		return err
 	}
+	err = checkQuota()
+	if err != nil {
+		return err
+	}
+	err = s.recordRevision(txn, "av", ap.ID, ap, EventCreate, false)
+	if err != nil {
+		return err
+	}
 	txn.Commit()
 	return nil
 }
@@ -309,31 +1190,584 @@ func (s *Storer) UpdateAV(ap AV) error {
 	if err != nil {
 		return err
 	}
-	if existing == nil {
-		return ErrAWNotFound
+	if existing == nil || existing.(*AV).DeletedAt != "" {
+		return ErrAVNotFound
+	}
+	checkQuota, err := s.quotaGuard(txn, ap.AccountID)
+	if err != nil {
+		return err
 	}
 	err = txn.Insert("av", &ap)
 	if err != nil {
 		return err
 	}
+	err = checkQuota()
+	if err != nil {
+		return err
+	}
+	err = s.recordRevision(txn, "av", ap.ID, ap, EventUpdate, false)
+	if err != nil {
+		return err
+	}
 	txn.Commit()
 	return nil
 }
 
-func (s *Storer) DeleteAV(id string) error {
+func (s *Storer) DeleteAV(id string) (AV, error) {
 	txn := s.db.Txn(true)
 	defer txn.Abort()
 	existing, err := txn.First("av", "id", id)
 	if err != nil {
-		return err
+		return AV{}, err
 	}
 	if existing == nil {
-		return ErrAWNotFound
+		return AV{}, ErrAVNotFound
 	}
 	err = txn.Delete("av", existing)
+	if err != nil {
+		return AV{}, err
+	}
+	err = s.recordRevision(txn, "av", existing.(*AV).ID, existing, EventDelete, true)
+	if err != nil {
+		return AV{}, err
+	}
+	txn.Commit()
+	return *existing.(*AV), nil
+}
+
+// SoftDeleteAV marks the AV as deleted at the given time. It's hidden
+// from GetAV until it's restored or purged.
+func (s *Storer) SoftDeleteAV(id string, at time.Time) (AV, error) {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("av", "id", id)
+	if err != nil {
+		return AV{}, err
+	}
+	if existing == nil || existing.(*AV).DeletedAt != "" {
+		return AV{}, ErrAVNotFound
+	}
+	ap := *existing.(*AV)
+	ap.DeletedAt = at.UTC().Format(time.RFC3339)
+	err = txn.Insert("av", &ap)
+	if err != nil {
+		return AV{}, err
+	}
+	err = s.recordRevision(txn, "av", ap.ID, ap, EventDelete, true)
+	if err != nil {
+		return AV{}, err
+	}
+	txn.Commit()
+	return ap, nil
+}
+
+func (s *Storer) RestoreAV(id string) (AV, error) {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("av", "id", id)
+	if err != nil {
+		return AV{}, err
+	}
+	if existing == nil {
+		return AV{}, ErrAVNotFound
+	}
+	if existing.(*AV).DeletedAt == "" {
+		return AV{}, ErrAVNotDeleted
+	}
+	ap := *existing.(*AV)
+	ap.DeletedAt = ""
+	checkQuota, err := s.quotaGuard(txn, ap.AccountID)
+	if err != nil {
+		return AV{}, err
+	}
+	err = txn.Insert("av", &ap)
+	if err != nil {
+		return AV{}, err
+	}
+	err = checkQuota()
+	if err != nil {
+		return AV{}, err
+	}
+	err = s.recordRevision(txn, "av", ap.ID, ap, EventStatusChange, false)
+	if err != nil {
+		return AV{}, err
+	}
+	txn.Commit()
+	return ap, nil
+}
+
+func (s *Storer) GetWebhook(id string) (Webhook, error) {
+	txn := s.db.Txn(false)
+	wh, err := txn.First("webhook", "id", id)
+	if err != nil {
+		return Webhook{}, err
+	}
+	if wh == nil {
+		return Webhook{}, ErrWebhookNotFound
+	}
+	return *wh.(*Webhook), nil
+}
+
+func (s *Storer) ListWebhooks() ([]Webhook, error) {
+	txn := s.db.Txn(false)
+	iter, err := txn.Get("webhook", "id")
+	if err != nil {
+		return nil, err
+	}
+	var results []Webhook
+	for obj := iter.Next(); obj != nil; obj = iter.Next() {
+		results = append(results, *obj.(*Webhook))
+	}
+	return results, nil
+}
+
+func (s *Storer) CreateWebhook(wh Webhook) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	exists, err := txn.First("webhook", "id", wh.ID)
+	if err != nil {
+		return err
+	}
+	if exists != nil {
+		return ErrWebhookAlreadyExists
+	}
+	err = txn.Insert("webhook", &wh)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+func (s *Storer) UpdateWebhook(wh Webhook) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("webhook", "id", wh.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrWebhookNotFound
+	}
+	err = txn.Insert("webhook", &wh)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+func (s *Storer) DeleteWebhook(id string) (Webhook, error) {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	existing, err := txn.First("webhook", "id", id)
+	if err != nil {
+		return Webhook{}, err
+	}
+	if existing == nil {
+		return Webhook{}, ErrWebhookNotFound
+	}
+	err = txn.Delete("webhook", existing)
+	if err != nil {
+		return Webhook{}, err
+	}
+	_, err = txn.DeleteAll("delivery", "webhook", id)
+	if err != nil {
+		return Webhook{}, err
+	}
+	txn.Commit()
+	return *existing.(*Webhook), nil
+}
+
+// PutDelivery creates or updates a Delivery.
+func (s *Storer) PutDelivery(d Delivery) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	err := txn.Insert("delivery", &d)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// AddDelivery stores a new delivery, keeping at most max deliveries for its
+// webhook by deleting the oldest finished ones. If the webhook already has
+// max deliveries pending, it returns ErrTooManyDeliveries instead.
+func (s *Storer) AddDelivery(d Delivery, max int) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	iter, err := txn.Get("delivery", "webhook", d.WebhookID)
+	if err != nil {
+		return err
+	}
+	var pending int
+	var finished []*Delivery
+	for obj := iter.Next(); obj != nil; obj = iter.Next() {
+		existing := obj.(*Delivery)
+		if existing.Status == DeliveryPending {
+			pending++
+		} else {
+			finished = append(finished, existing)
+		}
+	}
+	if pending >= max {
+		return ErrTooManyDeliveries
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].CreatedAt.Before(finished[j].CreatedAt)
+	})
+	for len(finished) > 0 && pending+len(finished) >= max {
+		err = txn.Delete("delivery", finished[0])
+		if err != nil {
+			return err
+		}
+		finished = finished[1:]
+	}
+	err = txn.Insert("delivery", &d)
 	if err != nil {
 		return err
 	}
 	txn.Commit()
 	return nil
 }
+
+func (s *Storer) ListDeliveries(webhookID string) ([]Delivery, error) {
+	txn := s.db.Txn(false)
+	iter, err := txn.Get("delivery", "webhook", webhookID)
+	if err != nil {
+		return nil, err
+	}
+	var results []Delivery
+	for obj := iter.Next(); obj != nil; obj = iter.Next() {
+		results = append(results, *obj.(*Delivery))
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].CreatedAt.Before(results[j].CreatedAt)
+	})
+	return results, nil
+}
+
+// Purge permanently removes every resource that was soft deleted before the
+// given time, returning how many were removed.
+func (s *Storer) Purge(before time.Time) (int, error) {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	var expired []interface{}
+	var tables []string
+	for _, table := range []string{"eastore", "ehscluster", "aw", "av"} {
+		iter, err := txn.Get(table, "id")
+		if err != nil {
+			return 0, err
+		}
+		for obj := iter.Next(); obj != nil; obj = iter.Next() {
+			deletedAt := deletedAt(obj)
+			if deletedAt == "" {
+				continue
+			}
+			t, err := time.Parse(time.RFC3339, deletedAt)
+			if err != nil || t.Before(before) {
+				expired = append(expired, obj)
+				tables = append(tables, table)
+			}
+		}
+	}
+	for i, obj := range expired {
+		err := txn.Delete(tables[i], obj)
+		if err != nil {
+			return 0, err
+		}
+		if aw, ok := obj.(*AW); ok {
+			err = s.deleteAWSchedules(txn, aw.ID)
+			if err != nil {
+				return 0, err
+			}
+		}
+		err = s.purgeRevisions(txn, tables[i], resourceID(obj))
+		if err != nil {
+			return 0, err
+		}
+	}
+	txn.Commit()
+	return len(expired), nil
+}
+
+// purgeRevisions drops the resource's history, as part of txn, leaving a
+// purge tombstone revision without the object in its place, so a purged
+// resource can't be read back from its revisions.
+func (s *Storer) purgeRevisions(txn *memdb.Txn, resourceType, id string) error {
+	iter, err := txn.Get("revision", "resource", resourceType, id)
+	if err != nil {
+		return err
+	}
+	var revs []interface{}
+	for obj := iter.Next(); obj != nil; obj = iter.Next() {
+		revs = append(revs, obj)
+	}
+	for _, rev := range revs {
+		err = txn.Delete("revision", rev)
+		if err != nil {
+			return err
+		}
+	}
+	// The tombstone is numbered after the revisions it replaces, so
+	// revision numbers are never reused.
+	return s.recordRevision(txn, resourceType, id, nil, EventPurge, true)
+}
+
+func resourceID(obj interface{}) string {
+	switch o := obj.(type) {
+	case *EAStore:
+		return o.ID
+	case *EHSCluster:
+		return o.ID
+	case *AW:
+		return o.ID
+	case *AV:
+		return o.ID
+	}
+	return ""
+}
+
+func deletedAt(obj interface{}) string {
+	switch o := obj.(type) {
+	case *EAStore:
+		return o.DeletedAt
+	case *EHSCluster:
+		return o.DeletedAt
+	case *AW:
+		return o.DeletedAt
+	case *AV:
+		return o.DeletedAt
+	}
+	return ""
+}
+
+// recordRevision stores obj as the next revision of the resource, as part of
+// txn. If obj is nil, the revision has no object.
+func (s *Storer) recordRevision(txn *memdb.Txn, resourceType, id string, obj interface{}, event string, deleted bool) error {
+	var b []byte
+	if obj != nil {
+		var err error
+		b, err = json.Marshal(obj)
+		if err != nil {
+			return err
+		}
+	}
+	number := 1
+	count, err := txn.First("revisioncount", "id", resourceType, id)
+	if err != nil {
+		return err
+	}
+	if count != nil {
+		number = count.(*revisionCount).Count + 1
+	}
+	err = txn.Insert("revisioncount", &revisionCount{
+		ResourceType: resourceType,
+		ResourceID:   id,
+		Count:        number,
+	})
+	if err != nil {
+		return err
+	}
+	return txn.Insert("revision", &Revision{
+		Index:        s.nextIndex(txn),
+		Event:        event,
+		ResourceType: resourceType,
+		ResourceID:   id,
+		Number:       number,
+		Time:         time.Now().UTC(),
+		Deleted:      deleted,
+		Object:       b,
+	})
+}
+
+// revisionCount is how many revisions a resource has had, so the next one
+// can be numbered without counting them, even once earlier ones have been
+// pruned.
+type revisionCount struct {
+	ResourceType string
+	ResourceID   string
+	Count        int
+}
+
+// PruneRevisions deletes revisions recorded before the given time,
+// returning how many were deleted. A resource's latest revision is kept
+// for as long as the resource exists, so its history is never empty.
+func (s *Storer) PruneRevisions(before time.Time) (int, error) {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	iter, err := txn.Get("revision", "index")
+	if err != nil {
+		return 0, err
+	}
+	var expired []*Revision
+	for obj := iter.Next(); obj != nil; obj = iter.Next() {
+		rev := obj.(*Revision)
+		// Revisions are indexed in the order they were recorded.
+		if !rev.Time.Before(before) {
+			break
+		}
+		expired = append(expired, rev)
+	}
+	var pruned int
+	for _, rev := range expired {
+		count, err := txn.First("revisioncount", "id", rev.ResourceType, rev.ResourceID)
+		if err != nil {
+			return 0, err
+		}
+		if count != nil && count.(*revisionCount).Count == rev.Number {
+			resource, err := txn.First(rev.ResourceType, "id", rev.ResourceID)
+			if err != nil {
+				return 0, err
+			}
+			if resource != nil {
+				continue
+			}
+			err = txn.Delete("revisioncount", count)
+			if err != nil {
+				return 0, err
+			}
+		}
+		err = txn.Delete("revision", rev)
+		if err != nil {
+			return 0, err
+		}
+		pruned++
+	}
+	txn.Commit()
+	return pruned, nil
+}
+
+func (s *Storer) ListRevisions(resourceType, id string) ([]Revision, error) {
+	txn := s.db.Txn(false)
+	iter, err := txn.Get("revision", "resource", resourceType, id)
+	if err != nil {
+		return nil, err
+	}
+	var results []Revision
+	for obj := iter.Next(); obj != nil; obj = iter.Next() {
+		results = append(results, *obj.(*Revision))
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Number < results[j].Number
+	})
+	return results, nil
+}
+
+// nextIndex reserves the next index for a change made in txn, publishing
+// it as the Storer's index once txn commits.
+func (s *Storer) nextIndex(txn *memdb.Txn) uint64 {
+	index := atomic.AddUint64(&s.next, 1)
+	txn.Defer(func() {
+		for {
+			current := atomic.LoadUint64(&s.index)
+			if current >= index || atomic.CompareAndSwapUint64(&s.index, current, index) {
+				return
+			}
+		}
+	})
+	return index
+}
+
+// updateEvent is the event for replacing a resource with status existing
+// by one with status updated.
+func updateEvent(existing, updated string) string {
+	if existing != updated {
+		return EventStatusChange
+	}
+	return EventUpdate
+}
+
+// Index returns the index of the most recent change to the Storer.
+func (s *Storer) Index() uint64 {
+	return atomic.LoadUint64(&s.index)
+}
+
+// WaitForChange blocks until the resource has a revision after index, the
+// timeout elapses, or ctx is done.
+func (s *Storer) WaitForChange(ctx context.Context, resourceType, id string, index uint64, timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		txn := s.db.Txn(false)
+		iter, err := txn.Get("revision", "resource", resourceType, id)
+		if err != nil {
+			return err
+		}
+		for obj := iter.Next(); obj != nil; obj = iter.Next() {
+			if obj.(*Revision).Index > index {
+				return nil
+			}
+		}
+		ws := memdb.NewWatchSet()
+		ws.Add(iter.WatchCh())
+		ws.Add(ctx.Done())
+		if ws.Watch(timer.C) {
+			return ErrWaitTimeout
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// waitForRow blocks until the row in table with the given id has changed
+// since index, as reported by rowIndex, the timeout elapses, or ctx is done.
+// A missing row counts as changed.
+func (s *Storer) waitForRow(ctx context.Context, table, id string, index uint64, timeout time.Duration, rowIndex func(obj interface{}) uint64) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		txn := s.db.Txn(false)
+		ch, obj, err := txn.FirstWatch(table, "id", id)
+		if err != nil {
+			return err
+		}
+		if obj == nil || rowIndex(obj) > index {
+			return nil
+		}
+		ws := memdb.NewWatchSet()
+		ws.Add(ch)
+		ws.Add(ctx.Done())
+		if ws.Watch(timer.C) {
+			return ErrWaitTimeout
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// RevisionsSince returns every revision recorded after index, in order, along
+// with a channel that's closed when another revision is recorded.
+func (s *Storer) RevisionsSince(index uint64) ([]Revision, <-chan struct{}, error) {
+	txn := s.db.Txn(false)
+	// LowerBound iterators can't be watched, so watch the whole index,
+	// which changes whenever a revision is recorded.
+	watch, err := txn.Get("revision", "index")
+	if err != nil {
+		return nil, nil, err
+	}
+	iter, err := txn.LowerBound("revision", "index", index+1)
+	if err != nil {
+		return nil, nil, err
+	}
+	var results []Revision
+	for obj := iter.Next(); obj != nil; obj = iter.Next() {
+		results = append(results, *obj.(*Revision))
+	}
+	return results, watch.WatchCh(), nil
+}
+
+func (s *Storer) GetRevision(resourceType, id string, number int) (Revision, error) {
+	txn := s.db.Txn(false)
+	rev, err := txn.First("revision", "id", resourceType, id, number)
+	if err != nil {
+		return Revision{}, err
+	}
+	if rev == nil {
+		return Revision{}, ErrRevisionNotFound
+	}
+	return *rev.(*Revision), nil
+}
@@ -0,0 +1,559 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"darlinggo.co/api"
+	"darlinggo.co/trout/v2"
+	"github.com/hashicorp/go-uuid"
+)
+
+const (
+	EHSClusterReady     = "ready"
+	EHSClusterUpgrading = "upgrading"
+	EHSClusterResizing  = "resizing"
+)
+
+const (
+	UpgradeRunning     = "running"
+	UpgradePaused      = "paused"
+	UpgradeRollingBack = "rolling_back"
+	UpgradeSucceeded   = "succeeded"
+	UpgradeRolledBack  = "rolled_back"
+	UpgradeFailed      = "failed"
+)
+
+const (
+	NodePending     = "pending"
+	NodeUpgrading   = "upgrading"
+	NodeUpgraded    = "upgraded"
+	NodeRollingBack = "rolling_back"
+	NodeRolledBack  = "rolled_back"
+)
+
+var (
+	ErrUpgradeNotFound     = errors.New("upgrade not found")
+	ErrUpgradeInvalidState = errors.New("upgrade can't do that in its current state")
+)
+
+// Upgrade is a rolling upgrade of an EHS cluster from one release to
+// another, one node at a time. OperationID is the operation clients can
+// wait on for it to finish.
+type Upgrade struct {
+	ID           string        `json:"id"`
+	EHSClusterID string        `json:"ehs_cluster_id"`
+	FromRelease  string        `json:"from_release"`
+	ToRelease    string        `json:"to_release"`
+	Status       string        `json:"status"`
+	Nodes        []UpgradeNode `json:"nodes"`
+	OperationID  string        `json:"operation_id,omitempty"`
+	StartedAt    string        `json:"started_at"`
+	FinishedAt   string        `json:"finished_at,omitempty"`
+
+	index uint64
+}
+
+// UpgradeNode is the state of one of the cluster's nodes during an Upgrade.
+type UpgradeNode struct {
+	Name    string `json:"name"`
+	Release string `json:"release"`
+	Status  string `json:"status"`
+}
+
+// Done reports whether the upgrade has finished, one way or another.
+func (u Upgrade) Done() bool {
+	switch u.Status {
+	case UpgradeSucceeded, UpgradeRolledBack, UpgradeFailed:
+		return true
+	}
+	return false
+}
+
+// progress is how far along the upgrade is, out of 100.
+func (u Upgrade) progress() int {
+	var upgraded int
+	for _, node := range u.Nodes {
+		if node.Status == NodeUpgraded {
+			upgraded++
+		}
+	}
+	return upgraded * 100 / len(u.Nodes)
+}
+
+// step moves the upgrade on by a node: in a running upgrade the node being
+// upgraded finishes and the next one starts, and in one being rolled back
+// the node being reverted finishes and the previous upgraded one starts. It
+// reports whether the upgrade is done.
+func (u *Upgrade) step() bool {
+	switch u.Status {
+	case UpgradeRunning:
+		for i := range u.Nodes {
+			if u.Nodes[i].Status != NodeUpgrading {
+				continue
+			}
+			u.Nodes[i].Status = NodeUpgraded
+			u.Nodes[i].Release = u.ToRelease
+			if i+1 < len(u.Nodes) {
+				u.Nodes[i+1].Status = NodeUpgrading
+				return false
+			}
+		}
+		u.Status = UpgradeSucceeded
+		return true
+	case UpgradeRollingBack:
+		for i := len(u.Nodes) - 1; i >= 0; i-- {
+			if u.Nodes[i].Status != NodeRollingBack {
+				continue
+			}
+			u.Nodes[i].Status = NodeRolledBack
+			u.Nodes[i].Release = u.FromRelease
+			if i > 0 && u.Nodes[i-1].Status == NodeUpgraded {
+				u.Nodes[i-1].Status = NodeRollingBack
+				return false
+			}
+		}
+		u.Status = UpgradeRolledBack
+		return true
+	}
+	return false
+}
+
+func (u *Upgrade) pause() error {
+	if u.Status != UpgradeRunning {
+		return ErrUpgradeInvalidState
+	}
+	u.Status = UpgradePaused
+	for i := range u.Nodes {
+		if u.Nodes[i].Status == NodeUpgrading {
+			u.Nodes[i].Status = NodePending
+		}
+	}
+	return nil
+}
+
+func (u *Upgrade) resume() error {
+	if u.Status != UpgradePaused {
+		return ErrUpgradeInvalidState
+	}
+	u.Status = UpgradeRunning
+	for i := range u.Nodes {
+		if u.Nodes[i].Status == NodePending {
+			u.Nodes[i].Status = NodeUpgrading
+			break
+		}
+	}
+	return nil
+}
+
+// rollback starts reverting the nodes that have been upgraded, newest
+// first. It reports whether there was nothing to revert, so the upgrade is
+// already rolled back.
+func (u *Upgrade) rollback() (bool, error) {
+	if u.Status != UpgradeRunning && u.Status != UpgradePaused {
+		return false, ErrUpgradeInvalidState
+	}
+	u.Status = UpgradeRollingBack
+	for i := len(u.Nodes) - 1; i >= 0; i-- {
+		if u.Nodes[i].Status == NodeUpgrading {
+			u.Nodes[i].Status = NodePending
+		}
+		if u.Nodes[i].Status == NodeUpgraded {
+			u.Nodes[i].Status = NodeRollingBack
+			return false, nil
+		}
+	}
+	u.Status = UpgradeRolledBack
+	return true, nil
+}
+
+// UpgradesConfig sets how long simulated upgrades take to upgrade, or roll
+// back, each node.
+type UpgradesConfig struct {
+	NodeDuration time.Duration `json:"node_duration" yaml:"node_duration"`
+}
+
+// Upgrades runs rolling release upgrades of EHS clusters in the background,
+// tracking each as an operation.
+type Upgrades struct {
+	storer     *Storer
+	operations *Operations
+
+	mu     sync.Mutex
+	config UpgradesConfig
+	wakes  map[string]chan struct{}
+}
+
+func NewUpgrades(storer *Storer, operations *Operations, config UpgradesConfig) *Upgrades {
+	return &Upgrades{
+		storer:     storer,
+		operations: operations,
+		config:     config,
+		wakes:      map[string]chan struct{}{},
+	}
+}
+
+// SetConfig replaces the durations used for upgrades from their next node
+// on.
+func (u *Upgrades) SetConfig(config UpgradesConfig) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.config = config
+}
+
+// Start records an upgrade of cluster to the release to, returning the
+// cluster as it should be stored while the upgrade runs. The upgrade doesn't
+// run until Begin is called, once the cluster has been stored, or Abandon if
+// it couldn't be.
+func (u *Upgrades) Start(cluster EHSCluster, to string) (EHSCluster, Upgrade, error) {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return EHSCluster{}, Upgrade{}, err
+	}
+	up := Upgrade{
+		ID:           id,
+		EHSClusterID: cluster.ID,
+		FromRelease:  cluster.Release,
+		ToRelease:    to,
+		Status:       UpgradeRunning,
+		StartedAt:    time.Now().UTC().Format(time.RFC3339),
+	}
+	for i := 0; i < cluster.nodeCount(); i++ {
+		up.Nodes = append(up.Nodes, UpgradeNode{Name: fmt.Sprintf("node-%d", i+1), Release: cluster.Release, Status: NodePending})
+	}
+	up.Nodes[0].Status = NodeUpgrading
+	op, err := u.operations.Track(Operation{
+		Kind:         "ehscluster.upgrade",
+		ResourceType: "ehscluster",
+		ResourceID:   cluster.ID,
+	})
+	if err != nil {
+		return EHSCluster{}, Upgrade{}, err
+	}
+	up.OperationID = op.ID
+	err = u.storer.PutUpgrade(up)
+	if err != nil {
+		u.operations.Finish(op.ID, OperationFailed)
+		return EHSCluster{}, Upgrade{}, err
+	}
+	cluster.Status = EHSClusterUpgrading
+	cluster.TargetRelease = to
+	cluster.UpgradeID = up.ID
+	cluster.OperationID = op.ID
+	return cluster, up, nil
+}
+
+// Begin runs an upgrade recorded by Start.
+func (u *Upgrades) Begin(id string) {
+	wake := make(chan struct{}, 1)
+	u.mu.Lock()
+	u.wakes[id] = wake
+	u.mu.Unlock()
+	go u.run(id, wake)
+}
+
+// Abandon fails an upgrade recorded by Start whose cluster couldn't be
+// stored.
+func (u *Upgrades) Abandon(id string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	up, err := u.storer.GetUpgrade(id)
+	if err != nil {
+		return
+	}
+	up.Status = UpgradeFailed
+	up.FinishedAt = time.Now().UTC().Format(time.RFC3339)
+	u.storer.PutUpgrade(up) //nolint:errcheck
+	u.operations.Finish(up.OperationID, OperationFailed)
+}
+
+func (u *Upgrades) nodeDuration() time.Duration {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.config.NodeDuration
+}
+
+// run steps the upgrade on a node every NodeDuration until it's done. A
+// wake restarts the wait for the current node, after a pause or rollback.
+func (u *Upgrades) run(id string, wake chan struct{}) {
+	timer := time.NewTimer(u.nodeDuration())
+	defer timer.Stop()
+	for {
+		select {
+		case <-wake:
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(u.nodeDuration())
+			continue
+		case <-timer.C:
+		}
+		if u.advance(id) {
+			return
+		}
+		timer.Reset(u.nodeDuration())
+	}
+}
+
+// advance steps the upgrade on a node, reporting whether it's done.
+func (u *Upgrades) advance(id string) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	up, err := u.storer.GetUpgrade(id)
+	if err != nil || up.Done() {
+		delete(u.wakes, id)
+		return true
+	}
+	if !up.step() {
+		u.storer.PutUpgrade(up) //nolint:errcheck
+		if up.Status == UpgradeRunning {
+			u.operations.Progress(up.OperationID, up.progress())
+		}
+		return false
+	}
+	u.finish(up)
+	return true
+}
+
+// finish records the upgrade as done and puts the cluster back to ready,
+// on its new release if the upgrade succeeded. It must be called with mu
+// held.
+func (u *Upgrades) finish(up Upgrade) {
+	delete(u.wakes, up.ID)
+	var release string
+	if up.Status == UpgradeSucceeded {
+		release = up.ToRelease
+	}
+	err := u.storer.FinishEHSClusterUpgrade(up.EHSClusterID, up.ID, release)
+	if err != nil {
+		up.Status = UpgradeFailed
+	}
+	up.FinishedAt = time.Now().UTC().Format(time.RFC3339)
+	u.storer.PutUpgrade(up) //nolint:errcheck
+	status := OperationFailed
+	if up.Status == UpgradeSucceeded {
+		status = OperationSucceeded
+	}
+	u.operations.Finish(up.OperationID, status)
+}
+
+// Control pauses, resumes or rolls back an upgrade.
+func (u *Upgrades) Control(id, action string) (Upgrade, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	up, err := u.storer.GetUpgrade(id)
+	if err != nil {
+		return Upgrade{}, err
+	}
+	done := false
+	switch action {
+	case "pause":
+		err = up.pause()
+	case "resume":
+		err = up.resume()
+	case "rollback":
+		done, err = up.rollback()
+	default:
+		return Upgrade{}, ErrUpgradeNotFound
+	}
+	if err != nil {
+		return up, err
+	}
+	if done {
+		// Nothing had been upgraded yet. The runner stops on its next step.
+		u.finish(up)
+		return u.storer.GetUpgrade(id)
+	}
+	err = u.storer.PutUpgrade(up)
+	if err != nil {
+		return Upgrade{}, err
+	}
+	select {
+	case u.wakes[id] <- struct{}{}:
+	default:
+	}
+	return up, nil
+}
+
+// awsScaling reports whether any AW hosted on the cluster is scaling, which
+// is while an operation updating it is running.
+func (a API) awsScaling(clusterID string) (bool, error) {
+	if a.Operations == nil {
+		return false, nil
+	}
+	ops, err := a.Storer.ListOperations()
+	if err != nil {
+		return false, err
+	}
+	for _, op := range ops {
+		if op.Status != OperationRunning || op.Kind != "aw.update" {
+			continue
+		}
+		aw, err := a.Storer.GetAW(op.ResourceID)
+		if err == ErrAWNotFound {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		if aw.EHSClusterID == clusterID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// upgradeEHSCluster starts an upgrade to the new release if the cluster
+// being PUT changes it, instead of changing it directly. If the change isn't
+// allowed, it writes an error response and returns false.
+func (a API) upgradeEHSCluster(w http.ResponseWriter, r *http.Request, cluster EHSCluster, existing EHSCluster) (EHSCluster, bool) {
+	if a.Upgrades == nil || cluster.Release == existing.Release {
+		return cluster, true
+	}
+	if existing.Status == EHSClusterUpgrading && cluster.Release == existing.TargetRelease {
+		cluster.Release = existing.Release
+		return cluster, true
+	}
+	if existing.Status != EHSClusterReady {
+		api.Encode(w, r, http.StatusConflict, Response{Errors: []api.RequestError{{Field: "/release", Slug: api.RequestErrConflict}}})
+		return EHSCluster{}, false
+	}
+	err := checkUpgradePath(existing.Release, cluster.Release)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/release", Slug: api.RequestErrInvalidValue}}})
+		return EHSCluster{}, false
+	}
+	scaling, err := a.awsScaling(existing.ID)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return EHSCluster{}, false
+	}
+	if scaling {
+		api.Encode(w, r, http.StatusConflict, Response{Errors: []api.RequestError{{Field: "/release", Slug: api.RequestErrConflict}}})
+		return EHSCluster{}, false
+	}
+	to := cluster.Release
+	cluster.Release = existing.Release
+	cluster, _, err = a.Upgrades.Start(cluster, to)
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return EHSCluster{}, false
+	}
+	return cluster, true
+}
+
+func (s *Storer) GetUpgrade(id string) (Upgrade, error) {
+	txn := s.db.Txn(false)
+	up, err := txn.First("upgrade", "id", id)
+	if err != nil {
+		return Upgrade{}, err
+	}
+	if up == nil {
+		return Upgrade{}, ErrUpgradeNotFound
+	}
+	result := *up.(*Upgrade)
+	result.Nodes = append([]UpgradeNode(nil), result.Nodes...)
+	return result, nil
+}
+
+func (s *Storer) ListUpgrades() ([]Upgrade, error) {
+	txn := s.db.Txn(false)
+	iter, err := txn.Get("upgrade", "id")
+	if err != nil {
+		return nil, err
+	}
+	var results []Upgrade
+	for obj := iter.Next(); obj != nil; obj = iter.Next() {
+		results = append(results, *obj.(*Upgrade))
+	}
+	return results, nil
+}
+
+// PutUpgrade creates or replaces up, marking it as changed at a new index.
+func (s *Storer) PutUpgrade(up Upgrade) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	up.index = s.nextIndex(txn)
+	up.Nodes = append([]UpgradeNode(nil), up.Nodes...)
+	err := txn.Insert("upgrade", &up)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// WaitForUpgrade blocks until the upgrade has changed since index, the
+// timeout elapses, or ctx is done.
+func (s *Storer) WaitForUpgrade(ctx context.Context, id string, index uint64, timeout time.Duration) error {
+	return s.waitForRow(ctx, "upgrade", id, index, timeout, func(obj interface{}) uint64 {
+		return obj.(*Upgrade).index
+	})
+}
+
+func (a API) handleListUpgrades(w http.ResponseWriter, r *http.Request) {
+	ups, err := a.Storer.ListUpgrades()
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	q := r.URL.Query()
+	results := []Upgrade{}
+	for _, up := range ups {
+		if q.Get("ehs_cluster_id") != "" && !strings.EqualFold(up.EHSClusterID, q.Get("ehs_cluster_id")) {
+			continue
+		}
+		if q.Get("status") != "" && up.Status != q.Get("status") {
+			continue
+		}
+		results = append(results, up)
+	}
+	api.Encode(w, r, http.StatusOK, Response{Upgrades: results})
+}
+
+func (a API) handleGetUpgrade(w http.ResponseWriter, r *http.Request) {
+	id := trout.RequestVars(r).Get("id")
+	ok := a.block(w, r, func(ctx context.Context, index uint64, wait time.Duration) error {
+		return a.Storer.WaitForUpgrade(ctx, id, index, wait)
+	})
+	if !ok {
+		return
+	}
+	up, err := a.Storer.GetUpgrade(id)
+	if err != nil {
+		if err == ErrUpgradeNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{Upgrades: []Upgrade{up}})
+}
+
+// handleControlUpgrade serves {id}:pause, {id}:resume and {id}:rollback.
+func (a API) handleControlUpgrade(w http.ResponseWriter, r *http.Request) {
+	id, action := splitAction(trout.RequestVars(r).Get("id"))
+	up, err := a.Upgrades.Control(id, action)
+	if err != nil {
+		if err == ErrUpgradeNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		if err == ErrUpgradeInvalidState {
+			api.Encode(w, r, http.StatusConflict, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrConflict}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{Upgrades: []Upgrade{up}})
+}
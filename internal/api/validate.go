@@ -0,0 +1,182 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"darlinggo.co/api"
+)
+
+// validationMiddleware rejects requests whose query params or JSON bodies
+// don't match the operation doc describes for them. Requests doc doesn't
+// describe are passed through untouched.
+func validationMiddleware(baseURL string, doc OpenAPIDocument, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		op := findOperation(doc, r.Method, strings.TrimPrefix(r.URL.Path, baseURL))
+		if op == nil {
+			h.ServeHTTP(w, r)
+			return
+		}
+		var errs []api.RequestError
+		q := r.URL.Query()
+		for _, param := range op.Parameters {
+			if param.In != "query" {
+				continue
+			}
+			for _, v := range q[param.Name] {
+				schema := param.Schema
+				if schema.Type == "array" {
+					schema = schema.Items
+				}
+				if slug := validateParam(schema, v); slug != "" {
+					errs = append(errs, api.RequestError{Param: param.Name, Slug: slug})
+					break
+				}
+			}
+		}
+		if op.RequestBody != nil {
+			b, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+				return
+			}
+			r.Body = ioutil.NopCloser(bytes.NewReader(b))
+			var body interface{}
+			dec := json.NewDecoder(bytes.NewReader(b))
+			dec.UseNumber()
+			err = dec.Decode(&body)
+			if err != nil {
+				api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+				return
+			}
+			errs = append(errs, validateValue(doc, op.RequestBody.Content["application/json"].Schema, body, "")...)
+		}
+		if len(errs) > 0 {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func findOperation(doc OpenAPIDocument, method, p string) *OpenAPIOperation {
+	for pattern, item := range doc.Paths {
+		if !matchRoute(pattern, p) {
+			continue
+		}
+		if op, ok := item[strings.ToLower(method)]; ok {
+			return op
+		}
+	}
+	return nil
+}
+
+// validateParam returns the slug of the RequestError v causes, or an empty
+// string if it's valid.
+func validateParam(schema *OpenAPISchema, v string) string {
+	var err error
+	switch schema.Type {
+	case "integer":
+		_, err = strconv.ParseInt(v, 10, 64)
+	case "boolean":
+		_, err = strconv.ParseBool(v)
+	case "string":
+		switch schema.Format {
+		case "date-time":
+			_, err = time.Parse(time.RFC3339, v)
+		case "duration":
+			_, err = time.ParseDuration(v)
+		}
+	}
+	if err != nil {
+		return api.RequestErrInvalidFormat
+	}
+	if len(schema.Enum) > 0 && !contains(schema.Enum, v) {
+		return api.RequestErrInvalidValue
+	}
+	return ""
+}
+
+// validateValue checks a decoded JSON value against schema, returning a
+// RequestError for each problem, with Field set to the JSON pointer of the
+// offending value.
+func validateValue(doc OpenAPIDocument, schema *OpenAPISchema, v interface{}, pointer string) []api.RequestError {
+	schema = resolveSchema(doc, schema)
+	field := pointer
+	if field == "" {
+		field = "/"
+	}
+	if v == nil || schema.Type == "" {
+		return nil
+	}
+	invalid := []api.RequestError{{Field: field, Slug: api.RequestErrInvalidFormat}}
+	switch schema.Type {
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return invalid
+		}
+		var errs []api.RequestError
+		for k, val := range obj {
+			ps := schema.AdditionalProperties
+			if p, ok := schema.Properties[k]; ok {
+				ps = p
+			}
+			if ps == nil {
+				continue
+			}
+			errs = append(errs, validateValue(doc, ps, val, pointer+"/"+escapePointer(k))...)
+		}
+		return errs
+	case "array":
+		arr, ok := v.([]interface{})
+		if !ok {
+			return invalid
+		}
+		var errs []api.RequestError
+		for i, val := range arr {
+			errs = append(errs, validateValue(doc, schema.Items, val, pointer+"/"+strconv.Itoa(i))...)
+		}
+		return errs
+	case "string":
+		s, ok := v.(string)
+		if !ok {
+			return invalid
+		}
+		if schema.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, s); err != nil {
+				return invalid
+			}
+		}
+		if len(schema.Enum) > 0 && !contains(schema.Enum, s) {
+			return []api.RequestError{{Field: field, Slug: api.RequestErrInvalidValue}}
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return invalid
+		}
+	case "integer", "number":
+		n, ok := v.(json.Number)
+		if !ok {
+			return invalid
+		}
+		f, err := n.Float64()
+		if err != nil || (schema.Type == "integer" && f != math.Trunc(f)) {
+			return invalid
+		}
+		if schema.Minimum != nil && f < *schema.Minimum {
+			return []api.RequestError{{Field: field, Slug: api.RequestErrInvalidValue}}
+		}
+	}
+	return nil
+}
+
+func escapePointer(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
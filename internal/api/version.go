@@ -0,0 +1,137 @@
+package api
+
+import (
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"darlinggo.co/api"
+)
+
+const (
+	// LegacyVersion is the unversioned routes, served without a prefix for
+	// providers released before the API was versioned.
+	LegacyVersion = "v0"
+	// CurrentVersion is the newest version of the API, served under /v1.
+	CurrentVersion = "v1"
+)
+
+// Versions lists every version of the API edisond serves, oldest first.
+var Versions = []string{LegacyVersion, CurrentVersion}
+
+// VersionPolicy sets when a version of the API is deprecated, and when it
+// will stop being served. Either can be in the future, to give notice.
+type VersionPolicy struct {
+	DeprecatedAt time.Time `json:"deprecated_at,omitempty" yaml:"deprecated_at,omitempty"`
+	SunsetAt     time.Time `json:"sunset_at,omitempty" yaml:"sunset_at,omitempty"`
+}
+
+// Version describes a version of the API, as listed by /capabilities.
+type Version struct {
+	Name         string `json:"name"`
+	Path         string `json:"path"`
+	DeprecatedAt string `json:"deprecated_at,omitempty"`
+	SunsetAt     string `json:"sunset_at,omitempty"`
+}
+
+// Capabilities describes what the server supports, so clients can check
+// they're compatible before making any other requests.
+type Capabilities struct {
+	Versions []Version `json:"versions"`
+	Features []string  `json:"features"`
+}
+
+// versionPath is the path a version is served under, relative to the base
+// URL.
+func versionPath(version string) string {
+	if version == LegacyVersion {
+		return "/"
+	}
+	return "/" + version
+}
+
+func (a API) versions() []Version {
+	var results []Version
+	for _, name := range Versions {
+		v := Version{Name: name, Path: versionPath(name)}
+		policy := a.VersionPolicies[name]
+		if !policy.DeprecatedAt.IsZero() {
+			v.DeprecatedAt = policy.DeprecatedAt.UTC().Format(time.RFC3339)
+		}
+		if !policy.SunsetAt.IsZero() {
+			v.SunsetAt = policy.SunsetAt.UTC().Format(time.RFC3339)
+		}
+		results = append(results, v)
+	}
+	return results
+}
+
+func (a API) features() []string {
+	features := []string{"events", "blocking_queries", "quotas", "resize", "catalog", "nodepools", "placement"}
+	if a.Operations != nil {
+		features = append(features, "operations")
+	}
+	if a.Upgrades != nil {
+		features = append(features, "upgrades")
+	}
+	if a.Migrations != nil {
+		features = append(features, "migrations")
+	}
+	if a.Autoscaler != nil {
+		features = append(features, "autoscaling")
+	}
+	if a.Scheduler != nil {
+		features = append(features, "schedules")
+	}
+	if a.Retention > 0 {
+		features = append(features, "soft_delete")
+	}
+	if a.Webhooks != nil {
+		features = append(features, "webhooks")
+	}
+	if a.Audit != nil {
+		features = append(features, "audit")
+	}
+	if a.Limiter != nil {
+		features = append(features, "rate_limits")
+	}
+	if a.ValidateRequests {
+		features = append(features, "request_validation")
+	}
+	return features
+}
+
+func (a API) handleCapabilities(w http.ResponseWriter, r *http.Request) {
+	api.Encode(w, r, http.StatusOK, Capabilities{Versions: a.versions(), Features: a.features()})
+}
+
+// versionMiddleware serves a version of the API from h, which serves the
+// routes without a version prefix. Deprecated versions get Deprecation,
+// Sunset and successor-version Link headers on every response.
+func (a API) versionMiddleware(baseURL, version string, h http.Handler) http.Handler {
+	prefix := strings.TrimSuffix(path.Join("/", baseURL, versionPath(version)), "/")
+	base := strings.TrimSuffix(path.Join("/", baseURL), "/")
+	policy := a.VersionPolicies[version]
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := base + strings.TrimPrefix(r.URL.Path, prefix)
+		if !policy.DeprecatedAt.IsZero() {
+			w.Header().Set("Deprecation", "@"+strconv.FormatInt(policy.DeprecatedAt.Unix(), 10))
+			w.Header().Set("Link", "<"+path.Join("/", baseURL, versionPath(CurrentVersion), strings.TrimPrefix(p, base))+`>; rel="successor-version"`)
+		}
+		if !policy.SunsetAt.IsZero() {
+			w.Header().Set("Sunset", policy.SunsetAt.UTC().Format(http.TimeFormat))
+		}
+		if p != r.URL.Path {
+			r2 := new(http.Request)
+			*r2 = *r
+			u := *r.URL
+			u.Path = p
+			u.RawPath = ""
+			r2.URL = &u
+			r = r2
+		}
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,436 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"darlinggo.co/api"
+	"darlinggo.co/trout/v2"
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/go-uuid"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+
+	// EventPing is sent when a webhook is tested, rather than for a change.
+	EventPing = "ping"
+)
+
+// Webhook subscribes a URL to resource change events. Secret is used to sign
+// every delivery, and is never returned by the API.
+type Webhook struct {
+	ID            string   `json:"id,omitempty"`
+	URL           string   `json:"url"`
+	Events        []string `json:"events,omitempty"`
+	ResourceTypes []string `json:"resource_types,omitempty"`
+	Secret        string   `json:"secret,omitempty"`
+	CreatedAt     string   `json:"created_at,omitempty"`
+	UpdatedAt     string   `json:"updated_at,omitempty"`
+}
+
+func (wh Webhook) matches(rev Revision) bool {
+	return (len(wh.Events) < 1 || contains(wh.Events, rev.Event)) &&
+		(len(wh.ResourceTypes) < 1 || contains(wh.ResourceTypes, rev.ResourceType))
+}
+
+func (wh Webhook) redacted() Webhook {
+	wh.Secret = ""
+	return wh
+}
+
+func (wh Webhook) validate() []api.RequestError {
+	var errs []api.RequestError
+	if wh.URL == "" {
+		errs = append(errs, api.RequestError{Field: "/url", Slug: api.RequestErrMissing})
+	} else if u, err := url.Parse(wh.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, api.RequestError{Field: "/url", Slug: api.RequestErrInvalidValue})
+	}
+	if wh.Secret == "" {
+		errs = append(errs, api.RequestError{Field: "/secret", Slug: api.RequestErrMissing})
+	}
+	for i, event := range wh.Events {
+		switch event {
+		case EventCreate, EventUpdate, EventDelete, EventPurge, EventStatusChange, EventScale, EventSchedule:
+		default:
+			errs = append(errs, api.RequestError{Field: fmt.Sprintf("/events/%d", i), Slug: api.RequestErrInvalidValue})
+		}
+	}
+	return errs
+}
+
+func contains(list []string, s string) bool {
+	for _, candidate := range list {
+		if candidate == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivery records an attempt to send an event to a Webhook.
+type Delivery struct {
+	ID           string    `json:"id"`
+	WebhookID    string    `json:"webhook_id"`
+	Event        string    `json:"event"`
+	Index        uint64    `json:"index,omitempty"`
+	Status       string    `json:"status"`
+	Attempts     int       `json:"attempts"`
+	ResponseCode int       `json:"response_code,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// SignWebhook returns the X-Edison-Signature header value for body.
+func SignWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature reports whether signature is a valid
+// X-Edison-Signature for body.
+func VerifyWebhookSignature(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(SignWebhook(secret, body)), []byte(signature))
+}
+
+// webhooksMaxBackoff caps how long Run waits before retrying after it
+// couldn't read changes or webhooks.
+const webhooksMaxBackoff = time.Minute
+
+// Webhooks delivers every change recorded by the Storer to the webhooks
+// subscribed to it, retrying failures with exponential backoff. Each
+// webhook keeps its most recent maxDeliveries deliveries, and can have at
+// most that many pending at once.
+type Webhooks struct {
+	storer        *Storer
+	client        *http.Client
+	attempts      int
+	backoff       time.Duration
+	maxDeliveries int
+
+	mu  sync.Mutex
+	ctx context.Context
+}
+
+func NewWebhooks(storer *Storer) *Webhooks {
+	client := cleanhttp.DefaultPooledClient()
+	client.Timeout = 10 * time.Second
+	return &Webhooks{
+		storer:        storer,
+		client:        client,
+		attempts:      5,
+		backoff:       time.Second,
+		maxDeliveries: 100,
+	}
+}
+
+// runContext returns the context Run was called with, so deliveries
+// started outside it, like pings, stop when it does.
+func (w *Webhooks) runContext() context.Context {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.ctx == nil {
+		return context.Background()
+	}
+	return w.ctx
+}
+
+// Run delivers changes made after it's called until ctx is done.
+func (w *Webhooks) Run(ctx context.Context) {
+	w.mu.Lock()
+	w.ctx = ctx
+	w.mu.Unlock()
+	last := w.storer.Index()
+	backoff := w.backoff
+	for {
+		revs, watch, err := w.storer.RevisionsSince(last)
+		var hooks []Webhook
+		if err == nil && len(revs) > 0 {
+			hooks, err = w.storer.ListWebhooks()
+		}
+		if err != nil {
+			// Changes aren't skipped; they're read again from last.
+			log.Printf("Error reading changes for webhooks, retrying in %s: %s", backoff, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			if backoff < webhooksMaxBackoff {
+				backoff *= 2
+			}
+			continue
+		}
+		backoff = w.backoff
+		for _, rev := range revs {
+			last = rev.Index
+			for _, hook := range hooks {
+				if !hook.matches(rev) {
+					continue
+				}
+				_, err = w.send(ctx, hook, rev)
+				if err != nil {
+					log.Println("Error sending webhook:", err.Error())
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-watch:
+		}
+	}
+}
+
+// send starts delivering rev to hook in the background, returning the
+// pending Delivery.
+func (w *Webhooks) send(ctx context.Context, hook Webhook, rev Revision) (Delivery, error) {
+	body, err := json.Marshal(rev)
+	if err != nil {
+		return Delivery{}, err
+	}
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return Delivery{}, err
+	}
+	now := time.Now().UTC()
+	d := Delivery{
+		ID:        id,
+		WebhookID: hook.ID,
+		Event:     rev.Event,
+		Index:     rev.Index,
+		Status:    DeliveryPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	err = w.storer.AddDelivery(d, w.maxDeliveries)
+	if err != nil {
+		return Delivery{}, err
+	}
+	go w.deliver(ctx, hook, d, body)
+	return d, nil
+}
+
+func (w *Webhooks) deliver(ctx context.Context, hook Webhook, d Delivery, body []byte) {
+	backoff := w.backoff
+	for {
+		if _, err := w.storer.GetWebhook(hook.ID); err != nil {
+			return
+		}
+		d.Attempts++
+		d.ResponseCode, d.Error = w.post(ctx, hook, d, body)
+		d.UpdatedAt = time.Now().UTC()
+		if d.Error == "" {
+			d.Status = DeliverySucceeded
+		} else if d.Attempts >= w.attempts {
+			d.Status = DeliveryFailed
+		}
+		err := w.storer.PutDelivery(d)
+		if err != nil {
+			log.Println("Error recording webhook delivery:", err.Error())
+		}
+		if d.Status != DeliveryPending {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (w *Webhooks) post(ctx context.Context, hook Webhook, d Delivery, body []byte) (int, string) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err.Error()
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "edisond-webhooks")
+	req.Header.Set("X-Edison-Event", d.Event)
+	req.Header.Set("X-Edison-Delivery", d.ID)
+	req.Header.Set("X-Edison-Signature", SignWebhook(hook.Secret, body))
+	res, err := w.client.Do(req)
+	if err != nil {
+		return 0, err.Error()
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Sprintf("unexpected response status %d", res.StatusCode)
+	}
+	return res.StatusCode, ""
+}
+
+func (a API) handleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	hooks, err := a.Storer.ListWebhooks()
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	results := []Webhook{}
+	for _, hook := range hooks {
+		results = append(results, hook.redacted())
+	}
+	api.Encode(w, r, http.StatusOK, Response{Webhooks: results})
+}
+
+func (a API) handleGetWebhook(w http.ResponseWriter, r *http.Request) {
+	hook, err := a.Storer.GetWebhook(trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrWebhookNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{Webhooks: []Webhook{hook.redacted()}})
+}
+
+func (a API) handlePostWebhook(w http.ResponseWriter, r *http.Request) {
+	var hook Webhook
+	err := api.Decode(r, &hook)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	if errs := hook.validate(); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	hook.ID, err = uuid.GenerateUUID()
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	hook.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	hook.UpdatedAt = hook.CreatedAt
+	err = a.Storer.CreateWebhook(hook)
+	if err != nil {
+		if err == ErrWebhookAlreadyExists {
+			api.Encode(w, r, http.StatusBadRequest, Response{Errors: []api.RequestError{{Field: "/id", Slug: api.RequestErrConflict}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusCreated, Response{Webhooks: []Webhook{hook.redacted()}})
+}
+
+func (a API) handlePutWebhook(w http.ResponseWriter, r *http.Request) {
+	var hook Webhook
+	err := api.Decode(r, &hook)
+	if err != nil {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: api.InvalidFormatError})
+		return
+	}
+	hook.ID = trout.RequestVars(r).Get("id")
+	existing, err := a.Storer.GetWebhook(hook.ID)
+	if err != nil {
+		if err == ErrWebhookNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	if hook.Secret == "" {
+		hook.Secret = existing.Secret
+	}
+	if errs := hook.validate(); len(errs) > 0 {
+		api.Encode(w, r, http.StatusBadRequest, Response{Errors: errs})
+		return
+	}
+	hook.CreatedAt = existing.CreatedAt
+	hook.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	err = a.Storer.UpdateWebhook(hook)
+	if err != nil {
+		if err == ErrWebhookNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{Webhooks: []Webhook{hook.redacted()}})
+}
+
+func (a API) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	hook, err := a.Storer.DeleteWebhook(trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrWebhookNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{Webhooks: []Webhook{hook.redacted()}})
+}
+
+// handlePingWebhook sends a ping event to the webhook, so receivers can be
+// tested without changing any resources.
+func (a API) handlePingWebhook(w http.ResponseWriter, r *http.Request) {
+	id, action := splitAction(trout.RequestVars(r).Get("id"))
+	if action != "ping" {
+		api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+		return
+	}
+	hook, err := a.Storer.GetWebhook(id)
+	if err != nil {
+		if err == ErrWebhookNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	d, err := a.Webhooks.send(a.Webhooks.runContext(), hook, Revision{
+		Event:        EventPing,
+		ResourceType: "webhook",
+		ResourceID:   hook.ID,
+		Time:         time.Now().UTC(),
+	})
+	if err == ErrTooManyDeliveries {
+		api.Encode(w, r, http.StatusConflict, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrConflict}}})
+		return
+	}
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusAccepted, Response{Deliveries: []Delivery{d}})
+}
+
+func (a API) handleListDeliveries(w http.ResponseWriter, r *http.Request) {
+	_, err := a.Storer.GetWebhook(trout.RequestVars(r).Get("id"))
+	if err != nil {
+		if err == ErrWebhookNotFound {
+			api.Encode(w, r, http.StatusNotFound, Response{Errors: []api.RequestError{{Param: "id", Slug: api.RequestErrNotFound}}})
+			return
+		}
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	deliveries, err := a.Storer.ListDeliveries(trout.RequestVars(r).Get("id"))
+	if err != nil {
+		api.Encode(w, r, http.StatusInternalServerError, Response{Errors: api.ActOfGodError})
+		return
+	}
+	api.Encode(w, r, http.StatusOK, Response{Deliveries: deliveries})
+}
@@ -0,0 +1,109 @@
+package edison
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+)
+
+type AuditService struct {
+	basePath string
+	client   *Client
+}
+
+func newAuditService(basePath string, client *Client) *AuditService {
+	return &AuditService{
+		basePath: basePath,
+		client:   client,
+	}
+}
+
+type AuditEvent struct {
+	ID           string          `json:"id"`
+	Time         time.Time       `json:"time"`
+	Principal    string          `json:"principal"`
+	SourceIP     string          `json:"source_ip"`
+	RequestID    string          `json:"request_id"`
+	Method       string          `json:"method"`
+	Path         string          `json:"path"`
+	Status       int             `json:"status"`
+	ResourceType string          `json:"resource_type"`
+	ResourceID   string          `json:"resource_id,omitempty"`
+	Before       json.RawMessage `json:"before,omitempty"`
+	After        json.RawMessage `json:"after,omitempty"`
+	Changes      []AuditChange   `json:"changes,omitempty"`
+}
+
+type AuditChange struct {
+	Field  string          `json:"field"`
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+type AuditFilter struct {
+	ResourceType string
+	ResourceID   string
+	Principal    string
+	Since        time.Time
+	Until        time.Time
+}
+
+func (s AuditService) buildURL(p string) string {
+	return path.Join(s.basePath, p)
+}
+
+func (s AuditService) List(ctx context.Context, filter AuditFilter) ([]AuditEvent, error) {
+	q := url.Values{}
+	if filter.ResourceType != "" {
+		q.Set("resource_type", filter.ResourceType)
+	}
+	if filter.ResourceID != "" {
+		q.Set("resource_id", filter.ResourceID)
+	}
+	if filter.Principal != "" {
+		q.Set("principal", filter.Principal)
+	}
+	if !filter.Since.IsZero() {
+		q.Set("since", filter.Since.Format(time.RFC3339))
+	}
+	if !filter.Until.IsZero() {
+		q.Set("until", filter.Until.Format(time.RFC3339))
+	}
+	req, err := s.client.NewRequest(ctx, http.MethodGet, s.buildURL("/")+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidFormat,
+		Param: "since",
+	}) {
+		return nil, errors.New("since must be an RFC 3339 timestamp")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidFormat,
+		Param: "until",
+	}) {
+		return nil, errors.New("until must be an RFC 3339 timestamp")
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.AuditEvents, nil
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"net/http"
 	"path"
+	"time"
 )
 
 var (
@@ -27,13 +28,15 @@ func newAVService(basePath string, client *Client) *AVsService {
 }
 
 type AV struct {
-	ID           string `json:"id,omitempty"`
-	AccountID    string `json:"account_id"`
-	TenantID     string `json:"tenant_id"`
-	TenantFolder string `json:"tenant_folder,omitempty"`
-	TenantQueue  string `json:"tenant_queue,omitempty"`
-	CreatedAt    string `json:"created_at,omitempty"`
-	UpdatedAt    string `json:"updated_at,omitempty"`
+	ID           string            `json:"id,omitempty"`
+	AccountID    string            `json:"account_id"`
+	TenantID     string            `json:"tenant_id"`
+	TenantFolder string            `json:"tenant_folder,omitempty"`
+	TenantQueue  string            `json:"tenant_queue,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	CreatedAt    string            `json:"created_at,omitempty"`
+	UpdatedAt    string            `json:"updated_at,omitempty"`
+	DeletedAt    string            `json:"deleted_at,omitempty"`
 }
 
 func (s AVsService) buildURL(p string) string {
@@ -41,19 +44,51 @@ func (s AVsService) buildURL(p string) string {
 }
 
 func (s AVsService) Create(ctx context.Context, av AV) (AV, error) {
+	req, err := s.createRequest(ctx, av)
+	if err != nil {
+		return AV{}, err
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return AV{}, fmt.Errorf("error making request: %w", err)
+	}
+	return s.createResponse(res)
+}
+
+// StartCreate asks the server to create the AV in the background,
+// returning the Operation to wait on. Servers that don't support operations
+// create it straight away, and the Operation returned has already
+// succeeded.
+func (s AVsService) StartCreate(ctx context.Context, av AV) (Operation, error) {
+	req, err := s.createRequest(ctx, av)
+	if err != nil {
+		return Operation{}, err
+	}
+	op, res, err := s.client.startOperation(req)
+	if err != nil || res == nil {
+		return op, err
+	}
+	created, err := s.createResponse(res)
+	if err != nil {
+		return Operation{}, err
+	}
+	return completedOperation("av", "create", created.ID), nil
+}
+
+func (s AVsService) createRequest(ctx context.Context, av AV) (*http.Request, error) {
 	b, err := json.Marshal(av)
 	if err != nil {
-		return AV{}, fmt.Errorf("error serialising av: %w", err)
+		return nil, fmt.Errorf("error serialising av: %w", err)
 	}
 	buf := bytes.NewBuffer(b)
 	req, err := s.client.NewRequest(ctx, http.MethodPost, s.buildURL("/"), buf)
 	if err != nil {
-		return AV{}, fmt.Errorf("error constructing request: %w", err)
-	}
-	res, err := s.client.Do(req)
-	if err != nil {
-		return AV{}, fmt.Errorf("error making request: %w", err)
+		return nil, fmt.Errorf("error constructing request: %w", err)
 	}
+	return req, nil
+}
+
+func (s AVsService) createResponse(res *http.Response) (AV, error) {
 	resp, err := responseFromBody(res)
 	if err != nil {
 		return AV{}, err
@@ -84,6 +119,10 @@ func (s AVsService) Create(ctx context.Context, av AV) (AV, error) {
 		return AV{}, errors.New("account id must be set")
 	}
 
+	err = resp.quotaError()
+	if err != nil {
+		return AV{}, err
+	}
 	if len(resp.Errors) > 0 {
 		return AV{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
 	}
@@ -129,22 +168,54 @@ func (s AVsService) Get(ctx context.Context, id string) (AV, error) {
 }
 
 func (s AVsService) Update(ctx context.Context, av AV) (AV, error) {
+	req, err := s.updateRequest(ctx, av)
+	if err != nil {
+		return AV{}, err
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return AV{}, fmt.Errorf("error making request: %w", err)
+	}
+	return s.updateResponse(res)
+}
+
+// StartUpdate asks the server to update the AV in the background,
+// returning the Operation to wait on. Servers that don't support operations
+// update it straight away, and the Operation returned has already
+// succeeded.
+func (s AVsService) StartUpdate(ctx context.Context, av AV) (Operation, error) {
+	req, err := s.updateRequest(ctx, av)
+	if err != nil {
+		return Operation{}, err
+	}
+	op, res, err := s.client.startOperation(req)
+	if err != nil || res == nil {
+		return op, err
+	}
+	_, err = s.updateResponse(res)
+	if err != nil {
+		return Operation{}, err
+	}
+	return completedOperation("av", "update", av.ID), nil
+}
+
+func (s AVsService) updateRequest(ctx context.Context, av AV) (*http.Request, error) {
 	if av.ID == "" {
-		return AV{}, errors.New("id must be specified")
+		return nil, errors.New("id must be specified")
 	}
 	b, err := json.Marshal(av)
 	if err != nil {
-		return AV{}, fmt.Errorf("error serialising AV: %w", err)
+		return nil, fmt.Errorf("error serialising AV: %w", err)
 	}
 	buf := bytes.NewBuffer(b)
 	req, err := s.client.NewRequest(ctx, http.MethodPut, s.buildURL("/"+av.ID), buf)
 	if err != nil {
-		return AV{}, fmt.Errorf("error constructing request: %w", err)
-	}
-	res, err := s.client.Do(req)
-	if err != nil {
-		return AV{}, fmt.Errorf("error making request: %w", err)
+		return nil, fmt.Errorf("error constructing request: %w", err)
 	}
+	return req, nil
+}
+
+func (s AVsService) updateResponse(res *http.Response) (AV, error) {
 	resp, err := responseFromBody(res)
 	if err != nil {
 		return AV{}, err
@@ -168,6 +239,10 @@ func (s AVsService) Update(ctx context.Context, av AV) (AV, error) {
 	}) {
 		return AV{}, errors.New("Tenant ID must be set")
 	}
+	err = resp.quotaError()
+	if err != nil {
+		return AV{}, err
+	}
 	if len(resp.Errors) > 0 {
 		return AV{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
 	}
@@ -177,18 +252,66 @@ func (s AVsService) Update(ctx context.Context, av AV) (AV, error) {
 	return resp.AVs[0], nil
 }
 
+// Delete deletes the AV. If the server keeps deleted resources for a
+// retention window, it can be restored until then.
 func (s AVsService) Delete(ctx context.Context, id string) error {
-	if id == "" {
-		return errors.New("id must be specified")
+	return s.delete(ctx, id, false)
+}
+
+// ForceDelete deletes the AV permanently, skipping any retention
+// window.
+func (s AVsService) ForceDelete(ctx context.Context, id string) error {
+	return s.delete(ctx, id, true)
+}
+
+// StartDelete asks the server to delete the AV in the background,
+// returning the Operation to wait on. Servers that don't support operations
+// delete it straight away, and the Operation returned has already
+// succeeded.
+func (s AVsService) StartDelete(ctx context.Context, id string) (Operation, error) {
+	req, err := s.deleteRequest(ctx, id, false)
+	if err != nil {
+		return Operation{}, err
 	}
-	req, err := s.client.NewRequest(ctx, http.MethodGet, s.buildURL("/"+id), nil)
+	op, res, err := s.client.startOperation(req)
+	if err != nil || res == nil {
+		return op, err
+	}
+	err = s.deleteResponse(res)
+	if err != nil {
+		return Operation{}, err
+	}
+	return completedOperation("av", "delete", id), nil
+}
+
+func (s AVsService) delete(ctx context.Context, id string, force bool) error {
+	req, err := s.deleteRequest(ctx, id, force)
 	if err != nil {
-		return fmt.Errorf("error constructing request: %w", err)
+		return err
 	}
 	res, err := s.client.Do(req)
 	if err != nil {
 		return fmt.Errorf("error making request: %w", err)
 	}
+	return s.deleteResponse(res)
+}
+
+func (s AVsService) deleteRequest(ctx context.Context, id string, force bool) (*http.Request, error) {
+	if id == "" {
+		return nil, errors.New("id must be specified")
+	}
+	u := s.buildURL("/" + id)
+	if force {
+		u += "?force=true"
+	}
+	req, err := s.client.NewRequest(ctx, http.MethodDelete, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	return req, nil
+}
+
+func (s AVsService) deleteResponse(res *http.Response) error {
 	resp, err := responseFromBody(res)
 	if err != nil {
 		return err
@@ -208,3 +331,116 @@ func (s AVsService) Delete(ctx context.Context, id string) error {
 	}
 	return nil
 }
+
+func (s AVsService) Revisions(ctx context.Context, id string) ([]Revision, error) {
+	if id == "" {
+		return nil, errors.New("id must be specified")
+	}
+	return s.client.listRevisions(ctx, s.buildURL("/"+id), ErrAVNotFound)
+}
+
+func (s AVsService) GetRevision(ctx context.Context, id string, revision int) (AV, error) {
+	if id == "" {
+		return AV{}, errors.New("id must be specified")
+	}
+	resp, err := s.client.getRevision(ctx, s.buildURL("/"+id), revision)
+	if err != nil {
+		return AV{}, err
+	}
+	if len(resp.AVs) < 1 {
+		return AV{}, errors.New("no AV returned in response")
+	}
+	return resp.AVs[0], nil
+}
+
+func (s AVsService) List(ctx context.Context, opts ListOptions) ([]AV, error) {
+	req, err := s.client.NewRequest(ctx, http.MethodGet, s.buildURL("/")+opts.query(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Param: "deleted",
+	}) {
+		return nil, errors.New("deleted must be \"include\" or \"only\"")
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.AVs, nil
+}
+
+// Restore undoes a Delete of the AV, as long as it hasn't been purged.
+func (s AVsService) Restore(ctx context.Context, id string) (AV, error) {
+	if id == "" {
+		return AV{}, errors.New("id must be specified")
+	}
+	req, err := s.client.NewRequest(ctx, http.MethodPost, s.buildURL("/"+id+":restore"), nil)
+	if err != nil {
+		return AV{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return AV{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return AV{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return AV{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return AV{}, ErrAVNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Param: "id",
+	}) {
+		return AV{}, errors.New("AV is not deleted")
+	}
+	err = resp.quotaError()
+	if err != nil {
+		return AV{}, err
+	}
+	if len(resp.Errors) > 0 {
+		return AV{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.AVs) < 1 {
+		return AV{}, errors.New("no AV returned in response")
+	}
+	return resp.AVs[0], nil
+}
+
+// Watch blocks until the AV has changed since index, or wait has
+// elapsed, then returns it along with the index to pass to the next call. An
+// index of 0 returns immediately.
+func (s AVsService) Watch(ctx context.Context, id string, index uint64, wait time.Duration) (AV, uint64, error) {
+	if id == "" {
+		return AV{}, 0, errors.New("id must be specified")
+	}
+	resp, next, err := s.client.blockingGet(ctx, s.buildURL("/"+id), index, wait, ErrAVNotFound)
+	if err != nil {
+		return AV{}, 0, err
+	}
+	if len(resp.AVs) < 1 {
+		return AV{}, 0, errors.New("no AV returned in response")
+	}
+	return resp.AVs[0], next, nil
+}
@@ -8,10 +8,24 @@ import (
 	"fmt"
 	"net/http"
 	"path"
+	"time"
 )
 
 var (
 	ErrAWNotFound = errors.New("aw not found")
+	// ErrNoEHSClusterRoom is returned when an AW created without an EHS
+	// Cluster can't be placed, because no ready cluster in its region and
+	// account has room for its concurrent users.
+	ErrNoEHSClusterRoom = errors.New("no ready EHS Cluster in the AW's region and account has room for it")
+	// ErrEHSClusterFull is returned when the AW's EHS Cluster doesn't have
+	// room for its concurrent users.
+	ErrEHSClusterFull = errors.New("EHS Cluster doesn't have room for the AW's concurrent users")
+	// ErrAWMigrating is returned when the AW's EHS Cluster is changed while
+	// it's still migrating to another one.
+	ErrAWMigrating = errors.New("AW is already migrating to another EHS Cluster")
+	// ErrAWChanged is returned when the AW started or finished migrating
+	// while it was being updated.
+	ErrAWChanged = errors.New("AW started or finished migrating while it was being updated")
 )
 
 type AWsService struct {
@@ -27,16 +41,57 @@ func newAWService(basePath string, client *Client) *AWsService {
 }
 
 type AW struct {
-	ID              string `json:"id,omitempty"`
-	ConcurrentUsers int    `json:"concurrent_users"`
-	EHSClusterID    string `json:"ehs_cluster_id"`
-	DicomEndPoint   string `json:"dicom_endpoint"`
-	DNSEndPoint     string `json:"dns_endpoint,omitempty"`
-	EAAccounID      string `json:"ea_account_id"`
-	EAServiceEP     string `json:"ea_service_ep"`
-	EAVpcEP         string `json:"ea_vpc_ep,omitempty"`
-	CreatedAt       string `json:"created_at,omitempty"`
-	UpdatedAt       string `json:"updated_at,omitempty"`
+	ID                 string `json:"id,omitempty"`
+	ConcurrentUsers    int    `json:"concurrent_users"`
+	EHSClusterID       string `json:"ehs_cluster_id"`
+	Region             string `json:"region,omitempty"`
+	DicomEndPoint      string `json:"dicom_endpoint"`
+	DNSEndPoint        string `json:"dns_endpoint,omitempty"`
+	EAAccounID         string `json:"ea_account_id"`
+	EAServiceEP        string `json:"ea_service_ep"`
+	EAVpcEP            string `json:"ea_vpc_ep,omitempty"`
+	Status             string `json:"status,omitempty"`
+	MigrationStrategy  string `json:"migration_strategy,omitempty"`
+	TargetEHSClusterID string `json:"target_ehs_cluster_id,omitempty"`
+	MigrationID        string `json:"migration_id,omitempty"`
+	OperationID        string `json:"operation_id,omitempty"`
+	// ScheduleID is the AWSchedule setting the AW's concurrent users. While
+	// it's set, ConcurrentUsers can't be changed directly.
+	ScheduleID string            `json:"schedule_id,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	CreatedAt  string            `json:"created_at,omitempty"`
+	UpdatedAt  string            `json:"updated_at,omitempty"`
+	DeletedAt  string            `json:"deleted_at,omitempty"`
+}
+
+// placementError returns an error describing why the AW couldn't be put
+// on an EHS Cluster, if it couldn't.
+func placementError(resp Response) error {
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInsufficient,
+		Field: "/ehs_cluster_id",
+	}) {
+		return ErrNoEHSClusterRoom
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInsufficient,
+		Field: "/concurrent_users",
+	}) {
+		return ErrEHSClusterFull
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/ehs_cluster_id",
+	}) {
+		return ErrEHSClusterNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/region",
+	}) {
+		return errors.New("region doesn't match the EHS Cluster's region")
+	}
+	return nil
 }
 
 func (s AWsService) buildURL(p string) string {
@@ -44,19 +99,51 @@ func (s AWsService) buildURL(p string) string {
 }
 
 func (s AWsService) Create(ctx context.Context, aw AW) (AW, error) {
+	req, err := s.createRequest(ctx, aw)
+	if err != nil {
+		return AW{}, err
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return AW{}, fmt.Errorf("error making request: %w", err)
+	}
+	return s.createResponse(res)
+}
+
+// StartCreate asks the server to create the AW in the background,
+// returning the Operation to wait on. Servers that don't support operations
+// create it straight away, and the Operation returned has already
+// succeeded.
+func (s AWsService) StartCreate(ctx context.Context, aw AW) (Operation, error) {
+	req, err := s.createRequest(ctx, aw)
+	if err != nil {
+		return Operation{}, err
+	}
+	op, res, err := s.client.startOperation(req)
+	if err != nil || res == nil {
+		return op, err
+	}
+	created, err := s.createResponse(res)
+	if err != nil {
+		return Operation{}, err
+	}
+	return completedOperation("aw", "create", created.ID), nil
+}
+
+func (s AWsService) createRequest(ctx context.Context, aw AW) (*http.Request, error) {
 	b, err := json.Marshal(aw)
 	if err != nil {
-		return AW{}, fmt.Errorf("error serialising aw: %w", err)
+		return nil, fmt.Errorf("error serialising aw: %w", err)
 	}
 	buf := bytes.NewBuffer(b)
 	req, err := s.client.NewRequest(ctx, http.MethodPost, s.buildURL("/"), buf)
 	if err != nil {
-		return AW{}, fmt.Errorf("error constructing request: %w", err)
-	}
-	res, err := s.client.Do(req)
-	if err != nil {
-		return AW{}, fmt.Errorf("error making request: %w", err)
+		return nil, fmt.Errorf("error constructing request: %w", err)
 	}
+	return req, nil
+}
+
+func (s AWsService) createResponse(res *http.Response) (AW, error) {
 	resp, err := responseFromBody(res)
 	if err != nil {
 		return AW{}, err
@@ -92,7 +179,21 @@ func (s AWsService) Create(ctx context.Context, aw AW) (AW, error) {
 	}) {
 		return AW{}, errors.New("Dicom End Point must be set")
 	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/migration_strategy",
+	}) {
+		return AW{}, fmt.Errorf("migration_strategy must be %q or %q", MigrationCutOver, MigrationBlueGreen)
+	}
 
+	err = placementError(resp)
+	if err != nil {
+		return AW{}, err
+	}
+	err = resp.quotaError()
+	if err != nil {
+		return AW{}, err
+	}
 	if len(resp.Errors) > 0 {
 		return AW{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
 	}
@@ -138,22 +239,54 @@ func (s AWsService) Get(ctx context.Context, id string) (AW, error) {
 }
 
 func (s AWsService) Update(ctx context.Context, aw AW) (AW, error) {
+	req, err := s.updateRequest(ctx, aw)
+	if err != nil {
+		return AW{}, err
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return AW{}, fmt.Errorf("error making request: %w", err)
+	}
+	return s.updateResponse(res)
+}
+
+// StartUpdate asks the server to update the AW in the background,
+// returning the Operation to wait on. Servers that don't support operations
+// update it straight away, and the Operation returned has already
+// succeeded.
+func (s AWsService) StartUpdate(ctx context.Context, aw AW) (Operation, error) {
+	req, err := s.updateRequest(ctx, aw)
+	if err != nil {
+		return Operation{}, err
+	}
+	op, res, err := s.client.startOperation(req)
+	if err != nil || res == nil {
+		return op, err
+	}
+	_, err = s.updateResponse(res)
+	if err != nil {
+		return Operation{}, err
+	}
+	return completedOperation("aw", "update", aw.ID), nil
+}
+
+func (s AWsService) updateRequest(ctx context.Context, aw AW) (*http.Request, error) {
 	if aw.ID == "" {
-		return AW{}, errors.New("id must be specified")
+		return nil, errors.New("id must be specified")
 	}
 	b, err := json.Marshal(aw)
 	if err != nil {
-		return AW{}, fmt.Errorf("error serialising AW: %w", err)
+		return nil, fmt.Errorf("error serialising AW: %w", err)
 	}
 	buf := bytes.NewBuffer(b)
 	req, err := s.client.NewRequest(ctx, http.MethodPut, s.buildURL("/"+aw.ID), buf)
 	if err != nil {
-		return AW{}, fmt.Errorf("error constructing request: %w", err)
-	}
-	res, err := s.client.Do(req)
-	if err != nil {
-		return AW{}, fmt.Errorf("error making request: %w", err)
+		return nil, fmt.Errorf("error constructing request: %w", err)
 	}
+	return req, nil
+}
+
+func (s AWsService) updateResponse(res *http.Response) (AW, error) {
 	resp, err := responseFromBody(res)
 	if err != nil {
 		return AW{}, err
@@ -177,6 +310,32 @@ func (s AWsService) Update(ctx context.Context, aw AW) (AW, error) {
 	}) {
 		return AW{}, errors.New("AW partition_space_tb must be set")
 	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/ehs_cluster_id",
+	}) {
+		return AW{}, ErrAWMigrating
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/status",
+	}) {
+		return AW{}, ErrAWChanged
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/migration_strategy",
+	}) {
+		return AW{}, fmt.Errorf("migration_strategy must be %q or %q", MigrationCutOver, MigrationBlueGreen)
+	}
+	err = placementError(resp)
+	if err != nil {
+		return AW{}, err
+	}
+	err = resp.quotaError()
+	if err != nil {
+		return AW{}, err
+	}
 	if len(resp.Errors) > 0 {
 		return AW{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
 	}
@@ -186,18 +345,66 @@ func (s AWsService) Update(ctx context.Context, aw AW) (AW, error) {
 	return resp.AWs[0], nil
 }
 
+// Delete deletes the AW. If the server keeps deleted resources for a
+// retention window, it can be restored until then.
 func (s AWsService) Delete(ctx context.Context, id string) error {
-	if id == "" {
-		return errors.New("id must be specified")
+	return s.delete(ctx, id, false)
+}
+
+// ForceDelete deletes the AW permanently, skipping any retention
+// window.
+func (s AWsService) ForceDelete(ctx context.Context, id string) error {
+	return s.delete(ctx, id, true)
+}
+
+// StartDelete asks the server to delete the AW in the background,
+// returning the Operation to wait on. Servers that don't support operations
+// delete it straight away, and the Operation returned has already
+// succeeded.
+func (s AWsService) StartDelete(ctx context.Context, id string) (Operation, error) {
+	req, err := s.deleteRequest(ctx, id, false)
+	if err != nil {
+		return Operation{}, err
 	}
-	req, err := s.client.NewRequest(ctx, http.MethodGet, s.buildURL("/"+id), nil)
+	op, res, err := s.client.startOperation(req)
+	if err != nil || res == nil {
+		return op, err
+	}
+	err = s.deleteResponse(res)
 	if err != nil {
-		return fmt.Errorf("error constructing request: %w", err)
+		return Operation{}, err
+	}
+	return completedOperation("aw", "delete", id), nil
+}
+
+func (s AWsService) delete(ctx context.Context, id string, force bool) error {
+	req, err := s.deleteRequest(ctx, id, force)
+	if err != nil {
+		return err
 	}
 	res, err := s.client.Do(req)
 	if err != nil {
 		return fmt.Errorf("error making request: %w", err)
 	}
+	return s.deleteResponse(res)
+}
+
+func (s AWsService) deleteRequest(ctx context.Context, id string, force bool) (*http.Request, error) {
+	if id == "" {
+		return nil, errors.New("id must be specified")
+	}
+	u := s.buildURL("/" + id)
+	if force {
+		u += "?force=true"
+	}
+	req, err := s.client.NewRequest(ctx, http.MethodDelete, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	return req, nil
+}
+
+func (s AWsService) deleteResponse(res *http.Response) error {
 	resp, err := responseFromBody(res)
 	if err != nil {
 		return err
@@ -217,3 +424,116 @@ func (s AWsService) Delete(ctx context.Context, id string) error {
 	}
 	return nil
 }
+
+func (s AWsService) Revisions(ctx context.Context, id string) ([]Revision, error) {
+	if id == "" {
+		return nil, errors.New("id must be specified")
+	}
+	return s.client.listRevisions(ctx, s.buildURL("/"+id), ErrAWNotFound)
+}
+
+func (s AWsService) GetRevision(ctx context.Context, id string, revision int) (AW, error) {
+	if id == "" {
+		return AW{}, errors.New("id must be specified")
+	}
+	resp, err := s.client.getRevision(ctx, s.buildURL("/"+id), revision)
+	if err != nil {
+		return AW{}, err
+	}
+	if len(resp.AWs) < 1 {
+		return AW{}, errors.New("no AW returned in response")
+	}
+	return resp.AWs[0], nil
+}
+
+func (s AWsService) List(ctx context.Context, opts ListOptions) ([]AW, error) {
+	req, err := s.client.NewRequest(ctx, http.MethodGet, s.buildURL("/")+opts.query(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Param: "deleted",
+	}) {
+		return nil, errors.New("deleted must be \"include\" or \"only\"")
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.AWs, nil
+}
+
+// Restore undoes a Delete of the AW, as long as it hasn't been purged.
+func (s AWsService) Restore(ctx context.Context, id string) (AW, error) {
+	if id == "" {
+		return AW{}, errors.New("id must be specified")
+	}
+	req, err := s.client.NewRequest(ctx, http.MethodPost, s.buildURL("/"+id+":restore"), nil)
+	if err != nil {
+		return AW{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return AW{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return AW{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return AW{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return AW{}, ErrAWNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Param: "id",
+	}) {
+		return AW{}, errors.New("AW is not deleted")
+	}
+	err = resp.quotaError()
+	if err != nil {
+		return AW{}, err
+	}
+	if len(resp.Errors) > 0 {
+		return AW{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.AWs) < 1 {
+		return AW{}, errors.New("no AW returned in response")
+	}
+	return resp.AWs[0], nil
+}
+
+// Watch blocks until the AW has changed since index, or wait has
+// elapsed, then returns it along with the index to pass to the next call. An
+// index of 0 returns immediately.
+func (s AWsService) Watch(ctx context.Context, id string, index uint64, wait time.Duration) (AW, uint64, error) {
+	if id == "" {
+		return AW{}, 0, errors.New("id must be specified")
+	}
+	resp, next, err := s.client.blockingGet(ctx, s.buildURL("/"+id), index, wait, ErrAWNotFound)
+	if err != nil {
+		return AW{}, 0, err
+	}
+	if len(resp.AWs) < 1 {
+		return AW{}, 0, errors.New("no AW returned in response")
+	}
+	return resp.AWs[0], next, nil
+}
@@ -0,0 +1,59 @@
+package edison
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// blockingGet makes a blocking query for the resource at u, returning the
+// response and the X-Edison-Index it was served at.
+func (c Client) blockingGet(ctx context.Context, u string, index uint64, wait time.Duration, notFound error) (Response, uint64, error) {
+	q := url.Values{}
+	if index > 0 {
+		q.Set("index", strconv.FormatUint(index, 10))
+		if wait > 0 {
+			q.Set("wait", wait.String())
+		}
+	}
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	req, err := c.NewRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return Response{}, 0, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := c.Do(req)
+	if err != nil {
+		return Response{}, 0, fmt.Errorf("error making request: %w", err)
+	}
+	next, _ := strconv.ParseUint(res.Header.Get("X-Edison-Index"), 10, 64)
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return Response{}, 0, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return Response{}, 0, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return Response{}, 0, notFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidFormat,
+		Param: "wait",
+	}) {
+		return Response{}, 0, errors.New("wait must be a positive duration")
+	}
+	if len(resp.Errors) > 0 {
+		return Response{}, 0, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp, next, nil
+}
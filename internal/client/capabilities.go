@@ -0,0 +1,113 @@
+package edison
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// APIVersion is the version of the API the Client speaks.
+const APIVersion = "v1"
+
+// ErrCapabilitiesNotFound is returned when the server predates /capabilities,
+// and so only serves the unversioned routes.
+var ErrCapabilitiesNotFound = errors.New("server doesn't report its capabilities")
+
+// Version is a version of the API the server serves.
+type Version struct {
+	Name         string `json:"name"`
+	Path         string `json:"path"`
+	DeprecatedAt string `json:"deprecated_at,omitempty"`
+	SunsetAt     string `json:"sunset_at,omitempty"`
+}
+
+// Capabilities describes the API versions and features the server supports.
+type Capabilities struct {
+	Versions []Version `json:"versions"`
+	Features []string  `json:"features"`
+}
+
+// Version returns the named version, if the server serves it.
+func (c Capabilities) Version(name string) (Version, bool) {
+	for _, v := range c.Versions {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return Version{}, false
+}
+
+// Newest returns the name of the newest version the server serves.
+func (c Capabilities) Newest() string {
+	var newest string
+	for _, v := range c.Versions {
+		if newest == "" || CompareVersions(v.Name, newest) > 0 {
+			newest = v.Name
+		}
+	}
+	return newest
+}
+
+// Supports reports whether the server has the named feature enabled.
+func (c Capabilities) Supports(feature string) bool {
+	for _, f := range c.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// CompareVersions returns -1, 0, or 1 depending on whether version a is
+// older than, the same as, or newer than version b.
+func CompareVersions(a, b string) int {
+	an, _ := strconv.Atoi(strings.TrimPrefix(a, "v"))
+	bn, _ := strconv.Atoi(strings.TrimPrefix(b, "v"))
+	switch {
+	case an < bn:
+		return -1
+	case an > bn:
+		return 1
+	}
+	return 0
+}
+
+// SetAPIVersion changes the version of the API the Client makes requests
+// to. An empty version uses the unversioned routes.
+func (c *Client) SetAPIVersion(version string) {
+	c.version = version
+}
+
+// Capabilities returns the API versions and features the server supports.
+func (c Client) Capabilities(ctx context.Context) (Capabilities, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, c.baseURL, "capabilities", nil)
+	if err != nil {
+		return Capabilities{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := c.Do(req)
+	if err != nil {
+		return Capabilities{}, fmt.Errorf("error making request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return Capabilities{}, ErrCapabilitiesNotFound
+	}
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return Capabilities{}, fmt.Errorf("error reading response body: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return Capabilities{}, fmt.Errorf("unexpected status %d: %s", res.StatusCode, b)
+	}
+	var caps Capabilities
+	err = json.Unmarshal(b, &caps)
+	if err != nil {
+		return Capabilities{}, fmt.Errorf("error parsing response body: %w", err)
+	}
+	return caps, nil
+}
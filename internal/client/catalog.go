@@ -0,0 +1,162 @@
+package edison
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+)
+
+const (
+	ReleasePreview    = "preview"
+	ReleaseGA         = "ga"
+	ReleaseDeprecated = "deprecated"
+	ReleaseEOL        = "eol"
+)
+
+var ErrReleaseNotFound = errors.New("release not in catalog")
+
+// Release is an EHS release, and the regions it's available in.
+type Release struct {
+	Name    string   `json:"name"`
+	Version string   `json:"version"`
+	Status  string   `json:"status"`
+	Regions []string `json:"regions"`
+}
+
+// Region is somewhere EHS Clusters can run, and the releases and profiles
+// available there.
+type Region struct {
+	Name     string   `json:"name"`
+	Location string   `json:"location"`
+	Releases []string `json:"releases"`
+	Profiles []string `json:"profiles"`
+}
+
+// Profile is an EHS Cluster size, the capacity a cluster of that size has,
+// and the regions it's available in.
+type Profile struct {
+	Name       string   `json:"name"`
+	Nodes      int      `json:"nodes"`
+	VCPU       int      `json:"vcpu"`
+	MemoryGB   int      `json:"memory_gb"`
+	MaxAWSeats int      `json:"max_aw_seats"`
+	Regions    []string `json:"regions"`
+}
+
+// InstanceSize is the size of a node pool's nodes, and what each one adds
+// to its EHS Cluster.
+type InstanceSize struct {
+	Name     string `json:"name"`
+	VCPU     int    `json:"vcpu"`
+	MemoryGB int    `json:"memory_gb"`
+	GPUs     int    `json:"gpus"`
+	AWSeats  int    `json:"aw_seats"`
+}
+
+type CatalogService struct {
+	basePath string
+	client   *Client
+}
+
+func newCatalogService(basePath string, client *Client) *CatalogService {
+	return &CatalogService{
+		basePath: basePath,
+		client:   client,
+	}
+}
+
+func (s CatalogService) buildURL(p string) string {
+	return path.Join(s.basePath, p)
+}
+
+// Releases returns the releases available in region with the given status.
+// Empty arguments don't filter.
+func (s CatalogService) Releases(ctx context.Context, region, status string) ([]Release, error) {
+	q := url.Values{}
+	if region != "" {
+		q.Set("region", region)
+	}
+	if status != "" {
+		q.Set("status", status)
+	}
+	resp, err := s.list(ctx, "/releases", q)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Releases, nil
+}
+
+// Release returns the named release, or ErrReleaseNotFound if it isn't in
+// the catalog.
+func (s CatalogService) Release(ctx context.Context, name string) (Release, error) {
+	releases, err := s.Releases(ctx, "", "")
+	if err != nil {
+		return Release{}, err
+	}
+	for _, release := range releases {
+		if release.Name == name {
+			return release, nil
+		}
+	}
+	return Release{}, ErrReleaseNotFound
+}
+
+func (s CatalogService) Regions(ctx context.Context) ([]Region, error) {
+	resp, err := s.list(ctx, "/regions", nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Regions, nil
+}
+
+// Profiles returns the profiles available in region or, if it's empty, every
+// profile.
+func (s CatalogService) Profiles(ctx context.Context, region string) ([]Profile, error) {
+	q := url.Values{}
+	if region != "" {
+		q.Set("region", region)
+	}
+	resp, err := s.list(ctx, "/profiles", q)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Profiles, nil
+}
+
+func (s CatalogService) InstanceSizes(ctx context.Context) ([]InstanceSize, error) {
+	resp, err := s.list(ctx, "/instancesizes", nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.InstanceSizes, nil
+}
+
+func (s CatalogService) list(ctx context.Context, p string, q url.Values) (Response, error) {
+	u := s.buildURL(p)
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	req, err := s.client.NewRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return Response{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return Response{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return Response{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return Response{}, errors.New("server error")
+	}
+	if len(resp.Errors) > 0 {
+		return Response{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp, nil
+}
@@ -2,10 +2,16 @@ package edison
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/hashicorp/go-cleanhttp"
 )
@@ -13,6 +19,7 @@ import (
 type Client struct {
 	client  *http.Client
 	baseURL *url.URL
+	version string
 
 	token string
 
@@ -20,31 +27,107 @@ type Client struct {
 	EHSClusters *EHSClustersService
 	AWs         *AWsService
 	AVs         *AVsService
+	Audit       *AuditService
+	Webhooks    *WebhooksService
+	Quotas      *QuotasService
+	Operations  *OperationsService
+	Upgrades    *UpgradesService
+	Migrations  *MigrationsService
+	Catalog     *CatalogService
+	NodePools   *NodePoolsService
+	AWSchedules *AWSchedulesService
 }
 
-func NewClient(baseURL, token string) (*Client, error) {
+// TransportConfig controls how the Client connects to the API.
+type TransportConfig struct {
+	CACertFile         string
+	ClientCertFile     string
+	ClientKeyFile      string
+	InsecureSkipVerify bool
+	ProxyURL           string
+}
+
+func NewClient(baseURL, token string, config TransportConfig) (*Client, error) {
 	base, err := url.Parse(baseURL)
 	if err != nil {
 		return nil, err
 	}
+	transport, err := newTransport(config)
+	if err != nil {
+		return nil, err
+	}
 	c := &Client{
-		client:  cleanhttp.DefaultPooledClient(),
+		client:  &http.Client{Transport: transport},
 		baseURL: base,
+		version: APIVersion,
 		token:   token,
 	}
 	c.EAStores = newEAStoreService("eastores", c)
 	c.EHSClusters = newEHSClusterService("ehsclusters", c)
 	c.AWs = newAWService("aws", c)
 	c.AVs = newAVService("avs", c)
+	c.Audit = newAuditService("audit", c)
+	c.Webhooks = newWebhookService("webhooks", c)
+	c.Quotas = newQuotasService("quotas", c)
+	c.Operations = newOperationsService("operations", c)
+	c.Upgrades = newUpgradesService("upgrades", c)
+	c.Migrations = newMigrationsService("migrations", c)
+	c.Catalog = newCatalogService("catalog", c)
+	c.NodePools = newNodePoolsService("ehsclusters", c)
+	c.AWSchedules = newAWSchedulesService("awschedules", c)
 	return c, nil
 }
 
+func newTransport(config TransportConfig) (*http.Transport, error) {
+	transport := cleanhttp.DefaultPooledTransport()
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: config.InsecureSkipVerify, //nolint:gosec
+	}
+	if config.CACertFile != "" {
+		pem, err := ioutil.ReadFile(config.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificates found in " + config.CACertFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if config.ClientCertFile != "" || config.ClientKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.ClientCertFile, config.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	transport.TLSClientConfig = tlsConfig
+	if config.ProxyURL != "" {
+		proxy, err := url.Parse(config.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing proxy URL: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	return transport, nil
+}
+
+// NewRequest builds a request for path, relative to the version of the API
+// the Client uses.
 func (c Client) NewRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	base := c.baseURL
+	if c.version != "" {
+		base = base.ResolveReference(&url.URL{Path: c.version + "/"})
+	}
+	return c.newRequest(ctx, method, base, path, body)
+}
+
+func (c Client) newRequest(ctx context.Context, method string, base *url.URL, path string, body io.Reader) (*http.Request, error) {
 	u, err := url.Parse(path)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing path: %w", err)
 	}
-	reqURL := c.baseURL.ResolveReference(u)
+	reqURL := base.ResolveReference(u)
 	req, err := http.NewRequestWithContext(ctx, method, reqURL.String(), body)
 	if err != nil {
 		return nil, err
@@ -57,6 +140,56 @@ func (c Client) NewRequest(ctx context.Context, method, path string, body io.Rea
 	return req, nil
 }
 
+// rateLimitRetries is how many times a rate limited request is retried
+// before the 429 is returned to the caller.
+const rateLimitRetries = 5
+
+// Do sends req. If the API rate limits the request, Do waits as long as the
+// Retry-After header asks and tries again.
 func (c Client) Do(req *http.Request) (*http.Response, error) {
-	return c.client.Do(req)
+	for attempt := 0; ; attempt++ {
+		res, err := c.client.Do(req)
+		if err != nil || res.StatusCode != http.StatusTooManyRequests || attempt >= rateLimitRetries {
+			return res, err
+		}
+		wait, ok := retryAfter(res.Header.Get("Retry-After"), time.Now())
+		if !ok || (req.Body != nil && req.GetBody == nil) {
+			return res, nil
+		}
+		res.Body.Close()
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+		req = req.Clone(req.Context())
+		if req.GetBody != nil {
+			req.Body, err = req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// retryAfter parses a Retry-After header, which is either a number of
+// seconds or an HTTP date.
+func retryAfter(header string, now time.Time) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(header); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	t, err := http.ParseTime(header)
+	if err != nil {
+		return 0, false
+	}
+	if t.Before(now) {
+		return 0, true
+	}
+	return t.Sub(now), true
 }
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"net/http"
 	"path"
+	"time"
 )
 
 var (
@@ -27,16 +28,17 @@ func newEAStoreService(basePath string, client *Client) *EAStoresService {
 }
 
 type EAStore struct {
-	ID               string `json:"id,omitempty"`
-	PartitionSpaceTB int64  `json:"partition_space_tb"`
-	IPAddress        string `json:"ip_address,omitempty"` //omitempty allows for null, aka null value
-	IPPort           string `json:"ip_port,omitempty"`
-	AET              string `json:"aet,omitempty"`
-	AccountID        string `json:"account_id,omitempty"`
-	ServiceEP        string `json:"service_ep,omitempty"`
-	CreatedAt        string `json:"created_at,omitempty"`
-	UpdatedAt        string `json:"updated_at,omitempty"`
-	//DeletedAt        sql.NullString `db:"deleted_at" json:"-"`
+	ID               string            `json:"id,omitempty"`
+	PartitionSpaceTB int64             `json:"partition_space_tb"`
+	IPAddress        string            `json:"ip_address,omitempty"` //omitempty allows for null, aka null value
+	IPPort           string            `json:"ip_port,omitempty"`
+	AET              string            `json:"aet,omitempty"`
+	AccountID        string            `json:"account_id,omitempty"`
+	ServiceEP        string            `json:"service_ep,omitempty"`
+	Labels           map[string]string `json:"labels,omitempty"`
+	CreatedAt        string            `json:"created_at,omitempty"`
+	UpdatedAt        string            `json:"updated_at,omitempty"`
+	DeletedAt        string            `json:"deleted_at,omitempty"`
 }
 
 func (s EAStoresService) buildURL(p string) string {
@@ -44,19 +46,51 @@ func (s EAStoresService) buildURL(p string) string {
 }
 
 func (s EAStoresService) Create(ctx context.Context, eastore EAStore) (EAStore, error) {
+	req, err := s.createRequest(ctx, eastore)
+	if err != nil {
+		return EAStore{}, err
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return EAStore{}, fmt.Errorf("error making request: %w", err)
+	}
+	return s.createResponse(res)
+}
+
+// StartCreate asks the server to create the EA Store in the background,
+// returning the Operation to wait on. Servers that don't support operations
+// create it straight away, and the Operation returned has already
+// succeeded.
+func (s EAStoresService) StartCreate(ctx context.Context, eastore EAStore) (Operation, error) {
+	req, err := s.createRequest(ctx, eastore)
+	if err != nil {
+		return Operation{}, err
+	}
+	op, res, err := s.client.startOperation(req)
+	if err != nil || res == nil {
+		return op, err
+	}
+	created, err := s.createResponse(res)
+	if err != nil {
+		return Operation{}, err
+	}
+	return completedOperation("eastore", "create", created.ID), nil
+}
+
+func (s EAStoresService) createRequest(ctx context.Context, eastore EAStore) (*http.Request, error) {
 	b, err := json.Marshal(eastore)
 	if err != nil {
-		return EAStore{}, fmt.Errorf("error serialising eastore: %w", err)
+		return nil, fmt.Errorf("error serialising eastore: %w", err)
 	}
 	buf := bytes.NewBuffer(b)
 	req, err := s.client.NewRequest(ctx, http.MethodPost, s.buildURL("/"), buf)
 	if err != nil {
-		return EAStore{}, fmt.Errorf("error constructing request: %w", err)
-	}
-	res, err := s.client.Do(req)
-	if err != nil {
-		return EAStore{}, fmt.Errorf("error making request: %w", err)
+		return nil, fmt.Errorf("error constructing request: %w", err)
 	}
+	return req, nil
+}
+
+func (s EAStoresService) createResponse(res *http.Response) (EAStore, error) {
 	resp, err := responseFromBody(res)
 	if err != nil {
 		return EAStore{}, err
@@ -80,6 +114,10 @@ func (s EAStoresService) Create(ctx context.Context, eastore EAStore) (EAStore,
 	}) {
 		return EAStore{}, errors.New("partition_space_tb must be set")
 	}
+	err = resp.quotaError()
+	if err != nil {
+		return EAStore{}, err
+	}
 	if len(resp.Errors) > 0 {
 		return EAStore{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
 	}
@@ -125,22 +163,54 @@ func (s EAStoresService) Get(ctx context.Context, id string) (EAStore, error) {
 }
 
 func (s EAStoresService) Update(ctx context.Context, eastore EAStore) (EAStore, error) {
+	req, err := s.updateRequest(ctx, eastore)
+	if err != nil {
+		return EAStore{}, err
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return EAStore{}, fmt.Errorf("error making request: %w", err)
+	}
+	return s.updateResponse(res)
+}
+
+// StartUpdate asks the server to update the EA Store in the background,
+// returning the Operation to wait on. Servers that don't support operations
+// update it straight away, and the Operation returned has already
+// succeeded.
+func (s EAStoresService) StartUpdate(ctx context.Context, eastore EAStore) (Operation, error) {
+	req, err := s.updateRequest(ctx, eastore)
+	if err != nil {
+		return Operation{}, err
+	}
+	op, res, err := s.client.startOperation(req)
+	if err != nil || res == nil {
+		return op, err
+	}
+	_, err = s.updateResponse(res)
+	if err != nil {
+		return Operation{}, err
+	}
+	return completedOperation("eastore", "update", eastore.ID), nil
+}
+
+func (s EAStoresService) updateRequest(ctx context.Context, eastore EAStore) (*http.Request, error) {
 	if eastore.ID == "" {
-		return EAStore{}, errors.New("id must be specified")
+		return nil, errors.New("id must be specified")
 	}
 	b, err := json.Marshal(eastore)
 	if err != nil {
-		return EAStore{}, fmt.Errorf("error serialising EA Store: %w", err)
+		return nil, fmt.Errorf("error serialising EA Store: %w", err)
 	}
 	buf := bytes.NewBuffer(b)
 	req, err := s.client.NewRequest(ctx, http.MethodPut, s.buildURL("/"+eastore.ID), buf)
 	if err != nil {
-		return EAStore{}, fmt.Errorf("error constructing request: %w", err)
-	}
-	res, err := s.client.Do(req)
-	if err != nil {
-		return EAStore{}, fmt.Errorf("error making request: %w", err)
+		return nil, fmt.Errorf("error constructing request: %w", err)
 	}
+	return req, nil
+}
+
+func (s EAStoresService) updateResponse(res *http.Response) (EAStore, error) {
 	resp, err := responseFromBody(res)
 	if err != nil {
 		return EAStore{}, err
@@ -164,6 +234,10 @@ func (s EAStoresService) Update(ctx context.Context, eastore EAStore) (EAStore,
 	}) {
 		return EAStore{}, errors.New("EA Store partition_space_tb must be set")
 	}
+	err = resp.quotaError()
+	if err != nil {
+		return EAStore{}, err
+	}
 	if len(resp.Errors) > 0 {
 		return EAStore{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
 	}
@@ -173,18 +247,66 @@ func (s EAStoresService) Update(ctx context.Context, eastore EAStore) (EAStore,
 	return resp.EAStores[0], nil
 }
 
+// Delete deletes the EAStore. If the server keeps deleted resources for a
+// retention window, it can be restored until then.
 func (s EAStoresService) Delete(ctx context.Context, id string) error {
-	if id == "" {
-		return errors.New("id must be specified")
+	return s.delete(ctx, id, false)
+}
+
+// ForceDelete deletes the EAStore permanently, skipping any retention
+// window.
+func (s EAStoresService) ForceDelete(ctx context.Context, id string) error {
+	return s.delete(ctx, id, true)
+}
+
+// StartDelete asks the server to delete the EA Store in the background,
+// returning the Operation to wait on. Servers that don't support operations
+// delete it straight away, and the Operation returned has already
+// succeeded.
+func (s EAStoresService) StartDelete(ctx context.Context, id string) (Operation, error) {
+	req, err := s.deleteRequest(ctx, id, false)
+	if err != nil {
+		return Operation{}, err
 	}
-	req, err := s.client.NewRequest(ctx, http.MethodGet, s.buildURL("/"+id), nil)
+	op, res, err := s.client.startOperation(req)
+	if err != nil || res == nil {
+		return op, err
+	}
+	err = s.deleteResponse(res)
+	if err != nil {
+		return Operation{}, err
+	}
+	return completedOperation("eastore", "delete", id), nil
+}
+
+func (s EAStoresService) delete(ctx context.Context, id string, force bool) error {
+	req, err := s.deleteRequest(ctx, id, force)
 	if err != nil {
-		return fmt.Errorf("error constructing request: %w", err)
+		return err
 	}
 	res, err := s.client.Do(req)
 	if err != nil {
 		return fmt.Errorf("error making request: %w", err)
 	}
+	return s.deleteResponse(res)
+}
+
+func (s EAStoresService) deleteRequest(ctx context.Context, id string, force bool) (*http.Request, error) {
+	if id == "" {
+		return nil, errors.New("id must be specified")
+	}
+	u := s.buildURL("/" + id)
+	if force {
+		u += "?force=true"
+	}
+	req, err := s.client.NewRequest(ctx, http.MethodDelete, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	return req, nil
+}
+
+func (s EAStoresService) deleteResponse(res *http.Response) error {
 	resp, err := responseFromBody(res)
 	if err != nil {
 		return err
@@ -204,3 +326,116 @@ func (s EAStoresService) Delete(ctx context.Context, id string) error {
 	}
 	return nil
 }
+
+func (s EAStoresService) Revisions(ctx context.Context, id string) ([]Revision, error) {
+	if id == "" {
+		return nil, errors.New("id must be specified")
+	}
+	return s.client.listRevisions(ctx, s.buildURL("/"+id), ErrEAStoreNotFound)
+}
+
+func (s EAStoresService) GetRevision(ctx context.Context, id string, revision int) (EAStore, error) {
+	if id == "" {
+		return EAStore{}, errors.New("id must be specified")
+	}
+	resp, err := s.client.getRevision(ctx, s.buildURL("/"+id), revision)
+	if err != nil {
+		return EAStore{}, err
+	}
+	if len(resp.EAStores) < 1 {
+		return EAStore{}, errors.New("no EAStore returned in response")
+	}
+	return resp.EAStores[0], nil
+}
+
+func (s EAStoresService) List(ctx context.Context, opts ListOptions) ([]EAStore, error) {
+	req, err := s.client.NewRequest(ctx, http.MethodGet, s.buildURL("/")+opts.query(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Param: "deleted",
+	}) {
+		return nil, errors.New("deleted must be \"include\" or \"only\"")
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.EAStores, nil
+}
+
+// Restore undoes a Delete of the EAStore, as long as it hasn't been purged.
+func (s EAStoresService) Restore(ctx context.Context, id string) (EAStore, error) {
+	if id == "" {
+		return EAStore{}, errors.New("id must be specified")
+	}
+	req, err := s.client.NewRequest(ctx, http.MethodPost, s.buildURL("/"+id+":restore"), nil)
+	if err != nil {
+		return EAStore{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return EAStore{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return EAStore{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return EAStore{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return EAStore{}, ErrEAStoreNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Param: "id",
+	}) {
+		return EAStore{}, errors.New("EAStore is not deleted")
+	}
+	err = resp.quotaError()
+	if err != nil {
+		return EAStore{}, err
+	}
+	if len(resp.Errors) > 0 {
+		return EAStore{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.EAStores) < 1 {
+		return EAStore{}, errors.New("no EAStore returned in response")
+	}
+	return resp.EAStores[0], nil
+}
+
+// Watch blocks until the EAStore has changed since index, or wait has
+// elapsed, then returns it along with the index to pass to the next call. An
+// index of 0 returns immediately.
+func (s EAStoresService) Watch(ctx context.Context, id string, index uint64, wait time.Duration) (EAStore, uint64, error) {
+	if id == "" {
+		return EAStore{}, 0, errors.New("id must be specified")
+	}
+	resp, next, err := s.client.blockingGet(ctx, s.buildURL("/"+id), index, wait, ErrEAStoreNotFound)
+	if err != nil {
+		return EAStore{}, 0, err
+	}
+	if len(resp.EAStores) < 1 {
+		return EAStore{}, 0, errors.New("no EAStore returned in response")
+	}
+	return resp.EAStores[0], next, nil
+}
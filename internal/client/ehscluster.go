@@ -8,10 +8,32 @@ import (
 	"fmt"
 	"net/http"
 	"path"
+	"time"
 )
 
 var (
 	ErrEHSClusterNotFound = errors.New("ehscluster not found")
+	// ErrEHSClusterBusy is returned when the release or profile can't be
+	// changed because the EHS Cluster is already upgrading or resizing, or
+	// AWs on it are scaling, or when an upgrade or resize started or
+	// finished while it was being updated.
+	ErrEHSClusterBusy = errors.New("EHS Cluster is upgrading or resizing, or its AWs are scaling")
+	// ErrInvalidUpgradePath is returned when the release can't be upgraded
+	// to directly. Upgrades go to newer releases in the catalog, one major
+	// version at a time.
+	ErrInvalidUpgradePath = errors.New("EHS Cluster can't be upgraded to that release")
+	// ErrReleaseEndOfLife is returned when creating an EHS Cluster on an EOL
+	// release.
+	ErrReleaseEndOfLife = errors.New("EHS Cluster release is end of life")
+	// ErrUnknownProfile is returned when resizing to a profile that isn't
+	// in the catalog.
+	ErrUnknownProfile = errors.New("EHS Cluster profile isn't in the catalog")
+	// ErrProfileTooSmall is returned when resizing to a profile without room
+	// for the AWs the EHS Cluster hosts.
+	ErrProfileTooSmall = errors.New("EHS Cluster profile doesn't have room for the AWs it hosts")
+	// ErrInvalidAutoscaling is wrapped by errors describing an invalid
+	// autoscaling setting.
+	ErrInvalidAutoscaling = errors.New("invalid autoscaling")
 )
 
 type EHSClustersService struct {
@@ -34,10 +56,68 @@ type EHSCluster struct {
 	Tag         string `json:"tag"`
 	ClusterName string `json:"cluster_name"`
 	//DicomEndPoint     string `json:"dicom_endpoint"`
-	APIServerEndPoint string `json:"api_server_endpoint,omitempty"`
-	VPC               string `json:"vpc,omitempty"`
-	CreatedAt         string `json:"created_at,omitempty"`
-	UpdatedAt         string `json:"updated_at,omitempty"`
+	APIServerEndPoint string            `json:"api_server_endpoint,omitempty"`
+	VPC               string            `json:"vpc,omitempty"`
+	AccountID         string            `json:"account_id,omitempty"`
+	Status            string            `json:"status,omitempty"`
+	TargetRelease     string            `json:"target_release,omitempty"`
+	UpgradeID         string            `json:"upgrade_id,omitempty"`
+	TargetProfile     string            `json:"target_profile,omitempty"`
+	OperationID       string            `json:"operation_id,omitempty"`
+	Autoscaling       *Autoscaling      `json:"autoscaling,omitempty"`
+	CurrentNodes      int               `json:"current_nodes,omitempty"`
+	DesiredNodes      int               `json:"desired_nodes,omitempty"`
+	LastScaledAt      string            `json:"last_scaled_at,omitempty"`
+	Capacity          *Capacity         `json:"capacity,omitempty"`
+	Labels            map[string]string `json:"labels,omitempty"`
+	CreatedAt         string            `json:"created_at,omitempty"`
+	UpdatedAt         string            `json:"updated_at,omitempty"`
+	DeletedAt         string            `json:"deleted_at,omitempty"`
+}
+
+// Autoscaling scales the number of its profile's nodes an EHS Cluster runs
+// between MinNodes and MaxNodes, keeping the AW seats it hosts at
+// TargetUtilization percent of what those nodes can host. Nodes are only
+// removed once ScaleInCooldown, a duration like "10m", has passed since the
+// cluster last scaled.
+type Autoscaling struct {
+	MinNodes          int    `json:"min_nodes"`
+	MaxNodes          int    `json:"max_nodes"`
+	TargetUtilization int    `json:"target_utilization"`
+	ScaleInCooldown   string `json:"scale_in_cooldown,omitempty"`
+}
+
+// autoscalingError returns an error describing the first invalid
+// autoscaling setting in resp, if there is one.
+func autoscalingError(resp Response) error {
+	for field, msg := range map[string]string{
+		"/autoscaling/min_nodes":          "min_nodes must be at least 1",
+		"/autoscaling/max_nodes":          "max_nodes must be at least min_nodes, and no more than 50",
+		"/autoscaling/target_utilization": "target_utilization must be between 1 and 100",
+		"/autoscaling/scale_in_cooldown":  "scale_in_cooldown must be a duration like \"10m\"",
+	} {
+		if resp.Errors.Contains(RequestError{
+			Slug:  requestErrInvalidValue,
+			Field: field,
+		}) {
+			return fmt.Errorf("%w: %s", ErrInvalidAutoscaling, msg)
+		}
+	}
+	return nil
+}
+
+// Capacity is how much an EHS Cluster can host.
+type Capacity struct {
+	Nodes      int `json:"nodes"`
+	VCPU       int `json:"vcpu"`
+	MemoryGB   int `json:"memory_gb"`
+	GPUs       int `json:"gpus"`
+	MaxAWSeats int `json:"max_aw_seats"`
+}
+
+// Less reports whether c is smaller than other in any way.
+func (c Capacity) Less(other Capacity) bool {
+	return c.Nodes < other.Nodes || c.VCPU < other.VCPU || c.MemoryGB < other.MemoryGB || c.GPUs < other.GPUs || c.MaxAWSeats < other.MaxAWSeats
 }
 
 func (s EHSClustersService) buildURL(p string) string {
@@ -45,19 +125,50 @@ func (s EHSClustersService) buildURL(p string) string {
 }
 
 func (s EHSClustersService) Create(ctx context.Context, ehscluster EHSCluster) (EHSCluster, error) {
-	b, err := json.Marshal(ehscluster)
-	if err != nil {
-		return EHSCluster{}, fmt.Errorf("error serialising ehscluster: %w", err)
-	}
-	buf := bytes.NewBuffer(b)
-	req, err := s.client.NewRequest(ctx, http.MethodPost, s.buildURL("/"), buf)
+	req, err := s.createRequest(ctx, ehscluster)
 	if err != nil {
-		return EHSCluster{}, fmt.Errorf("error constructing request: %w", err)
+		return EHSCluster{}, err
 	}
 	res, err := s.client.Do(req)
 	if err != nil {
 		return EHSCluster{}, fmt.Errorf("error making request: %w", err)
 	}
+	return s.createResponse(res)
+}
+
+// StartCreate asks the server to create the EHS Cluster in the background,
+// returning the Operation to wait on. Servers that don't support operations
+// create it straight away, and the Operation returned has already
+// succeeded.
+func (s EHSClustersService) StartCreate(ctx context.Context, ehscluster EHSCluster) (Operation, error) {
+	req, err := s.createRequest(ctx, ehscluster)
+	if err != nil {
+		return Operation{}, err
+	}
+	op, res, err := s.client.startOperation(req)
+	if err != nil || res == nil {
+		return op, err
+	}
+	created, err := s.createResponse(res)
+	if err != nil {
+		return Operation{}, err
+	}
+	return completedOperation("ehscluster", "create", created.ID), nil
+}
+
+func (s EHSClustersService) createRequest(ctx context.Context, ehscluster EHSCluster) (*http.Request, error) {
+	b, err := json.Marshal(ehscluster)
+	if err != nil {
+		return nil, fmt.Errorf("error serialising ehscluster: %w", err)
+	}
+	req, err := s.client.NewRequest(ctx, http.MethodPost, s.buildURL("/"), bytes.NewBuffer(b))
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	return req, nil
+}
+
+func (s EHSClustersService) createResponse(res *http.Response) (EHSCluster, error) {
 	resp, err := responseFromBody(res)
 	if err != nil {
 		return EHSCluster{}, err
@@ -99,7 +210,21 @@ func (s EHSClustersService) Create(ctx context.Context, ehscluster EHSCluster) (
 	}) {
 		return EHSCluster{}, errors.New("release must be set")
 	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/release",
+	}) {
+		return EHSCluster{}, ErrReleaseEndOfLife
+	}
 
+	err = autoscalingError(resp)
+	if err != nil {
+		return EHSCluster{}, err
+	}
+	err = resp.quotaError()
+	if err != nil {
+		return EHSCluster{}, err
+	}
 	if len(resp.Errors) > 0 {
 		return EHSCluster{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
 	}
@@ -145,22 +270,53 @@ func (s EHSClustersService) Get(ctx context.Context, id string) (EHSCluster, err
 }
 
 func (s EHSClustersService) Update(ctx context.Context, ehscluster EHSCluster) (EHSCluster, error) {
-	if ehscluster.ID == "" {
-		return EHSCluster{}, errors.New("id must be specified")
+	req, err := s.updateRequest(ctx, ehscluster)
+	if err != nil {
+		return EHSCluster{}, err
 	}
-	b, err := json.Marshal(ehscluster)
+	res, err := s.client.Do(req)
 	if err != nil {
-		return EHSCluster{}, fmt.Errorf("error serialising EHS Cluster: %w", err)
+		return EHSCluster{}, fmt.Errorf("error making request: %w", err)
 	}
-	buf := bytes.NewBuffer(b)
-	req, err := s.client.NewRequest(ctx, http.MethodPut, s.buildURL("/"+ehscluster.ID), buf)
+	return s.updateResponse(res)
+}
+
+// StartUpdate asks the server to update the EHS Cluster in the background,
+// returning the Operation to wait on. Servers that don't support operations
+// update it straight away, and the Operation returned has already
+// succeeded.
+func (s EHSClustersService) StartUpdate(ctx context.Context, ehscluster EHSCluster) (Operation, error) {
+	req, err := s.updateRequest(ctx, ehscluster)
 	if err != nil {
-		return EHSCluster{}, fmt.Errorf("error constructing request: %w", err)
+		return Operation{}, err
 	}
-	res, err := s.client.Do(req)
+	op, res, err := s.client.startOperation(req)
+	if err != nil || res == nil {
+		return op, err
+	}
+	_, err = s.updateResponse(res)
 	if err != nil {
-		return EHSCluster{}, fmt.Errorf("error making request: %w", err)
+		return Operation{}, err
+	}
+	return completedOperation("ehscluster", "update", ehscluster.ID), nil
+}
+
+func (s EHSClustersService) updateRequest(ctx context.Context, ehscluster EHSCluster) (*http.Request, error) {
+	if ehscluster.ID == "" {
+		return nil, errors.New("id must be specified")
 	}
+	b, err := json.Marshal(ehscluster)
+	if err != nil {
+		return nil, fmt.Errorf("error serialising EHS Cluster: %w", err)
+	}
+	req, err := s.client.NewRequest(ctx, http.MethodPut, s.buildURL("/"+ehscluster.ID), bytes.NewBuffer(b))
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	return req, nil
+}
+
+func (s EHSClustersService) updateResponse(res *http.Response) (EHSCluster, error) {
 	resp, err := responseFromBody(res)
 	if err != nil {
 		return EHSCluster{}, err
@@ -184,6 +340,50 @@ func (s EHSClustersService) Update(ctx context.Context, ehscluster EHSCluster) (
 	}) {
 		return EHSCluster{}, errors.New("EHS Cluster partition_space_tb must be set")
 	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/release",
+	}) {
+		return EHSCluster{}, ErrEHSClusterBusy
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/release",
+	}) {
+		return EHSCluster{}, ErrInvalidUpgradePath
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/profile",
+	}) {
+		return EHSCluster{}, ErrEHSClusterBusy
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/status",
+	}) {
+		return EHSCluster{}, ErrEHSClusterBusy
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/profile",
+	}) {
+		return EHSCluster{}, ErrUnknownProfile
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInsufficient,
+		Field: "/profile",
+	}) {
+		return EHSCluster{}, ErrProfileTooSmall
+	}
+	err = autoscalingError(resp)
+	if err != nil {
+		return EHSCluster{}, err
+	}
+	err = resp.quotaError()
+	if err != nil {
+		return EHSCluster{}, err
+	}
 	if len(resp.Errors) > 0 {
 		return EHSCluster{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
 	}
@@ -193,18 +393,66 @@ func (s EHSClustersService) Update(ctx context.Context, ehscluster EHSCluster) (
 	return resp.EHSClusters[0], nil
 }
 
+// Delete deletes the EHS Cluster. If the server keeps deleted resources for a
+// retention window, it can be restored until then.
 func (s EHSClustersService) Delete(ctx context.Context, id string) error {
-	if id == "" {
-		return errors.New("id must be specified")
+	return s.delete(ctx, id, false)
+}
+
+// ForceDelete deletes the EHS Cluster permanently, skipping any retention
+// window.
+func (s EHSClustersService) ForceDelete(ctx context.Context, id string) error {
+	return s.delete(ctx, id, true)
+}
+
+// StartDelete asks the server to delete the EHS Cluster in the background,
+// returning the Operation to wait on. Servers that don't support operations
+// delete it straight away, and the Operation returned has already
+// succeeded.
+func (s EHSClustersService) StartDelete(ctx context.Context, id string) (Operation, error) {
+	req, err := s.deleteRequest(ctx, id, false)
+	if err != nil {
+		return Operation{}, err
 	}
-	req, err := s.client.NewRequest(ctx, http.MethodGet, s.buildURL("/"+id), nil)
+	op, res, err := s.client.startOperation(req)
+	if err != nil || res == nil {
+		return op, err
+	}
+	err = s.deleteResponse(res)
 	if err != nil {
-		return fmt.Errorf("error constructing request: %w", err)
+		return Operation{}, err
+	}
+	return completedOperation("ehscluster", "delete", id), nil
+}
+
+func (s EHSClustersService) delete(ctx context.Context, id string, force bool) error {
+	req, err := s.deleteRequest(ctx, id, force)
+	if err != nil {
+		return err
 	}
 	res, err := s.client.Do(req)
 	if err != nil {
 		return fmt.Errorf("error making request: %w", err)
 	}
+	return s.deleteResponse(res)
+}
+
+func (s EHSClustersService) deleteRequest(ctx context.Context, id string, force bool) (*http.Request, error) {
+	if id == "" {
+		return nil, errors.New("id must be specified")
+	}
+	u := s.buildURL("/" + id)
+	if force {
+		u += "?force=true"
+	}
+	req, err := s.client.NewRequest(ctx, http.MethodDelete, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	return req, nil
+}
+
+func (s EHSClustersService) deleteResponse(res *http.Response) error {
 	resp, err := responseFromBody(res)
 	if err != nil {
 		return err
@@ -224,3 +472,116 @@ func (s EHSClustersService) Delete(ctx context.Context, id string) error {
 	}
 	return nil
 }
+
+func (s EHSClustersService) Revisions(ctx context.Context, id string) ([]Revision, error) {
+	if id == "" {
+		return nil, errors.New("id must be specified")
+	}
+	return s.client.listRevisions(ctx, s.buildURL("/"+id), ErrEHSClusterNotFound)
+}
+
+func (s EHSClustersService) GetRevision(ctx context.Context, id string, revision int) (EHSCluster, error) {
+	if id == "" {
+		return EHSCluster{}, errors.New("id must be specified")
+	}
+	resp, err := s.client.getRevision(ctx, s.buildURL("/"+id), revision)
+	if err != nil {
+		return EHSCluster{}, err
+	}
+	if len(resp.EHSClusters) < 1 {
+		return EHSCluster{}, errors.New("no EHS Cluster returned in response")
+	}
+	return resp.EHSClusters[0], nil
+}
+
+func (s EHSClustersService) List(ctx context.Context, opts ListOptions) ([]EHSCluster, error) {
+	req, err := s.client.NewRequest(ctx, http.MethodGet, s.buildURL("/")+opts.query(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Param: "deleted",
+	}) {
+		return nil, errors.New("deleted must be \"include\" or \"only\"")
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.EHSClusters, nil
+}
+
+// Restore undoes a Delete of the EHS Cluster, as long as it hasn't been purged.
+func (s EHSClustersService) Restore(ctx context.Context, id string) (EHSCluster, error) {
+	if id == "" {
+		return EHSCluster{}, errors.New("id must be specified")
+	}
+	req, err := s.client.NewRequest(ctx, http.MethodPost, s.buildURL("/"+id+":restore"), nil)
+	if err != nil {
+		return EHSCluster{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return EHSCluster{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return EHSCluster{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return EHSCluster{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return EHSCluster{}, ErrEHSClusterNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Param: "id",
+	}) {
+		return EHSCluster{}, errors.New("EHS Cluster is not deleted")
+	}
+	err = resp.quotaError()
+	if err != nil {
+		return EHSCluster{}, err
+	}
+	if len(resp.Errors) > 0 {
+		return EHSCluster{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.EHSClusters) < 1 {
+		return EHSCluster{}, errors.New("no EHS Cluster returned in response")
+	}
+	return resp.EHSClusters[0], nil
+}
+
+// Watch blocks until the EHS Cluster has changed since index, or wait has
+// elapsed, then returns it along with the index to pass to the next call. An
+// index of 0 returns immediately.
+func (s EHSClustersService) Watch(ctx context.Context, id string, index uint64, wait time.Duration) (EHSCluster, uint64, error) {
+	if id == "" {
+		return EHSCluster{}, 0, errors.New("id must be specified")
+	}
+	resp, next, err := s.client.blockingGet(ctx, s.buildURL("/"+id), index, wait, ErrEHSClusterNotFound)
+	if err != nil {
+		return EHSCluster{}, 0, err
+	}
+	if len(resp.EHSClusters) < 1 {
+		return EHSCluster{}, 0, errors.New("no EHS Cluster returned in response")
+	}
+	return resp.EHSClusters[0], next, nil
+}
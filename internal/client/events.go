@@ -0,0 +1,157 @@
+package edison
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	EventCreate       = "create"
+	EventUpdate       = "update"
+	EventDelete       = "delete"
+	EventPurge        = "purge"
+	EventStatusChange = "status_change"
+	EventScale        = "scale"
+	EventSchedule     = "schedule"
+)
+
+// Event is a change to a resource, carrying the Revision it produced.
+type Event struct {
+	ID       uint64
+	Type     string
+	Revision Revision
+}
+
+type EventFilter struct {
+	ResourceType string
+	ResourceID   string
+	// Types limits the stream to the given event types, like EventDelete.
+	Types []string
+}
+
+func (f EventFilter) query() string {
+	q := url.Values{}
+	if f.ResourceType != "" {
+		q.Set("resource_type", f.ResourceType)
+	}
+	if f.ResourceID != "" {
+		q.Set("resource_id", f.ResourceID)
+	}
+	for _, t := range f.Types {
+		q.Add("event", t)
+	}
+	if len(q) < 1 {
+		return ""
+	}
+	return "?" + q.Encode()
+}
+
+// Events streams changes matching filter until ctx is cancelled, at which
+// point the returned channel is closed. Dropped connections are resumed from
+// the last event received, so no events are missed.
+func (c Client) Events(ctx context.Context, filter EventFilter) (<-chan Event, error) {
+	res, err := c.openEvents(ctx, filter, "")
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan Event)
+	go func() {
+		defer close(ch)
+		var last uint64
+		for {
+			last = c.readEvents(ctx, res, ch, last)
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(time.Second):
+				}
+				res, err = c.openEvents(ctx, filter, strconv.FormatUint(last, 10))
+				if err == nil {
+					break
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func (c Client) openEvents(ctx context.Context, filter EventFilter, lastEventID string) (*http.Response, error) {
+	req, err := c.NewRequest(ctx, http.MethodGet, "events"+filter.query(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	res, err := c.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		resp, err := responseFromBody(res)
+		if err != nil {
+			return nil, err
+		}
+		if resp.Errors.Contains(RequestError{
+			Slug:  requestErrInvalidValue,
+			Param: "event",
+		}) {
+			return nil, fmt.Errorf("event types must be one of %q, %q, %q, %q, %q, %q or %q", EventCreate, EventUpdate, EventDelete, EventPurge, EventStatusChange, EventScale, EventSchedule)
+		}
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return res, nil
+}
+
+// readEvents sends every event in the stream to ch until the stream ends,
+// returning the ID of the last one.
+func (c Client) readEvents(ctx context.Context, res *http.Response, ch chan<- Event, last uint64) uint64 {
+	defer res.Body.Close()
+	scanner := bufio.NewScanner(res.Body)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var event Event
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if len(data) < 1 && event.ID > 0 {
+				last = event.ID
+			} else if len(data) > 0 && json.Unmarshal([]byte(strings.Join(data, "\n")), &event.Revision) == nil {
+				select {
+				case ch <- event:
+					last = event.ID
+				case <-ctx.Done():
+					return last
+				}
+			}
+			event = Event{}
+			data = nil
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value := line, ""
+		if pos := strings.Index(line, ":"); pos >= 0 {
+			field, value = line[:pos], strings.TrimPrefix(line[pos+1:], " ")
+		}
+		switch field {
+		case "id":
+			event.ID, _ = strconv.ParseUint(value, 10, 64)
+		case "event":
+			event.Type = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+	return last
+}
@@ -0,0 +1,28 @@
+package edison
+
+import (
+	"net/url"
+)
+
+type ListOptions struct {
+	// Deleted can be "include" to list deleted resources alongside active
+	// ones, or "only" to list just the deleted ones.
+	Deleted string
+	// Selector only lists resources whose labels match, like
+	// "env=prod,team=radiology".
+	Selector string
+}
+
+func (o ListOptions) query() string {
+	q := url.Values{}
+	if o.Deleted != "" {
+		q.Set("deleted", o.Deleted)
+	}
+	if o.Selector != "" {
+		q.Set("selector", o.Selector)
+	}
+	if len(q) < 1 {
+		return ""
+	}
+	return "?" + q.Encode()
+}
@@ -0,0 +1,160 @@
+package edison
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+)
+
+const (
+	AWReady     = "ready"
+	AWMigrating = "migrating"
+)
+
+const (
+	MigrationCutOver   = "cut_over"
+	MigrationBlueGreen = "blue_green"
+)
+
+const (
+	MigrationRunning   = "running"
+	MigrationSucceeded = "succeeded"
+	MigrationFailed    = "failed"
+)
+
+const (
+	MigrationDrain     = "drain"
+	MigrationProvision = "provision"
+	MigrationSwitchDNS = "switch_dns"
+	MigrationTeardown  = "teardown"
+)
+
+const (
+	MigrationStepPending = "pending"
+	MigrationStepRunning = "running"
+	MigrationStepDone    = "done"
+	MigrationStepFailed  = "failed"
+)
+
+var ErrMigrationNotFound = errors.New("migration not found")
+
+// Migration is a move of an AW from one EHS Cluster to another, one step
+// at a time. Its strategy decides the order of the steps. Wait on the
+// operation with the ID OperationID for it to finish.
+type Migration struct {
+	ID               string          `json:"id"`
+	AWID             string          `json:"aw_id"`
+	FromEHSClusterID string          `json:"from_ehs_cluster_id"`
+	ToEHSClusterID   string          `json:"to_ehs_cluster_id"`
+	Strategy         string          `json:"strategy"`
+	Status           string          `json:"status"`
+	Steps            []MigrationStep `json:"steps"`
+	FromDNSEndPoint  string          `json:"from_dns_endpoint,omitempty"`
+	ToDNSEndPoint    string          `json:"to_dns_endpoint"`
+	OperationID      string          `json:"operation_id,omitempty"`
+	StartedAt        string          `json:"started_at"`
+	FinishedAt       string          `json:"finished_at,omitempty"`
+}
+
+// MigrationStep is the state of one of the steps of a Migration.
+type MigrationStep struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// Done reports whether the migration has finished, one way or another.
+func (m Migration) Done() bool {
+	return m.Status == MigrationSucceeded || m.Status == MigrationFailed
+}
+
+// CurrentStep returns the name of the step that's running, or the step
+// that failed, if there is one.
+func (m Migration) CurrentStep() string {
+	for _, step := range m.Steps {
+		if step.Status == MigrationStepRunning || step.Status == MigrationStepFailed {
+			return step.Name
+		}
+	}
+	return ""
+}
+
+// MigrationFailedError describes a migration that finished without
+// succeeding.
+type MigrationFailedError struct {
+	Migration Migration
+}
+
+func (e MigrationFailedError) Error() string {
+	return fmt.Sprintf("migration %s of AW %s to EHS Cluster %s failed at %s", e.Migration.ID, e.Migration.AWID, e.Migration.ToEHSClusterID, e.Migration.CurrentStep())
+}
+
+type MigrationsService struct {
+	basePath string
+	client   *Client
+}
+
+func newMigrationsService(basePath string, client *Client) *MigrationsService {
+	return &MigrationsService{
+		basePath: basePath,
+		client:   client,
+	}
+}
+
+func (s MigrationsService) buildURL(p string) string {
+	return path.Join(s.basePath, p)
+}
+
+func (s MigrationsService) Get(ctx context.Context, id string) (Migration, error) {
+	mig, _, err := s.Watch(ctx, id, 0, 0)
+	return mig, err
+}
+
+// Watch blocks until the migration has changed since index, or wait has
+// elapsed, then returns it along with the index to pass to the next call.
+// An index of 0 returns immediately.
+func (s MigrationsService) Watch(ctx context.Context, id string, index uint64, wait time.Duration) (Migration, uint64, error) {
+	if id == "" {
+		return Migration{}, 0, errors.New("id must be specified")
+	}
+	resp, next, err := s.client.blockingGet(ctx, s.buildURL("/"+id), index, wait, ErrMigrationNotFound)
+	if err != nil {
+		return Migration{}, 0, err
+	}
+	if len(resp.Migrations) < 1 {
+		return Migration{}, 0, errors.New("no migration returned in response")
+	}
+	return resp.Migrations[0], next, nil
+}
+
+// List returns the migrations of the AW or, if awID is empty, of every
+// AW.
+func (s MigrationsService) List(ctx context.Context, awID string) ([]Migration, error) {
+	u := s.buildURL("/")
+	if awID != "" {
+		u += "?" + url.Values{"aw_id": {awID}}.Encode()
+	}
+	req, err := s.client.NewRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.Migrations, nil
+}
@@ -0,0 +1,240 @@
+package edison
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"regexp"
+)
+
+const (
+	NodePoolReady   = "ready"
+	NodePoolScaling = "scaling"
+)
+
+const (
+	TaintNoSchedule       = "NoSchedule"
+	TaintPreferNoSchedule = "PreferNoSchedule"
+	TaintNoExecute        = "NoExecute"
+)
+
+var (
+	ErrNodePoolNotFound = errors.New("node pool not found")
+	// ErrNodePoolBusy is returned when the desired count is changed while
+	// the node pool is still scaling to the last one.
+	ErrNodePoolBusy = errors.New("node pool is already scaling")
+	// ErrNodePoolTooSmall is returned when scaling in or deleting a node
+	// pool would leave its EHS Cluster without room for the AWs it hosts.
+	ErrNodePoolTooSmall = errors.New("EHS Cluster wouldn't have room for the AWs it hosts")
+	// ErrInstanceSizeChanged is returned when updating a node pool's
+	// instance size, which can only be set when the pool is created.
+	ErrInstanceSizeChanged = errors.New("instance_size can't be changed; replace the node pool instead")
+
+	nodePoolTaintKeyField    = regexp.MustCompile(`^/taints/[0-9]+/key$`)
+	nodePoolTaintEffectField = regexp.MustCompile(`^/taints/[0-9]+/effect$`)
+)
+
+type NodePoolsService struct {
+	basePath string
+	client   *Client
+}
+
+func newNodePoolsService(basePath string, client *Client) *NodePoolsService {
+	return &NodePoolsService{
+		basePath: basePath,
+		client:   client,
+	}
+}
+
+// NodePool is a group of identically sized nodes in an EHS Cluster, on top
+// of the nodes its profile runs. CurrentCount catches up with DesiredCount
+// as the pool scales.
+type NodePool struct {
+	ID           string            `json:"id,omitempty"`
+	EHSClusterID string            `json:"ehs_cluster_id,omitempty"`
+	Name         string            `json:"name"`
+	InstanceSize string            `json:"instance_size"`
+	MinCount     int               `json:"min_count"`
+	MaxCount     int               `json:"max_count"`
+	DesiredCount int               `json:"desired_count"`
+	CurrentCount int               `json:"current_count"`
+	Status       string            `json:"status,omitempty"`
+	OperationID  string            `json:"operation_id,omitempty"`
+	Taints       []Taint           `json:"taints,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	CreatedAt    string            `json:"created_at,omitempty"`
+	UpdatedAt    string            `json:"updated_at,omitempty"`
+}
+
+type Taint struct {
+	Key    string `json:"key"`
+	Value  string `json:"value,omitempty"`
+	Effect string `json:"effect"`
+}
+
+func (s NodePoolsService) buildURL(clusterID, p string) string {
+	return path.Join(s.basePath, clusterID, "nodepools", p)
+}
+
+func (s NodePoolsService) do(ctx context.Context, method, u string, pool *NodePool) (Response, error) {
+	var body io.Reader
+	if pool != nil {
+		b, err := json.Marshal(pool)
+		if err != nil {
+			return Response{}, fmt.Errorf("error serialising node pool: %w", err)
+		}
+		body = bytes.NewBuffer(b)
+	}
+	req, err := s.client.NewRequest(ctx, method, u, body)
+	if err != nil {
+		return Response{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return Response{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return Response{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return Response{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return Response{}, errors.New("invalid format error returned")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return Response{}, ErrEHSClusterNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "pool",
+	}) {
+		return Response{}, ErrNodePoolNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrMissing,
+		Field: "/name",
+	}) {
+		return Response{}, errors.New("name must be set")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/name",
+	}) {
+		return Response{}, errors.New("a node pool with that name already exists on the EHS Cluster")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/instance_size",
+	}) {
+		if method == http.MethodPut {
+			return Response{}, ErrInstanceSizeChanged
+		}
+		return Response{}, errors.New("instance_size isn't a known instance size")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/min_count",
+	}) {
+		return Response{}, errors.New("min_count can't be negative")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/max_count",
+	}) {
+		return Response{}, errors.New("max_count must be at least min_count, and no more than 100")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/desired_count",
+	}) {
+		return Response{}, errors.New("desired_count must be between min_count and max_count")
+	}
+	if resp.Errors.FieldMatches(requestErrMissing, nodePoolTaintKeyField) != nil {
+		return Response{}, errors.New("taints must have a key")
+	}
+	if resp.Errors.FieldMatches(requestErrInvalidValue, nodePoolTaintEffectField) != nil {
+		return Response{}, fmt.Errorf("taint effects must be %q, %q or %q", TaintNoSchedule, TaintPreferNoSchedule, TaintNoExecute)
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/desired_count",
+	}) {
+		return Response{}, ErrNodePoolBusy
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInsufficient,
+		Field: "/desired_count",
+	}) || resp.Errors.Contains(RequestError{
+		Slug:  requestErrInsufficient,
+		Param: "pool",
+	}) {
+		return Response{}, ErrNodePoolTooSmall
+	}
+	if len(resp.Errors) > 0 {
+		return Response{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp, nil
+}
+
+func (s NodePoolsService) single(resp Response, err error) (NodePool, error) {
+	if err != nil {
+		return NodePool{}, err
+	}
+	if len(resp.NodePools) < 1 {
+		return NodePool{}, errors.New("no node pool returned in response")
+	}
+	return resp.NodePools[0], nil
+}
+
+func (s NodePoolsService) Create(ctx context.Context, pool NodePool) (NodePool, error) {
+	if pool.EHSClusterID == "" {
+		return NodePool{}, errors.New("ehs_cluster_id must be specified")
+	}
+	return s.single(s.do(ctx, http.MethodPost, s.buildURL(pool.EHSClusterID, "/"), &pool))
+}
+
+func (s NodePoolsService) Get(ctx context.Context, clusterID, id string) (NodePool, error) {
+	if clusterID == "" || id == "" {
+		return NodePool{}, errors.New("ehs_cluster_id and id must be specified")
+	}
+	return s.single(s.do(ctx, http.MethodGet, s.buildURL(clusterID, id), nil))
+}
+
+func (s NodePoolsService) List(ctx context.Context, clusterID string, opts ListOptions) ([]NodePool, error) {
+	if clusterID == "" {
+		return nil, errors.New("ehs_cluster_id must be specified")
+	}
+	resp, err := s.do(ctx, http.MethodGet, s.buildURL(clusterID, "/")+opts.query(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.NodePools, nil
+}
+
+// Update replaces the node pool. Changing DesiredCount starts it scaling;
+// follow the returned OperationID to see when it's done.
+func (s NodePoolsService) Update(ctx context.Context, pool NodePool) (NodePool, error) {
+	if pool.EHSClusterID == "" || pool.ID == "" {
+		return NodePool{}, errors.New("ehs_cluster_id and id must be specified")
+	}
+	return s.single(s.do(ctx, http.MethodPut, s.buildURL(pool.EHSClusterID, pool.ID), &pool))
+}
+
+func (s NodePoolsService) Delete(ctx context.Context, clusterID, id string) error {
+	if clusterID == "" || id == "" {
+		return errors.New("ehs_cluster_id and id must be specified")
+	}
+	_, err := s.do(ctx, http.MethodDelete, s.buildURL(clusterID, id), nil)
+	return err
+}
@@ -0,0 +1,261 @@
+package edison
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+)
+
+const (
+	OperationRunning   = "running"
+	OperationSucceeded = "succeeded"
+	OperationFailed    = "failed"
+	OperationCancelled = "cancelled"
+)
+
+// operationPollTimeout is how long each blocking query made by Wait can
+// take.
+const operationPollTimeout = 30 * time.Second
+
+var (
+	ErrOperationNotFound   = errors.New("operation not found")
+	ErrOperationNotRunning = errors.New("operation is not running")
+)
+
+// Operation tracks a change the server is making in the background.
+// ResourceID is only set for creates once the operation has succeeded.
+type Operation struct {
+	ID           string        `json:"id"`
+	Kind         string        `json:"kind"`
+	ResourceType string        `json:"resource_type"`
+	ResourceID   string        `json:"resource_id,omitempty"`
+	Status       string        `json:"status"`
+	Progress     int           `json:"progress"`
+	StartedAt    string        `json:"started_at"`
+	FinishedAt   string        `json:"finished_at,omitempty"`
+	Errors       RequestErrors `json:"errors,omitempty"`
+	Quotas       []QuotaUsage  `json:"quotas,omitempty"`
+}
+
+// Done reports whether the operation has finished, successfully or not.
+func (o Operation) Done() bool {
+	return o.Status != OperationRunning
+}
+
+// OperationFailedError is returned by Wait when an operation fails or is
+// cancelled. If it failed because of a quota, it wraps a
+// QuotaExceededError, and if an AW couldn't be placed on an EHS Cluster, the
+// placement error.
+type OperationFailedError struct {
+	Operation Operation
+}
+
+func (e OperationFailedError) Error() string {
+	if e.Operation.Status == OperationCancelled {
+		return fmt.Sprintf("operation %s (%s) was cancelled", e.Operation.ID, e.Operation.Kind)
+	}
+	if err := e.Unwrap(); err != nil {
+		return fmt.Sprintf("operation %s (%s) failed: %s", e.Operation.ID, e.Operation.Kind, err)
+	}
+	return fmt.Sprintf("operation %s (%s) failed: %+v", e.Operation.ID, e.Operation.Kind, e.Operation.Errors)
+}
+
+func (e OperationFailedError) Unwrap() error {
+	resp := Response{Errors: e.Operation.Errors, Quotas: e.Operation.Quotas}
+	if err := resp.quotaError(); err != nil {
+		return err
+	}
+	if e.Operation.ResourceType == "aw" {
+		return placementError(resp)
+	}
+	return nil
+}
+
+// completedOperation stands in for an operation when the server made the
+// change straight away, because it doesn't support running it in the
+// background.
+func completedOperation(resourceType, verb, resourceID string) Operation {
+	now := time.Now().UTC().Format(time.RFC3339)
+	return Operation{
+		Kind:         resourceType + "." + verb,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Status:       OperationSucceeded,
+		Progress:     100,
+		StartedAt:    now,
+		FinishedAt:   now,
+	}
+}
+
+// startOperation sends req, asking the server to make the change in the
+// background. If it does, the running Operation is returned and res is nil.
+// Servers that don't support operations make the change straight away, and
+// their response is returned for the caller to handle.
+func (c Client) startOperation(req *http.Request) (Operation, *http.Response, error) {
+	req.Header.Set("Prefer", "respond-async")
+	res, err := c.Do(req)
+	if err != nil {
+		return Operation{}, nil, fmt.Errorf("error making request: %w", err)
+	}
+	if res.StatusCode != http.StatusAccepted {
+		return Operation{}, res, nil
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return Operation{}, nil, err
+	}
+	if len(resp.Operations) < 1 {
+		return Operation{}, nil, errors.New("no operation returned in response")
+	}
+	return resp.Operations[0], nil, nil
+}
+
+type OperationsService struct {
+	basePath string
+	client   *Client
+}
+
+func newOperationsService(basePath string, client *Client) *OperationsService {
+	return &OperationsService{
+		basePath: basePath,
+		client:   client,
+	}
+}
+
+func (s OperationsService) buildURL(p string) string {
+	return path.Join(s.basePath, p)
+}
+
+// OperationListOptions filters the operations returned by List. Empty
+// fields don't filter.
+type OperationListOptions struct {
+	ResourceType string
+	ResourceID   string
+	Status       string
+}
+
+func (s OperationsService) Get(ctx context.Context, id string) (Operation, error) {
+	op, _, err := s.Watch(ctx, id, 0, 0)
+	return op, err
+}
+
+// Watch blocks until the operation has changed since index, or wait has
+// elapsed, then returns it along with the index to pass to the next call.
+// An index of 0 returns immediately.
+func (s OperationsService) Watch(ctx context.Context, id string, index uint64, wait time.Duration) (Operation, uint64, error) {
+	if id == "" {
+		return Operation{}, 0, errors.New("id must be specified")
+	}
+	resp, next, err := s.client.blockingGet(ctx, s.buildURL("/"+id), index, wait, ErrOperationNotFound)
+	if err != nil {
+		return Operation{}, 0, err
+	}
+	if len(resp.Operations) < 1 {
+		return Operation{}, 0, errors.New("no operation returned in response")
+	}
+	return resp.Operations[0], next, nil
+}
+
+// Wait blocks until the operation has finished, or ctx is done. If the
+// operation didn't succeed, it returns an OperationFailedError.
+func (s OperationsService) Wait(ctx context.Context, id string) (Operation, error) {
+	var index uint64
+	for {
+		op, next, err := s.Watch(ctx, id, index, operationPollTimeout)
+		if err != nil {
+			return Operation{}, err
+		}
+		if op.Done() {
+			if op.Status != OperationSucceeded {
+				return op, OperationFailedError{Operation: op}
+			}
+			return op, nil
+		}
+		index = next
+	}
+}
+
+func (s OperationsService) List(ctx context.Context, opts OperationListOptions) ([]Operation, error) {
+	q := url.Values{}
+	if opts.ResourceType != "" {
+		q.Set("resource_type", opts.ResourceType)
+	}
+	if opts.ResourceID != "" {
+		q.Set("resource_id", opts.ResourceID)
+	}
+	if opts.Status != "" {
+		q.Set("status", opts.Status)
+	}
+	u := s.buildURL("/")
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	req, err := s.client.NewRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.Operations, nil
+}
+
+// Cancel stops a running operation before it makes its change. It returns
+// ErrOperationNotRunning if the operation has finished, or is already
+// making its change.
+func (s OperationsService) Cancel(ctx context.Context, id string) (Operation, error) {
+	if id == "" {
+		return Operation{}, errors.New("id must be specified")
+	}
+	req, err := s.client.NewRequest(ctx, http.MethodPost, s.buildURL("/"+id+":cancel"), nil)
+	if err != nil {
+		return Operation{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return Operation{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return Operation{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return Operation{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return Operation{}, ErrOperationNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Param: "id",
+	}) {
+		return Operation{}, ErrOperationNotRunning
+	}
+	if len(resp.Errors) > 0 {
+		return Operation{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.Operations) < 1 {
+		return Operation{}, errors.New("no operation returned in response")
+	}
+	return resp.Operations[0], nil
+}
@@ -0,0 +1,100 @@
+package edison
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+)
+
+const (
+	QuotaEHSClustersPerRegion = "ehs_clusters_per_region"
+	QuotaPartitionSpaceTB     = "partition_space_tb"
+	QuotaConcurrentUsers      = "concurrent_users"
+	QuotaAVTenants            = "av_tenants"
+)
+
+type QuotasService struct {
+	basePath string
+	client   *Client
+}
+
+func newQuotasService(basePath string, client *Client) *QuotasService {
+	return &QuotasService{
+		basePath: basePath,
+		client:   client,
+	}
+}
+
+// QuotaUsage is how much of one quota an account is using. A Limit of 0
+// means unlimited. Region is only set for per-region quotas.
+type QuotaUsage struct {
+	AccountID string `json:"account_id"`
+	Name      string `json:"name"`
+	Region    string `json:"region,omitempty"`
+	Limit     int64  `json:"limit"`
+	Used      int64  `json:"used"`
+}
+
+// QuotaExceededError is returned when a request would take an account over
+// one of its quotas.
+type QuotaExceededError struct {
+	Usage QuotaUsage
+}
+
+func (e QuotaExceededError) Error() string {
+	quota := e.Usage.Name
+	if e.Usage.Region != "" {
+		quota += " in " + e.Usage.Region
+	}
+	return fmt.Sprintf("account %q would exceed its %s quota: %d of %d", e.Usage.AccountID, quota, e.Usage.Used, e.Usage.Limit)
+}
+
+// quotaError returns a QuotaExceededError if the response reports one.
+func (r Response) quotaError() error {
+	for _, e := range r.Errors {
+		if e.Slug == requestErrOverflow && len(r.Quotas) > 0 {
+			return QuotaExceededError{Usage: r.Quotas[0]}
+		}
+	}
+	return nil
+}
+
+func (s QuotasService) buildURL(p string) string {
+	return path.Join(s.basePath, p)
+}
+
+// Usage returns the usage of every quota for the given accounts or, if none
+// are given, for every account edisond knows about.
+func (s QuotasService) Usage(ctx context.Context, accountIDs ...string) ([]QuotaUsage, error) {
+	q := url.Values{}
+	for _, id := range accountIDs {
+		q.Add("account_id", id)
+	}
+	u := s.buildURL("/")
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	req, err := s.client.NewRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.Quotas, nil
+}
@@ -26,12 +26,26 @@ var (
 )
 
 type Response struct {
-	Errors      RequestErrors `json:"errors,omitempty"`
-	Status      int           `json:"-"`
-	EAStores    []EAStore     `json:"eastores,omitempty"`
-	EHSClusters []EHSCluster  `json:"ehsclusters,omitempty"`
-	AWs         []AW          `json:"aws,omitempty"`
-	AVs         []AV          `json:"avs,omitempty"`
+	Errors        RequestErrors  `json:"errors,omitempty"`
+	Status        int            `json:"-"`
+	EAStores      []EAStore      `json:"eastores,omitempty"`
+	EHSClusters   []EHSCluster   `json:"ehsclusters,omitempty"`
+	AWs           []AW           `json:"aws,omitempty"`
+	AVs           []AV           `json:"avs,omitempty"`
+	AuditEvents   []AuditEvent   `json:"audit_events,omitempty"`
+	Revisions     []Revision     `json:"revisions,omitempty"`
+	Webhooks      []Webhook      `json:"webhooks,omitempty"`
+	Deliveries    []Delivery     `json:"deliveries,omitempty"`
+	Quotas        []QuotaUsage   `json:"quotas,omitempty"`
+	Operations    []Operation    `json:"operations,omitempty"`
+	Upgrades      []Upgrade      `json:"upgrades,omitempty"`
+	Migrations    []Migration    `json:"migrations,omitempty"`
+	Releases      []Release      `json:"releases,omitempty"`
+	Regions       []Region       `json:"regions,omitempty"`
+	Profiles      []Profile      `json:"profiles,omitempty"`
+	InstanceSizes []InstanceSize `json:"instance_sizes,omitempty"`
+	NodePools     []NodePool     `json:"nodepools,omitempty"`
+	AWSchedules   []AWSchedule   `json:"awschedules,omitempty"`
 }
 
 func responseFromBody(resp *http.Response) (Response, error) {
@@ -0,0 +1,90 @@
+package edison
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrRevisionNotFound = errors.New("revision not found")
+)
+
+type Revision struct {
+	Index        uint64          `json:"index"`
+	Event        string          `json:"event"`
+	ResourceType string          `json:"resource_type"`
+	ResourceID   string          `json:"resource_id"`
+	Number       int             `json:"revision"`
+	Time         time.Time       `json:"time"`
+	Deleted      bool            `json:"deleted,omitempty"`
+	Object       json.RawMessage `json:"object,omitempty"`
+}
+
+func (c Client) listRevisions(ctx context.Context, url string, notFound error) ([]Revision, error) {
+	req, err := c.NewRequest(ctx, http.MethodGet, url+"/revisions", nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := c.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return nil, notFound
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.Revisions, nil
+}
+
+func (c Client) getRevision(ctx context.Context, url string, revision int) (Response, error) {
+	req, err := c.NewRequest(ctx, http.MethodGet, url+"?revision="+strconv.Itoa(revision), nil)
+	if err != nil {
+		return Response{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := c.Do(req)
+	if err != nil {
+		return Response{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return Response{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return Response{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "revision",
+	}) {
+		return Response{}, ErrRevisionNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidFormat,
+		Param: "revision",
+	}) {
+		return Response{}, errors.New("revision must be a positive integer")
+	}
+	if len(resp.Errors) > 0 {
+		return Response{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp, nil
+}
@@ -0,0 +1,206 @@
+package edison
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+)
+
+const (
+	ScheduledActionApplied = "applied"
+	ScheduledActionFailed  = "failed"
+)
+
+var (
+	ErrAWScheduleNotFound = errors.New("AW schedule not found")
+	// ErrAWAlreadyScheduled is returned when creating a schedule for an AW
+	// that already has one.
+	ErrAWAlreadyScheduled = errors.New("AW already has a schedule")
+	// ErrScheduleAWChanged is returned when updating a schedule's AW, which
+	// can only be set when the schedule is created.
+	ErrScheduleAWChanged = errors.New("aw_id can't be changed; replace the schedule instead")
+
+	scheduleRuleCronField  = regexp.MustCompile(`^/rules/([0-9]+)/cron$`)
+	scheduleRuleUsersField = regexp.MustCompile(`^/rules/([0-9]+)/concurrent_users$`)
+)
+
+// AWSchedule sets an AW's concurrent users at the times its rules' cron
+// expressions match, in Timezone. NextAction is the next change it'll
+// make.
+type AWSchedule struct {
+	ID         string           `json:"id,omitempty"`
+	AWID       string           `json:"aw_id"`
+	Timezone   string           `json:"timezone,omitempty"`
+	Rules      []ScheduleRule   `json:"rules"`
+	NextAction *ScheduledAction `json:"next_action,omitempty"`
+	LastAction *ScheduledAction `json:"last_action,omitempty"`
+	CreatedAt  string           `json:"created_at,omitempty"`
+	UpdatedAt  string           `json:"updated_at,omitempty"`
+}
+
+type ScheduleRule struct {
+	Cron            string `json:"cron"`
+	ConcurrentUsers int    `json:"concurrent_users"`
+}
+
+type ScheduledAction struct {
+	At              string `json:"at"`
+	Cron            string `json:"cron"`
+	ConcurrentUsers int    `json:"concurrent_users"`
+	Status          string `json:"status,omitempty"`
+}
+
+type AWSchedulesService struct {
+	basePath string
+	client   *Client
+}
+
+func newAWSchedulesService(basePath string, client *Client) *AWSchedulesService {
+	return &AWSchedulesService{
+		basePath: basePath,
+		client:   client,
+	}
+}
+
+func (s AWSchedulesService) buildURL(p string) string {
+	return path.Join(s.basePath, p)
+}
+
+func (s AWSchedulesService) do(ctx context.Context, method, u string, sched *AWSchedule) (Response, error) {
+	var body io.Reader
+	if sched != nil {
+		b, err := json.Marshal(sched)
+		if err != nil {
+			return Response{}, fmt.Errorf("error serialising AW schedule: %w", err)
+		}
+		body = bytes.NewBuffer(b)
+	}
+	req, err := s.client.NewRequest(ctx, method, u, body)
+	if err != nil {
+		return Response{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return Response{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return Response{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return Response{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return Response{}, errors.New("invalid format error returned")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return Response{}, ErrAWScheduleNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrMissing,
+		Field: "/aw_id",
+	}) {
+		return Response{}, errors.New("aw_id must be set")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/aw_id",
+	}) {
+		if method == http.MethodPut {
+			return Response{}, ErrScheduleAWChanged
+		}
+		return Response{}, ErrAWNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Field: "/aw_id",
+	}) {
+		return Response{}, ErrAWAlreadyScheduled
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/timezone",
+	}) {
+		return Response{}, errors.New("timezone must be an IANA time zone, like \"America/Chicago\"")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrMissing,
+		Field: "/rules",
+	}) {
+		return Response{}, errors.New("at least one rule must be set")
+	}
+	if m := resp.Errors.FieldMatches(requestErrMissing, scheduleRuleCronField); m != nil {
+		return Response{}, fmt.Errorf("rule %s must have a cron expression", m[0][1])
+	}
+	if m := resp.Errors.FieldMatches(requestErrInvalidValue, scheduleRuleCronField); m != nil {
+		return Response{}, fmt.Errorf("rule %s's cron isn't a valid five field cron expression", m[0][1])
+	}
+	if m := resp.Errors.FieldMatches(requestErrInvalidValue, scheduleRuleUsersField); m != nil {
+		return Response{}, fmt.Errorf("rule %s's concurrent_users can't be negative", m[0][1])
+	}
+	if len(resp.Errors) > 0 {
+		return Response{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp, nil
+}
+
+func (s AWSchedulesService) single(resp Response, err error) (AWSchedule, error) {
+	if err != nil {
+		return AWSchedule{}, err
+	}
+	if len(resp.AWSchedules) < 1 {
+		return AWSchedule{}, errors.New("no AW schedule returned in response")
+	}
+	return resp.AWSchedules[0], nil
+}
+
+func (s AWSchedulesService) Create(ctx context.Context, sched AWSchedule) (AWSchedule, error) {
+	return s.single(s.do(ctx, http.MethodPost, s.buildURL("/"), &sched))
+}
+
+func (s AWSchedulesService) Get(ctx context.Context, id string) (AWSchedule, error) {
+	if id == "" {
+		return AWSchedule{}, errors.New("id must be specified")
+	}
+	return s.single(s.do(ctx, http.MethodGet, s.buildURL("/"+id), nil))
+}
+
+// List returns the schedule of the AW or, if awID is empty, every
+// schedule.
+func (s AWSchedulesService) List(ctx context.Context, awID string) ([]AWSchedule, error) {
+	u := s.buildURL("/")
+	if awID != "" {
+		u += "?" + url.Values{"aw_id": {awID}}.Encode()
+	}
+	resp, err := s.do(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.AWSchedules, nil
+}
+
+func (s AWSchedulesService) Update(ctx context.Context, sched AWSchedule) (AWSchedule, error) {
+	if sched.ID == "" {
+		return AWSchedule{}, errors.New("id must be specified")
+	}
+	return s.single(s.do(ctx, http.MethodPut, s.buildURL("/"+sched.ID), &sched))
+}
+
+func (s AWSchedulesService) Delete(ctx context.Context, id string) error {
+	if id == "" {
+		return errors.New("id must be specified")
+	}
+	_, err := s.do(ctx, http.MethodDelete, s.buildURL("/"+id), nil)
+	return err
+}
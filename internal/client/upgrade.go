@@ -0,0 +1,219 @@
+package edison
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+)
+
+const (
+	EHSClusterReady     = "ready"
+	EHSClusterUpgrading = "upgrading"
+	EHSClusterResizing  = "resizing"
+)
+
+const (
+	UpgradeRunning     = "running"
+	UpgradePaused      = "paused"
+	UpgradeRollingBack = "rolling_back"
+	UpgradeSucceeded   = "succeeded"
+	UpgradeRolledBack  = "rolled_back"
+	UpgradeFailed      = "failed"
+)
+
+const (
+	NodePending     = "pending"
+	NodeUpgrading   = "upgrading"
+	NodeUpgraded    = "upgraded"
+	NodeRollingBack = "rolling_back"
+	NodeRolledBack  = "rolled_back"
+)
+
+var (
+	ErrUpgradeNotFound     = errors.New("upgrade not found")
+	ErrUpgradeInvalidState = errors.New("upgrade can't do that in its current state")
+)
+
+// Upgrade is a rolling upgrade of an EHS Cluster from one release to
+// another, one node at a time. Wait on the operation with the ID
+// OperationID for it to finish.
+type Upgrade struct {
+	ID           string        `json:"id"`
+	EHSClusterID string        `json:"ehs_cluster_id"`
+	FromRelease  string        `json:"from_release"`
+	ToRelease    string        `json:"to_release"`
+	Status       string        `json:"status"`
+	Nodes        []UpgradeNode `json:"nodes"`
+	OperationID  string        `json:"operation_id,omitempty"`
+	StartedAt    string        `json:"started_at"`
+	FinishedAt   string        `json:"finished_at,omitempty"`
+}
+
+// UpgradeNode is the state of one of the cluster's nodes during an Upgrade.
+type UpgradeNode struct {
+	Name    string `json:"name"`
+	Release string `json:"release"`
+	Status  string `json:"status"`
+}
+
+// Done reports whether the upgrade has finished, one way or another.
+func (u Upgrade) Done() bool {
+	switch u.Status {
+	case UpgradeSucceeded, UpgradeRolledBack, UpgradeFailed:
+		return true
+	}
+	return false
+}
+
+// NodesUpgraded returns how many of the cluster's nodes are on the new
+// release.
+func (u Upgrade) NodesUpgraded() int {
+	var n int
+	for _, node := range u.Nodes {
+		if node.Status == NodeUpgraded {
+			n++
+		}
+	}
+	return n
+}
+
+// UpgradeFailedError describes an upgrade that finished without
+// succeeding.
+type UpgradeFailedError struct {
+	Upgrade Upgrade
+}
+
+func (e UpgradeFailedError) Error() string {
+	if e.Upgrade.Status == UpgradeRolledBack {
+		return fmt.Sprintf("upgrade %s from %s to %s was rolled back", e.Upgrade.ID, e.Upgrade.FromRelease, e.Upgrade.ToRelease)
+	}
+	return fmt.Sprintf("upgrade %s from %s to %s failed", e.Upgrade.ID, e.Upgrade.FromRelease, e.Upgrade.ToRelease)
+}
+
+type UpgradesService struct {
+	basePath string
+	client   *Client
+}
+
+func newUpgradesService(basePath string, client *Client) *UpgradesService {
+	return &UpgradesService{
+		basePath: basePath,
+		client:   client,
+	}
+}
+
+func (s UpgradesService) buildURL(p string) string {
+	return path.Join(s.basePath, p)
+}
+
+func (s UpgradesService) Get(ctx context.Context, id string) (Upgrade, error) {
+	up, _, err := s.Watch(ctx, id, 0, 0)
+	return up, err
+}
+
+// Watch blocks until the upgrade has changed since index, or wait has
+// elapsed, then returns it along with the index to pass to the next call.
+// An index of 0 returns immediately.
+func (s UpgradesService) Watch(ctx context.Context, id string, index uint64, wait time.Duration) (Upgrade, uint64, error) {
+	if id == "" {
+		return Upgrade{}, 0, errors.New("id must be specified")
+	}
+	resp, next, err := s.client.blockingGet(ctx, s.buildURL("/"+id), index, wait, ErrUpgradeNotFound)
+	if err != nil {
+		return Upgrade{}, 0, err
+	}
+	if len(resp.Upgrades) < 1 {
+		return Upgrade{}, 0, errors.New("no upgrade returned in response")
+	}
+	return resp.Upgrades[0], next, nil
+}
+
+// List returns the upgrades of the EHS Cluster or, if ehsClusterID is
+// empty, of every EHS Cluster.
+func (s UpgradesService) List(ctx context.Context, ehsClusterID string) ([]Upgrade, error) {
+	u := s.buildURL("/")
+	if ehsClusterID != "" {
+		u += "?" + url.Values{"ehs_cluster_id": {ehsClusterID}}.Encode()
+	}
+	req, err := s.client.NewRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return nil, errors.New("server error")
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp.Upgrades, nil
+}
+
+// Pause stops a running upgrade after the node it's upgrading.
+func (s UpgradesService) Pause(ctx context.Context, id string) (Upgrade, error) {
+	return s.control(ctx, id, "pause")
+}
+
+// Resume carries on with a paused upgrade.
+func (s UpgradesService) Resume(ctx context.Context, id string) (Upgrade, error) {
+	return s.control(ctx, id, "resume")
+}
+
+// Rollback reverts the nodes a running or paused upgrade has upgraded,
+// leaving the EHS Cluster on its original release.
+func (s UpgradesService) Rollback(ctx context.Context, id string) (Upgrade, error) {
+	return s.control(ctx, id, "rollback")
+}
+
+func (s UpgradesService) control(ctx context.Context, id, action string) (Upgrade, error) {
+	if id == "" {
+		return Upgrade{}, errors.New("id must be specified")
+	}
+	req, err := s.client.NewRequest(ctx, http.MethodPost, s.buildURL("/"+id+":"+action), nil)
+	if err != nil {
+		return Upgrade{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return Upgrade{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return Upgrade{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return Upgrade{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return Upgrade{}, ErrUpgradeNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrConflict,
+		Param: "id",
+	}) {
+		return Upgrade{}, ErrUpgradeInvalidState
+	}
+	if len(resp.Errors) > 0 {
+		return Upgrade{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	if len(resp.Upgrades) < 1 {
+		return Upgrade{}, errors.New("no upgrade returned in response")
+	}
+	return resp.Upgrades[0], nil
+}
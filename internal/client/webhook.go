@@ -0,0 +1,194 @@
+package edison
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"regexp"
+	"time"
+)
+
+var (
+	ErrWebhookNotFound = errors.New("webhook not found")
+
+	webhookEventField = regexp.MustCompile(`^/events/[0-9]+$`)
+)
+
+type WebhooksService struct {
+	basePath string
+	client   *Client
+}
+
+func newWebhookService(basePath string, client *Client) *WebhooksService {
+	return &WebhooksService{
+		basePath: basePath,
+		client:   client,
+	}
+}
+
+type Webhook struct {
+	ID            string   `json:"id,omitempty"`
+	URL           string   `json:"url"`
+	Events        []string `json:"events,omitempty"`
+	ResourceTypes []string `json:"resource_types,omitempty"`
+	// Secret is only ever sent, never returned by the API.
+	Secret    string `json:"secret,omitempty"`
+	CreatedAt string `json:"created_at,omitempty"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+}
+
+type Delivery struct {
+	ID           string    `json:"id"`
+	WebhookID    string    `json:"webhook_id"`
+	Event        string    `json:"event"`
+	Index        uint64    `json:"index,omitempty"`
+	Status       string    `json:"status"`
+	Attempts     int       `json:"attempts"`
+	ResponseCode int       `json:"response_code,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (s WebhooksService) buildURL(p string) string {
+	return path.Join(s.basePath, p)
+}
+
+func (s WebhooksService) do(ctx context.Context, method, u string, webhook *Webhook) (Response, error) {
+	var body io.Reader
+	if webhook != nil {
+		b, err := json.Marshal(webhook)
+		if err != nil {
+			return Response{}, fmt.Errorf("error serialising webhook: %w", err)
+		}
+		body = bytes.NewBuffer(b)
+	}
+	req, err := s.client.NewRequest(ctx, method, u, body)
+	if err != nil {
+		return Response{}, fmt.Errorf("error constructing request: %w", err)
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return Response{}, fmt.Errorf("error making request: %w", err)
+	}
+	resp, err := responseFromBody(res)
+	if err != nil {
+		return Response{}, err
+	}
+
+	if resp.Errors.Contains(serverError) {
+		return Response{}, errors.New("server error")
+	}
+	if resp.Errors.Contains(invalidFormatError) {
+		return Response{}, errors.New("invalid format error returned")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrNotFound,
+		Param: "id",
+	}) {
+		return Response{}, ErrWebhookNotFound
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrMissing,
+		Field: "/url",
+	}) {
+		return Response{}, errors.New("url must be set")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrInvalidValue,
+		Field: "/url",
+	}) {
+		return Response{}, errors.New("url must be an absolute http or https URL")
+	}
+	if resp.Errors.Contains(RequestError{
+		Slug:  requestErrMissing,
+		Field: "/secret",
+	}) {
+		return Response{}, errors.New("secret must be set")
+	}
+	if resp.Errors.FieldMatches(requestErrInvalidValue, webhookEventField) != nil {
+		return Response{}, fmt.Errorf("events must be %q, %q, %q, %q, %q, %q or %q", EventCreate, EventUpdate, EventDelete, EventPurge, EventStatusChange, EventScale, EventSchedule)
+	}
+	if len(resp.Errors) > 0 {
+		return Response{}, fmt.Errorf("unexpected error in response: %+v", resp.Errors)
+	}
+	return resp, nil
+}
+
+func (s WebhooksService) single(resp Response, err error) (Webhook, error) {
+	if err != nil {
+		return Webhook{}, err
+	}
+	if len(resp.Webhooks) < 1 {
+		return Webhook{}, errors.New("no webhook returned in response")
+	}
+	return resp.Webhooks[0], nil
+}
+
+func (s WebhooksService) Create(ctx context.Context, webhook Webhook) (Webhook, error) {
+	return s.single(s.do(ctx, http.MethodPost, s.buildURL("/"), &webhook))
+}
+
+func (s WebhooksService) Get(ctx context.Context, id string) (Webhook, error) {
+	if id == "" {
+		return Webhook{}, errors.New("id must be specified")
+	}
+	return s.single(s.do(ctx, http.MethodGet, s.buildURL("/"+id), nil))
+}
+
+func (s WebhooksService) List(ctx context.Context) ([]Webhook, error) {
+	resp, err := s.do(ctx, http.MethodGet, s.buildURL("/"), nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Webhooks, nil
+}
+
+// Update replaces the webhook. If Secret is empty, the current secret is
+// kept.
+func (s WebhooksService) Update(ctx context.Context, webhook Webhook) (Webhook, error) {
+	if webhook.ID == "" {
+		return Webhook{}, errors.New("id must be specified")
+	}
+	return s.single(s.do(ctx, http.MethodPut, s.buildURL("/"+webhook.ID), &webhook))
+}
+
+func (s WebhooksService) Delete(ctx context.Context, id string) error {
+	if id == "" {
+		return errors.New("id must be specified")
+	}
+	_, err := s.do(ctx, http.MethodDelete, s.buildURL("/"+id), nil)
+	return err
+}
+
+// Ping sends a test event to the webhook, returning the Delivery so its
+// progress can be followed with Deliveries.
+func (s WebhooksService) Ping(ctx context.Context, id string) (Delivery, error) {
+	if id == "" {
+		return Delivery{}, errors.New("id must be specified")
+	}
+	resp, err := s.do(ctx, http.MethodPost, s.buildURL("/"+id+":ping"), nil)
+	if err != nil {
+		return Delivery{}, err
+	}
+	if len(resp.Deliveries) < 1 {
+		return Delivery{}, errors.New("no delivery returned in response")
+	}
+	return resp.Deliveries[0], nil
+}
+
+func (s WebhooksService) Deliveries(ctx context.Context, id string) ([]Delivery, error) {
+	if id == "" {
+		return nil, errors.New("id must be specified")
+	}
+	resp, err := s.do(ctx, http.MethodGet, s.buildURL("/"+id+"/deliveries"), nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Deliveries, nil
+}
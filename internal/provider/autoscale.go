@@ -0,0 +1,101 @@
+package provider
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	edison "github.com/rahoolp/terraform-provider-edison/internal/client"
+)
+
+var autoscalingAttrTypes = map[string]attr.Type{
+	"min_nodes":          types.NumberType,
+	"max_nodes":          types.NumberType,
+	"target_utilization": types.NumberType,
+	"scale_in_cooldown":  types.StringType,
+}
+
+// autoscalingAttribute is the EHS Cluster's autoscaling block. Without it,
+// the cluster runs its profile's usual number of nodes.
+func autoscalingAttribute() schema.Attribute {
+	return schema.Attribute{
+		Optional: true,
+		Attributes: schema.SingleNestedAttributes(map[string]schema.Attribute{
+			"min_nodes": {
+				Type:     types.NumberType,
+				Required: true,
+			},
+			"max_nodes": {
+				Type:     types.NumberType,
+				Required: true,
+			},
+			"target_utilization": {
+				Type:     types.NumberType,
+				Required: true,
+			},
+			"scale_in_cooldown": {
+				Type:     types.StringType,
+				Optional: true,
+			},
+		}),
+	}
+}
+
+func intFromNumber(n attr.Value) int {
+	num, ok := n.(types.Number)
+	if !ok || num.Null || num.Unknown || num.Value == nil {
+		return 0
+	}
+	i, _ := num.Value.Int64()
+	return int(i)
+}
+
+func numberFromInt(i int) types.Number {
+	return types.Number{Value: big.NewFloat(float64(i))}
+}
+
+func autoscalingFromObject(obj types.Object) *edison.Autoscaling {
+	if obj.Null || obj.Unknown {
+		return nil
+	}
+	as := &edison.Autoscaling{
+		MinNodes:          intFromNumber(obj.Attrs["min_nodes"]),
+		MaxNodes:          intFromNumber(obj.Attrs["max_nodes"]),
+		TargetUtilization: intFromNumber(obj.Attrs["target_utilization"]),
+	}
+	if cooldown, ok := obj.Attrs["scale_in_cooldown"].(types.String); ok {
+		as.ScaleInCooldown = cooldown.Value
+	}
+	return as
+}
+
+func objectFromAutoscaling(as *edison.Autoscaling) types.Object {
+	obj := types.Object{AttrTypes: autoscalingAttrTypes}
+	if as == nil {
+		obj.Null = true
+		return obj
+	}
+	obj.Attrs = map[string]attr.Value{
+		"min_nodes":          numberFromInt(as.MinNodes),
+		"max_nodes":          numberFromInt(as.MaxNodes),
+		"target_utilization": numberFromInt(as.TargetUtilization),
+		"scale_in_cooldown":  types.String{Value: as.ScaleInCooldown, Null: as.ScaleInCooldown == ""},
+	}
+	return obj
+}
+
+// autoscalingDiagnostic turns an invalid autoscaling setting into an error
+// diagnostic.
+func autoscalingDiagnostic(err error) (*tfprotov6.Diagnostic, bool) {
+	if !errors.Is(err, edison.ErrInvalidAutoscaling) {
+		return nil, false
+	}
+	return &tfprotov6.Diagnostic{
+		Severity: tfprotov6.DiagnosticSeverityError,
+		Summary:  "Invalid EHS Cluster autoscaling",
+		Detail:   err.Error() + ".",
+	}, true
+}
@@ -0,0 +1,65 @@
+package provider
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	edison "github.com/rahoolp/terraform-provider-edison/internal/client"
+)
+
+// catalogID identifies a catalog data source by what it lists and the
+// filters it was read with.
+func catalogID(kind string, filters ...string) string {
+	parts := []string{kind}
+	for _, f := range filters {
+		if f != "" {
+			parts = append(parts, f)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// releaseDiagnostic looks name up in the release catalog and describes
+// anything users should know about running an EHS Cluster on it. EOL
+// releases are an error when choosing the release, and a warning for a
+// cluster that's already on it. Releases the catalog doesn't know about,
+// or servers without a catalog, aren't reported. Like capacityWarning,
+// these come with the apply or refresh rather than the plan, as this
+// version of the plugin framework can't modify or validate plans.
+func releaseDiagnostic(ctx context.Context, client *edison.Client, name string, choosing bool) (*tfprotov6.Diagnostic, bool) {
+	release, err := client.Catalog.Release(ctx, name)
+	if err != nil {
+		tflog.Info(ctx, "Release catalog: "+err.Error())
+		return nil, false
+	}
+	switch release.Status {
+	case edison.ReleasePreview:
+		return &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityWarning,
+			Summary:  "EHS release " + name + " is in preview",
+			Detail:   "Preview releases aren't supported for production workloads.",
+		}, true
+	case edison.ReleaseDeprecated:
+		return &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityWarning,
+			Summary:  "EHS release " + name + " is deprecated",
+			Detail:   "It will reach end of life soon. Upgrade to a GA release.",
+		}, true
+	case edison.ReleaseEOL:
+		if !choosing {
+			return &tfprotov6.Diagnostic{
+				Severity: tfprotov6.DiagnosticSeverityWarning,
+				Summary:  "EHS release " + name + " is end of life",
+				Detail:   "It's no longer supported. Upgrade to a GA release.",
+			}, true
+		}
+		return &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "EHS release " + name + " is end of life",
+			Detail:   "EHS Clusters can't be created on, or upgraded to, an end of life release. Choose a GA release.",
+		}, true
+	}
+	return nil, false
+}
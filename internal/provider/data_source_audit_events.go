@@ -0,0 +1,201 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	edison "github.com/rahoolp/terraform-provider-edison/internal/client"
+)
+
+var auditChangeAttrTypes = map[string]attr.Type{
+	"field":  types.StringType,
+	"before": types.StringType,
+	"after":  types.StringType,
+}
+
+var auditEventAttrTypes = map[string]attr.Type{
+	"id":            types.StringType,
+	"time":          types.StringType,
+	"principal":     types.StringType,
+	"source_ip":     types.StringType,
+	"request_id":    types.StringType,
+	"method":        types.StringType,
+	"path":          types.StringType,
+	"status":        types.NumberType,
+	"resource_type": types.StringType,
+	"resource_id":   types.StringType,
+	"before":        types.StringType,
+	"after":         types.StringType,
+	"changes":       types.ListType{ElemType: types.ObjectType{AttrTypes: auditChangeAttrTypes}},
+}
+
+type auditEventsDataSourceType struct {
+}
+
+func (a auditEventsDataSourceType) GetSchema(_ context.Context) (schema.Schema, []*tfprotov6.Diagnostic) {
+	return schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"id": {
+				Type:     types.StringType,
+				Computed: true,
+			},
+			"resource_type": {
+				Type:     types.StringType,
+				Optional: true,
+			},
+			"resource_id": {
+				Type:     types.StringType,
+				Optional: true,
+			},
+			"principal": {
+				Type:     types.StringType,
+				Optional: true,
+			},
+			"since": {
+				Type:     types.StringType,
+				Optional: true,
+			},
+			"until": {
+				Type:     types.StringType,
+				Optional: true,
+			},
+			"events": {
+				Type:     types.ListType{ElemType: types.ObjectType{AttrTypes: auditEventAttrTypes}},
+				Computed: true,
+			},
+		},
+	}, nil
+}
+
+type auditEventsData struct {
+	ID           types.String `tfsdk:"id"`
+	ResourceType types.String `tfsdk:"resource_type"`
+	ResourceID   types.String `tfsdk:"resource_id"`
+	Principal    types.String `tfsdk:"principal"`
+	Since        types.String `tfsdk:"since"`
+	Until        types.String `tfsdk:"until"`
+	Events       types.List   `tfsdk:"events"`
+}
+
+func (a auditEventsDataSourceType) NewDataSource(_ context.Context, p tfsdk.Provider) (tfsdk.DataSource, []*tfprotov6.Diagnostic) {
+	prov, ok := p.(*provider)
+	if !ok {
+		return nil, []*tfprotov6.Diagnostic{
+			{
+				Severity: tfprotov6.DiagnosticSeverityError,
+				Summary:  "Error converting provider",
+				Detail:   fmt.Sprintf("An unexpected error was encountered converting the provider. This is always a bug in the provider.\n\nType: %T", p),
+			},
+		}
+	}
+	return auditEventsDataSource{client: prov.client}, nil
+}
+
+type auditEventsDataSource struct {
+	client *edison.Client
+}
+
+func (a auditEventsDataSource) Read(ctx context.Context, req tfsdk.ReadDataSourceRequest, resp *tfsdk.ReadDataSourceResponse) {
+
+	tflog.Info(ctx, "Audit Events Read..")
+
+	var data auditEventsData
+	err := req.Config.Get(ctx, &data)
+	if err != nil {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "Error parsing config",
+			Detail:   "An unexpected error was encountered parsing the config. This is always a bug in the provider.\n\nDetails: " + err.Error(),
+		})
+		return
+	}
+
+	filter := edison.AuditFilter{
+		ResourceType: data.ResourceType.Value,
+		ResourceID:   data.ResourceID.Value,
+		Principal:    data.Principal.Value,
+	}
+	for name, v := range map[string]struct {
+		value  types.String
+		target *time.Time
+	}{
+		"since": {data.Since, &filter.Since},
+		"until": {data.Until, &filter.Until},
+	} {
+		if v.value.Value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v.value.Value)
+		if err != nil {
+			resp.Diagnostics = append(resp.Diagnostics, &tfprotov6.Diagnostic{
+				Severity:  tfprotov6.DiagnosticSeverityError,
+				Summary:   "Invalid timestamp",
+				Detail:    name + " must be an RFC 3339 timestamp, like 2006-01-02T15:04:05Z.",
+				Attribute: tftypes.NewAttributePath().WithAttributeName(name),
+			})
+			continue
+		}
+		*v.target = t
+	}
+	if len(resp.Diagnostics) > 0 {
+		return
+	}
+
+	events, err := a.client.Audit.List(ctx, filter)
+	if err != nil {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "Error listing audit events",
+			Detail:   "An unexpected error was encountered listing audit events.\n\nDetails: " + err.Error(),
+		})
+		return
+	}
+
+	data.ID = types.String{Value: fmt.Sprintf("%s/%s/%s/%s/%s", filter.ResourceType, filter.ResourceID, filter.Principal, data.Since.Value, data.Until.Value)}
+	data.Events = types.List{ElemType: types.ObjectType{AttrTypes: auditEventAttrTypes}, Elems: []attr.Value{}}
+	for _, e := range events {
+		changes := types.List{ElemType: types.ObjectType{AttrTypes: auditChangeAttrTypes}, Elems: []attr.Value{}}
+		for _, c := range e.Changes {
+			changes.Elems = append(changes.Elems, types.Object{
+				AttrTypes: auditChangeAttrTypes,
+				Attrs: map[string]attr.Value{
+					"field":  types.String{Value: c.Field},
+					"before": types.String{Value: string(c.Before)},
+					"after":  types.String{Value: string(c.After)},
+				},
+			})
+		}
+		data.Events.Elems = append(data.Events.Elems, types.Object{
+			AttrTypes: auditEventAttrTypes,
+			Attrs: map[string]attr.Value{
+				"id":            types.String{Value: e.ID},
+				"time":          types.String{Value: e.Time.Format(time.RFC3339)},
+				"principal":     types.String{Value: e.Principal},
+				"source_ip":     types.String{Value: e.SourceIP},
+				"request_id":    types.String{Value: e.RequestID},
+				"method":        types.String{Value: e.Method},
+				"path":          types.String{Value: e.Path},
+				"status":        types.Number{Value: big.NewFloat(float64(e.Status))},
+				"resource_type": types.String{Value: e.ResourceType},
+				"resource_id":   types.String{Value: e.ResourceID},
+				"before":        types.String{Value: string(e.Before)},
+				"after":         types.String{Value: string(e.After)},
+				"changes":       changes,
+			},
+		})
+	}
+
+	err = resp.State.Set(ctx, &data)
+	if err != nil {
+		tflog.Info(ctx, "Audit Events Read: "+err.Error())
+	}
+}
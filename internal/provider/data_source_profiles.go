@@ -0,0 +1,117 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	edison "github.com/rahoolp/terraform-provider-edison/internal/client"
+)
+
+var profileAttrTypes = map[string]attr.Type{
+	"name":         types.StringType,
+	"nodes":        types.NumberType,
+	"vcpu":         types.NumberType,
+	"memory_gb":    types.NumberType,
+	"max_aw_seats": types.NumberType,
+	"regions":      types.ListType{ElemType: types.StringType},
+}
+
+type profilesDataSourceType struct {
+}
+
+func (d profilesDataSourceType) GetSchema(_ context.Context) (schema.Schema, []*tfprotov6.Diagnostic) {
+	return schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"id": {
+				Type:     types.StringType,
+				Computed: true,
+			},
+			"region": {
+				Type:     types.StringType,
+				Optional: true,
+			},
+			"profiles": {
+				Type:     types.ListType{ElemType: types.ObjectType{AttrTypes: profileAttrTypes}},
+				Computed: true,
+			},
+		},
+	}, nil
+}
+
+type profilesData struct {
+	ID       types.String `tfsdk:"id"`
+	Region   types.String `tfsdk:"region"`
+	Profiles types.List   `tfsdk:"profiles"`
+}
+
+func (d profilesDataSourceType) NewDataSource(_ context.Context, p tfsdk.Provider) (tfsdk.DataSource, []*tfprotov6.Diagnostic) {
+	prov, ok := p.(*provider)
+	if !ok {
+		return nil, []*tfprotov6.Diagnostic{
+			{
+				Severity: tfprotov6.DiagnosticSeverityError,
+				Summary:  "Error converting provider",
+				Detail:   fmt.Sprintf("An unexpected error was encountered converting the provider. This is always a bug in the provider.\n\nType: %T", p),
+			},
+		}
+	}
+	return profilesDataSource{client: prov.client}, nil
+}
+
+type profilesDataSource struct {
+	client *edison.Client
+}
+
+func (d profilesDataSource) Read(ctx context.Context, req tfsdk.ReadDataSourceRequest, resp *tfsdk.ReadDataSourceResponse) {
+
+	tflog.Info(ctx, "Profiles Read..")
+
+	var data profilesData
+	err := req.Config.Get(ctx, &data)
+	if err != nil {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "Error parsing config",
+			Detail:   "An unexpected error was encountered parsing the config. This is always a bug in the provider.\n\nDetails: " + err.Error(),
+		})
+		return
+	}
+
+	profiles, err := d.client.Catalog.Profiles(ctx, data.Region.Value)
+	if err != nil {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "Error reading profiles",
+			Detail:   "An unexpected error was encountered reading the profile catalog.\n\nDetails: " + err.Error(),
+		})
+		return
+	}
+
+	data.ID = types.String{Value: catalogID("profiles", data.Region.Value)}
+	data.Profiles = types.List{ElemType: types.ObjectType{AttrTypes: profileAttrTypes}, Elems: []attr.Value{}}
+	for _, profile := range profiles {
+		data.Profiles.Elems = append(data.Profiles.Elems, types.Object{
+			AttrTypes: profileAttrTypes,
+			Attrs: map[string]attr.Value{
+				"name":         types.String{Value: profile.Name},
+				"nodes":        types.Number{Value: big.NewFloat(float64(profile.Nodes))},
+				"vcpu":         types.Number{Value: big.NewFloat(float64(profile.VCPU))},
+				"memory_gb":    types.Number{Value: big.NewFloat(float64(profile.MemoryGB))},
+				"max_aw_seats": types.Number{Value: big.NewFloat(float64(profile.MaxAWSeats))},
+				"regions":      listFromStrings(profile.Regions),
+			},
+		})
+	}
+
+	err = resp.State.Set(ctx, &data)
+	if err != nil {
+		tflog.Info(ctx, "Profiles Read: "+err.Error())
+	}
+}
@@ -0,0 +1,120 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	edison "github.com/rahoolp/terraform-provider-edison/internal/client"
+)
+
+var quotaUsageAttrTypes = map[string]attr.Type{
+	"name":      types.StringType,
+	"region":    types.StringType,
+	"limit":     types.NumberType,
+	"used":      types.NumberType,
+	"remaining": types.NumberType,
+}
+
+type quotaUsageDataSourceType struct {
+}
+
+func (q quotaUsageDataSourceType) GetSchema(_ context.Context) (schema.Schema, []*tfprotov6.Diagnostic) {
+	return schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"id": {
+				Type:     types.StringType,
+				Computed: true,
+			},
+			"account_id": {
+				Type:     types.StringType,
+				Required: true,
+			},
+			"quotas": {
+				Type:     types.ListType{ElemType: types.ObjectType{AttrTypes: quotaUsageAttrTypes}},
+				Computed: true,
+			},
+		},
+	}, nil
+}
+
+type quotaUsageData struct {
+	ID        types.String `tfsdk:"id"`
+	AccountID types.String `tfsdk:"account_id"`
+	Quotas    types.List   `tfsdk:"quotas"`
+}
+
+func (q quotaUsageDataSourceType) NewDataSource(_ context.Context, p tfsdk.Provider) (tfsdk.DataSource, []*tfprotov6.Diagnostic) {
+	prov, ok := p.(*provider)
+	if !ok {
+		return nil, []*tfprotov6.Diagnostic{
+			{
+				Severity: tfprotov6.DiagnosticSeverityError,
+				Summary:  "Error converting provider",
+				Detail:   fmt.Sprintf("An unexpected error was encountered converting the provider. This is always a bug in the provider.\n\nType: %T", p),
+			},
+		}
+	}
+	return quotaUsageDataSource{client: prov.client}, nil
+}
+
+type quotaUsageDataSource struct {
+	client *edison.Client
+}
+
+func (q quotaUsageDataSource) Read(ctx context.Context, req tfsdk.ReadDataSourceRequest, resp *tfsdk.ReadDataSourceResponse) {
+
+	tflog.Info(ctx, "Quota Usage Read..")
+
+	var data quotaUsageData
+	err := req.Config.Get(ctx, &data)
+	if err != nil {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "Error parsing config",
+			Detail:   "An unexpected error was encountered parsing the config. This is always a bug in the provider.\n\nDetails: " + err.Error(),
+		})
+		return
+	}
+
+	usages, err := q.client.Quotas.Usage(ctx, data.AccountID.Value)
+	if err != nil {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "Error reading quota usage",
+			Detail:   "An unexpected error was encountered reading quota usage.\n\nDetails: " + err.Error(),
+		})
+		return
+	}
+
+	data.ID = data.AccountID
+	data.Quotas = types.List{ElemType: types.ObjectType{AttrTypes: quotaUsageAttrTypes}, Elems: []attr.Value{}}
+	for _, u := range usages {
+		// A limit of 0 is unlimited, so there's no meaningful remaining.
+		remaining := types.Number{Null: true}
+		if u.Limit > 0 {
+			remaining = types.Number{Value: big.NewFloat(float64(u.Limit - u.Used))}
+		}
+		data.Quotas.Elems = append(data.Quotas.Elems, types.Object{
+			AttrTypes: quotaUsageAttrTypes,
+			Attrs: map[string]attr.Value{
+				"name":      types.String{Value: u.Name},
+				"region":    types.String{Value: u.Region},
+				"limit":     types.Number{Value: big.NewFloat(float64(u.Limit))},
+				"used":      types.Number{Value: big.NewFloat(float64(u.Used))},
+				"remaining": remaining,
+			},
+		})
+	}
+
+	err = resp.State.Set(ctx, &data)
+	if err != nil {
+		tflog.Info(ctx, "Quota Usage Read: "+err.Error())
+	}
+}
@@ -0,0 +1,98 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	edison "github.com/rahoolp/terraform-provider-edison/internal/client"
+)
+
+var regionAttrTypes = map[string]attr.Type{
+	"name":     types.StringType,
+	"location": types.StringType,
+	"releases": types.ListType{ElemType: types.StringType},
+	"profiles": types.ListType{ElemType: types.StringType},
+}
+
+type regionsDataSourceType struct {
+}
+
+func (r regionsDataSourceType) GetSchema(_ context.Context) (schema.Schema, []*tfprotov6.Diagnostic) {
+	return schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"id": {
+				Type:     types.StringType,
+				Computed: true,
+			},
+			"regions": {
+				Type:     types.ListType{ElemType: types.ObjectType{AttrTypes: regionAttrTypes}},
+				Computed: true,
+			},
+		},
+	}, nil
+}
+
+type regionsData struct {
+	ID      types.String `tfsdk:"id"`
+	Regions types.List   `tfsdk:"regions"`
+}
+
+func (r regionsDataSourceType) NewDataSource(_ context.Context, p tfsdk.Provider) (tfsdk.DataSource, []*tfprotov6.Diagnostic) {
+	prov, ok := p.(*provider)
+	if !ok {
+		return nil, []*tfprotov6.Diagnostic{
+			{
+				Severity: tfprotov6.DiagnosticSeverityError,
+				Summary:  "Error converting provider",
+				Detail:   fmt.Sprintf("An unexpected error was encountered converting the provider. This is always a bug in the provider.\n\nType: %T", p),
+			},
+		}
+	}
+	return regionsDataSource{client: prov.client}, nil
+}
+
+type regionsDataSource struct {
+	client *edison.Client
+}
+
+func (r regionsDataSource) Read(ctx context.Context, req tfsdk.ReadDataSourceRequest, resp *tfsdk.ReadDataSourceResponse) {
+
+	tflog.Info(ctx, "Regions Read..")
+
+	regions, err := r.client.Catalog.Regions(ctx)
+	if err != nil {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "Error reading regions",
+			Detail:   "An unexpected error was encountered reading the region catalog.\n\nDetails: " + err.Error(),
+		})
+		return
+	}
+
+	data := regionsData{
+		ID:      types.String{Value: catalogID("regions")},
+		Regions: types.List{ElemType: types.ObjectType{AttrTypes: regionAttrTypes}, Elems: []attr.Value{}},
+	}
+	for _, region := range regions {
+		data.Regions.Elems = append(data.Regions.Elems, types.Object{
+			AttrTypes: regionAttrTypes,
+			Attrs: map[string]attr.Value{
+				"name":     types.String{Value: region.Name},
+				"location": types.String{Value: region.Location},
+				"releases": listFromStrings(region.Releases),
+				"profiles": listFromStrings(region.Profiles),
+			},
+		})
+	}
+
+	err = resp.State.Set(ctx, &data)
+	if err != nil {
+		tflog.Info(ctx, "Regions Read: "+err.Error())
+	}
+}
@@ -0,0 +1,117 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	edison "github.com/rahoolp/terraform-provider-edison/internal/client"
+)
+
+var releaseAttrTypes = map[string]attr.Type{
+	"name":    types.StringType,
+	"version": types.StringType,
+	"status":  types.StringType,
+	"regions": types.ListType{ElemType: types.StringType},
+}
+
+type releasesDataSourceType struct {
+}
+
+func (r releasesDataSourceType) GetSchema(_ context.Context) (schema.Schema, []*tfprotov6.Diagnostic) {
+	return schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"id": {
+				Type:     types.StringType,
+				Computed: true,
+			},
+			"region": {
+				Type:     types.StringType,
+				Optional: true,
+			},
+			"status": {
+				Type:     types.StringType,
+				Optional: true,
+			},
+			"releases": {
+				Type:     types.ListType{ElemType: types.ObjectType{AttrTypes: releaseAttrTypes}},
+				Computed: true,
+			},
+		},
+	}, nil
+}
+
+type releasesData struct {
+	ID       types.String `tfsdk:"id"`
+	Region   types.String `tfsdk:"region"`
+	Status   types.String `tfsdk:"status"`
+	Releases types.List   `tfsdk:"releases"`
+}
+
+func (r releasesDataSourceType) NewDataSource(_ context.Context, p tfsdk.Provider) (tfsdk.DataSource, []*tfprotov6.Diagnostic) {
+	prov, ok := p.(*provider)
+	if !ok {
+		return nil, []*tfprotov6.Diagnostic{
+			{
+				Severity: tfprotov6.DiagnosticSeverityError,
+				Summary:  "Error converting provider",
+				Detail:   fmt.Sprintf("An unexpected error was encountered converting the provider. This is always a bug in the provider.\n\nType: %T", p),
+			},
+		}
+	}
+	return releasesDataSource{client: prov.client}, nil
+}
+
+type releasesDataSource struct {
+	client *edison.Client
+}
+
+func (r releasesDataSource) Read(ctx context.Context, req tfsdk.ReadDataSourceRequest, resp *tfsdk.ReadDataSourceResponse) {
+
+	tflog.Info(ctx, "Releases Read..")
+
+	var data releasesData
+	err := req.Config.Get(ctx, &data)
+	if err != nil {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "Error parsing config",
+			Detail:   "An unexpected error was encountered parsing the config. This is always a bug in the provider.\n\nDetails: " + err.Error(),
+		})
+		return
+	}
+
+	releases, err := r.client.Catalog.Releases(ctx, data.Region.Value, data.Status.Value)
+	if err != nil {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "Error reading releases",
+			Detail:   "An unexpected error was encountered reading the release catalog.\n\nDetails: " + err.Error(),
+		})
+		return
+	}
+
+	data.ID = types.String{Value: catalogID("releases", data.Region.Value, data.Status.Value)}
+	data.Releases = types.List{ElemType: types.ObjectType{AttrTypes: releaseAttrTypes}, Elems: []attr.Value{}}
+	for _, release := range releases {
+		data.Releases.Elems = append(data.Releases.Elems, types.Object{
+			AttrTypes: releaseAttrTypes,
+			Attrs: map[string]attr.Value{
+				"name":    types.String{Value: release.Name},
+				"version": types.String{Value: release.Version},
+				"status":  types.String{Value: release.Status},
+				"regions": listFromStrings(release.Regions),
+			},
+		})
+	}
+
+	err = resp.State.Set(ctx, &data)
+	if err != nil {
+		tflog.Info(ctx, "Releases Read: "+err.Error())
+	}
+}
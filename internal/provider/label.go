@@ -0,0 +1,79 @@
+package provider
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// labelAttributes are the attributes every resource has for its labels.
+// labels is what's configured on the resource; effective_labels is what
+// edisond stores, with the provider's default_labels merged in.
+func labelAttributes(attrs map[string]schema.Attribute) map[string]schema.Attribute {
+	attrs["labels"] = schema.Attribute{
+		Type:     types.MapType{ElemType: types.StringType},
+		Optional: true,
+	}
+	attrs["effective_labels"] = schema.Attribute{
+		Type:     types.MapType{ElemType: types.StringType},
+		Computed: true,
+	}
+	return attrs
+}
+
+func labelsFromMap(m types.Map) map[string]string {
+	if m.Null || m.Unknown {
+		return nil
+	}
+	results := map[string]string{}
+	for k, v := range m.Elems {
+		results[k] = v.(types.String).Value
+	}
+	return results
+}
+
+func mapFromLabels(labels map[string]string) types.Map {
+	m := types.Map{ElemType: types.StringType}
+	if len(labels) < 1 {
+		m.Null = true
+		return m
+	}
+	m.Elems = map[string]attr.Value{}
+	for k, v := range labels {
+		m.Elems[k] = types.String{Value: v}
+	}
+	return m
+}
+
+// mergeLabels returns defaults overridden by labels.
+func mergeLabels(defaults, labels map[string]string) map[string]string {
+	if len(defaults) < 1 && len(labels) < 1 {
+		return nil
+	}
+	results := map[string]string{}
+	for k, v := range defaults {
+		results[k] = v
+	}
+	for k, v := range labels {
+		results[k] = v
+	}
+	return results
+}
+
+// configuredLabels works out which of a resource's stored labels belong in
+// its labels attribute: everything but the labels default_labels added,
+// unless they were also configured on the resource.
+func configuredLabels(stored, defaults, configured map[string]string) map[string]string {
+	results := map[string]string{}
+	for k, v := range stored {
+		if _, ok := configured[k]; ok {
+			results[k] = v
+			continue
+		}
+		if d, ok := defaults[k]; ok && d == v {
+			continue
+		}
+		results[k] = v
+	}
+	return results
+}
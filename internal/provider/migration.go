@@ -0,0 +1,45 @@
+package provider
+
+import (
+	"errors"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	edison "github.com/rahoolp/terraform-provider-edison/internal/client"
+)
+
+// migrationDiagnostic turns an error moving an AW to another EHS Cluster
+// into an error diagnostic, so the apply fails instead of recording a
+// cluster the AW isn't on.
+func migrationDiagnostic(err error) (*tfprotov6.Diagnostic, bool) {
+	var failed edison.MigrationFailedError
+	switch {
+	case errors.As(err, &failed):
+		detail := failed.Error() + "."
+		if !switchedDNS(failed.Migration) {
+			detail += " The AW is still on EHS Cluster " + failed.Migration.FromEHSClusterID + "."
+		}
+		return &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "AW migration didn't complete",
+			Detail:   detail,
+		}, true
+	case errors.Is(err, edison.ErrAWMigrating):
+		return &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "AW can't be moved right now",
+			Detail:   err.Error() + ". Try again once it's finished.",
+		}, true
+	}
+	return nil, false
+}
+
+// switchedDNS reports whether the migration got as far as pointing the AW's
+// DNS endpoint at its new EHS Cluster.
+func switchedDNS(mig edison.Migration) bool {
+	for _, step := range mig.Steps {
+		if step.Name == edison.MigrationSwitchDNS {
+			return step.Status == edison.MigrationStepDone
+		}
+	}
+	return false
+}
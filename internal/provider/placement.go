@@ -0,0 +1,28 @@
+package provider
+
+import (
+	"errors"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	edison "github.com/rahoolp/terraform-provider-edison/internal/client"
+)
+
+// placementDiagnostic turns an AW that couldn't be placed on an EHS Cluster
+// into an error diagnostic.
+func placementDiagnostic(err error) (*tfprotov6.Diagnostic, bool) {
+	var detail string
+	switch {
+	case errors.Is(err, edison.ErrNoEHSClusterRoom):
+		detail = "\n\nCreate an EHS Cluster in the AW's region and account, or scale one out, to make room for it."
+	case errors.Is(err, edison.ErrEHSClusterFull):
+		detail = "\n\nLower concurrent_users, scale the EHS Cluster out, or leave ehs_cluster_id unset to place the AW on a cluster with room."
+	case errors.Is(err, edison.ErrEHSClusterNotFound):
+	default:
+		return nil, false
+	}
+	return &tfprotov6.Diagnostic{
+		Severity: tfprotov6.DiagnosticSeverityError,
+		Summary:  "Can't place AW on an EHS Cluster",
+		Detail:   err.Error() + "." + detail,
+	}, true
+}
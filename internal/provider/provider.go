@@ -3,6 +3,7 @@ package provider
 import (
 	"context"
 	"os"
+	"strconv"
 
 	edison "github.com/rahoolp/terraform-provider-edison/internal/client"
 
@@ -18,7 +19,8 @@ func New() tfsdk.Provider {
 }
 
 type provider struct {
-	client *edison.Client
+	client        *edison.Client
+	defaultLabels map[string]string
 }
 
 func (p *provider) GetSchema(_ context.Context) (schema.Schema, []*tfprotov6.Diagnostic) {
@@ -32,13 +34,43 @@ func (p *provider) GetSchema(_ context.Context) (schema.Schema, []*tfprotov6.Dia
 				Type:     types.StringType,
 				Optional: true, //so that we can allow for enviornment variables as well
 			},
+			"ca_cert_file": {
+				Type:     types.StringType,
+				Optional: true,
+			},
+			"client_cert_file": {
+				Type:     types.StringType,
+				Optional: true,
+			},
+			"client_key_file": {
+				Type:     types.StringType,
+				Optional: true,
+			},
+			"insecure_skip_verify": {
+				Type:     types.BoolType,
+				Optional: true,
+			},
+			"proxy_url": {
+				Type:     types.StringType,
+				Optional: true,
+			},
+			"default_labels": {
+				Type:     types.MapType{ElemType: types.StringType},
+				Optional: true,
+			},
 		},
 	}, nil
 }
 
 type providerData struct {
-	Endpoint types.String `tfsdk:"api_endpoint"`
-	Token    types.String `tfsdk:"token"`
+	Endpoint           types.String `tfsdk:"api_endpoint"`
+	Token              types.String `tfsdk:"token"`
+	CACertFile         types.String `tfsdk:"ca_cert_file"`
+	ClientCertFile     types.String `tfsdk:"client_cert_file"`
+	ClientKeyFile      types.String `tfsdk:"client_key_file"`
+	InsecureSkipVerify types.Bool   `tfsdk:"insecure_skip_verify"`
+	ProxyURL           types.String `tfsdk:"proxy_url"`
+	DefaultLabels      types.Map    `tfsdk:"default_labels"`
 }
 
 func (p *provider) Configure(ctx context.Context, req tfsdk.ConfigureProviderRequest, resp *tfsdk.ConfigureProviderResponse) {
@@ -70,12 +102,59 @@ func (p *provider) Configure(ctx context.Context, req tfsdk.ConfigureProviderReq
 		})
 		return
 	}
+	for name, unknown := range map[string]bool{
+		"ca_cert_file":         config.CACertFile.Unknown,
+		"client_cert_file":     config.ClientCertFile.Unknown,
+		"client_key_file":      config.ClientKeyFile.Unknown,
+		"insecure_skip_verify": config.InsecureSkipVerify.Unknown,
+		"proxy_url":            config.ProxyURL.Unknown,
+		"default_labels":       config.DefaultLabels.Unknown,
+	} {
+		if unknown {
+			resp.Diagnostics = append(resp.Diagnostics, &tfprotov6.Diagnostic{
+				Severity:  tfprotov6.DiagnosticSeverityError,
+				Summary:   "Can't interpolate into provider block",
+				Detail:    "Interpolating that value into the provider block doesn't give the provider enough information to run. Try hard-coding the value, instead.",
+				Attribute: tftypes.NewAttributePath().WithAttributeName(name),
+			})
+		}
+	}
+	if len(resp.Diagnostics) > 0 {
+		return
+	}
 	if config.Endpoint.Null {
 		config.Endpoint.Value = os.Getenv("EDISON_API_ENDPOINT")
 	}
 	if config.Token.Null {
 		config.Token.Value = os.Getenv("EDISON_TOKEN")
 	}
+	if config.CACertFile.Null {
+		config.CACertFile.Value = os.Getenv("EDISON_CA_CERT_FILE")
+	}
+	if config.ClientCertFile.Null {
+		config.ClientCertFile.Value = os.Getenv("EDISON_CLIENT_CERT_FILE")
+	}
+	if config.ClientKeyFile.Null {
+		config.ClientKeyFile.Value = os.Getenv("EDISON_CLIENT_KEY_FILE")
+	}
+	if config.InsecureSkipVerify.Null {
+		if v := os.Getenv("EDISON_INSECURE_SKIP_VERIFY"); v != "" {
+			skip, err := strconv.ParseBool(v)
+			if err != nil {
+				resp.Diagnostics = append(resp.Diagnostics, &tfprotov6.Diagnostic{
+					Severity:  tfprotov6.DiagnosticSeverityError,
+					Summary:   "Invalid provider config",
+					Detail:    "EDISON_INSECURE_SKIP_VERIFY must be a boolean.\n\nDetails: " + err.Error(),
+					Attribute: tftypes.NewAttributePath().WithAttributeName("insecure_skip_verify"),
+				})
+				return
+			}
+			config.InsecureSkipVerify.Value = skip
+		}
+	}
+	if config.ProxyURL.Null {
+		config.ProxyURL.Value = os.Getenv("EDISON_PROXY_URL")
+	}
 	if config.Endpoint.Value == "" {
 		resp.Diagnostics = append(resp.Diagnostics, &tfprotov6.Diagnostic{
 			Severity:  tfprotov6.DiagnosticSeverityError,
@@ -94,7 +173,22 @@ func (p *provider) Configure(ctx context.Context, req tfsdk.ConfigureProviderReq
 		})
 		return
 	}
-	client, err := edison.NewClient(config.Endpoint.Value, config.Token.Value)
+	if (config.ClientCertFile.Value == "") != (config.ClientKeyFile.Value == "") {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov6.Diagnostic{
+			Severity:  tfprotov6.DiagnosticSeverityError,
+			Summary:   "Invalid provider config",
+			Detail:    "client_cert_file and client_key_file must be set together.",
+			Attribute: tftypes.NewAttributePath().WithAttributeName("client_key_file"),
+		})
+		return
+	}
+	client, err := edison.NewClient(config.Endpoint.Value, config.Token.Value, edison.TransportConfig{
+		CACertFile:         config.CACertFile.Value,
+		ClientCertFile:     config.ClientCertFile.Value,
+		ClientKeyFile:      config.ClientKeyFile.Value,
+		InsecureSkipVerify: config.InsecureSkipVerify.Value,
+		ProxyURL:           config.ProxyURL.Value,
+	})
 	if err != nil {
 		resp.Diagnostics = append(resp.Diagnostics, &tfprotov6.Diagnostic{
 			Severity: tfprotov6.DiagnosticSeverityError,
@@ -103,18 +197,29 @@ func (p *provider) Configure(ctx context.Context, req tfsdk.ConfigureProviderReq
 		})
 		return
 	}
+	resp.Diagnostics = append(resp.Diagnostics, checkAPIVersion(ctx, client)...)
 	p.client = client
+	p.defaultLabels = labelsFromMap(config.DefaultLabels)
 }
 
 func (p *provider) GetResources(_ context.Context) (map[string]tfsdk.ResourceType, []*tfprotov6.Diagnostic) {
 	return map[string]tfsdk.ResourceType{
-		"edison_eastore":    eastoreResourceType{},
-		"edison_ehscluster": ehsclusterResourceType{},
-		"edison_aw":         awResourceType{},
-		"edison_av":         avResourceType{},
+		"edison_eastore":              eastoreResourceType{},
+		"edison_ehscluster":           ehsclusterResourceType{},
+		"edison_aw":                   awResourceType{},
+		"edison_av":                   avResourceType{},
+		"edison_webhook":              webhookResourceType{},
+		"edison_ehscluster_node_pool": nodePoolResourceType{},
+		"edison_aw_schedule":          awScheduleResourceType{},
 	}, nil
 }
 
 func (p *provider) GetDataSources(_ context.Context) (map[string]tfsdk.DataSourceType, []*tfprotov6.Diagnostic) {
-	return map[string]tfsdk.DataSourceType{}, nil
+	return map[string]tfsdk.DataSourceType{
+		"edison_audit_events": auditEventsDataSourceType{},
+		"edison_quota_usage":  quotaUsageDataSourceType{},
+		"edison_releases":     releasesDataSourceType{},
+		"edison_regions":      regionsDataSourceType{},
+		"edison_profiles":     profilesDataSourceType{},
+	}, nil
 }
@@ -0,0 +1,22 @@
+package provider
+
+import (
+	"errors"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	edison "github.com/rahoolp/terraform-provider-edison/internal/client"
+)
+
+// quotaDiagnostic turns a QuotaExceededError into an error diagnostic, so
+// an apply that would go over quota fails instead of writing empty state.
+func quotaDiagnostic(err error) (*tfprotov6.Diagnostic, bool) {
+	var quotaErr edison.QuotaExceededError
+	if !errors.As(err, &quotaErr) {
+		return nil, false
+	}
+	return &tfprotov6.Diagnostic{
+		Severity: tfprotov6.DiagnosticSeverityError,
+		Summary:  "Quota exceeded",
+		Detail:   quotaErr.Error() + ".\n\nCheck the edison_quota_usage data source for current usage.",
+	}, true
+}
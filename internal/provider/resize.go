@@ -0,0 +1,53 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	edison "github.com/rahoolp/terraform-provider-edison/internal/client"
+)
+
+// resizeDiagnostic turns an error changing an EHS Cluster's profile into an
+// error diagnostic, so the apply fails instead of recording a profile the
+// cluster isn't running.
+func resizeDiagnostic(err error) (*tfprotov6.Diagnostic, bool) {
+	var failed edison.OperationFailedError
+	switch {
+	case errors.Is(err, edison.ErrUnknownProfile):
+		return &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "Invalid EHS Cluster profile",
+			Detail:   "The profile isn't in the catalog.",
+		}, true
+	case errors.Is(err, edison.ErrProfileTooSmall):
+		return &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "EHS Cluster profile is too small",
+			Detail:   "The EHS Cluster hosts more AW seats than the profile allows. Move or shrink its AWs first.",
+		}, true
+	case errors.As(err, &failed) && failed.Operation.Kind == "ehscluster.resize":
+		return &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "EHS Cluster resize didn't complete",
+			Detail:   failed.Error() + ". The cluster is still on its previous profile.",
+		}, true
+	}
+	return nil, false
+}
+
+// capacityWarning warns when a resize left an EHS Cluster with less
+// capacity than it had before. The warning comes with the apply, not the
+// plan: this version of the plugin framework has no way for resources to
+// modify plans, so there's nowhere to warn from while planning.
+func capacityWarning(prior, updated edison.EHSCluster) (*tfprotov6.Diagnostic, bool) {
+	if prior.Profile == updated.Profile || prior.Capacity == nil || updated.Capacity == nil || !updated.Capacity.Less(*prior.Capacity) {
+		return nil, false
+	}
+	return &tfprotov6.Diagnostic{
+		Severity: tfprotov6.DiagnosticSeverityWarning,
+		Summary:  "EHS Cluster capacity reduced",
+		Detail: fmt.Sprintf("Resizing from %s to %s reduced the cluster to %d nodes, %d vCPUs, %d GB of memory and %d AW seats.",
+			prior.Profile, updated.Profile, updated.Capacity.Nodes, updated.Capacity.VCPU, updated.Capacity.MemoryGB, updated.Capacity.MaxAWSeats),
+	}, true
+}
@@ -20,7 +20,7 @@ type avResourceType struct {
 
 func (e avResourceType) GetSchema(_ context.Context) (schema.Schema, []*tfprotov6.Diagnostic) {
 	return schema.Schema{
-		Attributes: map[string]schema.Attribute{
+		Attributes: labelAttributes(map[string]schema.Attribute{
 			"id": {
 				Type:     types.StringType,
 				Computed: true,
@@ -49,18 +49,20 @@ func (e avResourceType) GetSchema(_ context.Context) (schema.Schema, []*tfprotov
 				Type:     types.StringType,
 				Computed: true,
 			},
-		},
+		}),
 	}, nil
 }
 
 type avData struct {
-	ID           types.String `tfsdk:"id"`
-	AccountID    types.String `tfsdk:"account_id"`
-	TenantID     types.String `tfsdk:"tenant_id"`
-	TenantFolder types.String `tfsdk:"tenant_folder"`
-	TenantQueue  types.String `tfsdk:"tenant_queue"`
-	CreatedAt    types.String `tfsdk:"created_at"`
-	UpdatedAt    types.String `tfsdk:"updated_at"`
+	ID              types.String `tfsdk:"id"`
+	AccountID       types.String `tfsdk:"account_id"`
+	TenantID        types.String `tfsdk:"tenant_id"`
+	TenantFolder    types.String `tfsdk:"tenant_folder"`
+	TenantQueue     types.String `tfsdk:"tenant_queue"`
+	Labels          types.Map    `tfsdk:"labels"`
+	EffectiveLabels types.Map    `tfsdk:"effective_labels"`
+	CreatedAt       types.String `tfsdk:"created_at"`
+	UpdatedAt       types.String `tfsdk:"updated_at"`
 }
 
 func (s avResourceType) NewResource(_ context.Context, p tfsdk.Provider) (tfsdk.Resource, []*tfprotov6.Diagnostic) {
@@ -75,11 +77,12 @@ func (s avResourceType) NewResource(_ context.Context, p tfsdk.Provider) (tfsdk.
 		}
 	}
 
-	return avResource{client: prov.client}, nil
+	return avResource{client: prov.client, defaultLabels: prov.defaultLabels}, nil
 }
 
 type avResource struct {
-	client *edison.Client
+	client        *edison.Client
+	defaultLabels map[string]string
 }
 
 func (e avResource) Create(ctx context.Context, req tfsdk.CreateResourceRequest, resp *tfsdk.CreateResourceResponse) {
@@ -105,16 +108,35 @@ func (e avResource) Create(ctx context.Context, req tfsdk.CreateResourceRequest,
 	var updatedAt string = now.Format("2006-01-02 15:04:05")
 	var tenantQueue string = "arn:aws:mq:us-east-1:" + av.TenantID.Value
 
-	eav, err := e.client.AVs.Create(ctx, edison.AV{
+	op, err := e.client.AVs.StartCreate(ctx, edison.AV{
 		AccountID:    av.AccountID.Value,
 		TenantID:     av.TenantID.Value,
 		TenantFolder: tenantFolder,
 		TenantQueue:  tenantQueue,
+		Labels:       mergeLabels(e.defaultLabels, labelsFromMap(av.Labels)),
 		CreatedAt:    createdAt,
 		UpdatedAt:    updatedAt,
 	})
+	if err == nil {
+		op, err = waitForOperation(ctx, e.client, op)
+	}
+	var eav edison.AV
 	if err != nil {
-		tflog.Info(ctx, "AV Create: "+err.Error())
+		if diag, ok := quotaDiagnostic(err); ok {
+			resp.Diagnostics = append(resp.Diagnostics, diag)
+			return
+		}
+		resp.Diagnostics = append(resp.Diagnostics, operationDiagnostic("creating the AV", err))
+		return
+	}
+	err = waitFor(ctx, edison.ErrAVNotFound, func(index uint64) (uint64, bool, error) {
+		created, next, err := e.client.AVs.Watch(ctx, op.ResourceID, index, waitPollTimeout)
+		eav = created
+		return next, err == nil, err
+	})
+	if err != nil {
+		resp.Diagnostics = append(resp.Diagnostics, operationDiagnostic("creating the AV", err))
+		return
 	}
 
 	av.ID = types.String{Value: eav.ID}
@@ -122,6 +144,7 @@ func (e avResource) Create(ctx context.Context, req tfsdk.CreateResourceRequest,
 	av.UpdatedAt = types.String{Value: eav.UpdatedAt}
 	av.TenantFolder = types.String{Value: tenantFolder}
 	av.TenantQueue = types.String{Value: tenantQueue}
+	av.EffectiveLabels = mapFromLabels(eav.Labels)
 
 	err = resp.State.Set(ctx, &av)
 	if err != nil {
@@ -187,6 +210,11 @@ func (e avResource) Read(ctx context.Context, req tfsdk.ReadResourceRequest, res
 		tflog.Info(ctx, "AV Read: "+err.Error())
 	}
 
+	labels, err := req.State.GetAttribute(ctx, tftypes.NewAttributePath().WithAttributeName("labels"))
+	if err != nil {
+		tflog.Info(ctx, "AV Read: "+err.Error())
+	}
+
 	av, err := e.client.AVs.Get(ctx, id.(types.String).Value)
 	if err != nil && !errors.Is(err, edison.ErrEHSClusterNotFound) {
 		tflog.Info(ctx, "AV Read: "+err.Error())
@@ -196,13 +224,15 @@ func (e avResource) Read(ctx context.Context, req tfsdk.ReadResourceRequest, res
 	}
 
 	err = resp.State.Set(ctx, &avData{
-		ID:           types.String{Value: av.ID},
-		TenantID:     types.String{Value: av.TenantID},
-		AccountID:    types.String{Value: av.AccountID},
-		TenantFolder: types.String{Value: av.TenantFolder},
-		TenantQueue:  types.String{Value: av.TenantQueue},
-		CreatedAt:    types.String{Value: av.CreatedAt},
-		UpdatedAt:    types.String{Value: av.UpdatedAt},
+		ID:              types.String{Value: av.ID},
+		TenantID:        types.String{Value: av.TenantID},
+		AccountID:       types.String{Value: av.AccountID},
+		TenantFolder:    types.String{Value: av.TenantFolder},
+		TenantQueue:     types.String{Value: av.TenantQueue},
+		Labels:          mapFromLabels(configuredLabels(av.Labels, e.defaultLabels, labelsFromMap(labels.(types.Map)))),
+		EffectiveLabels: mapFromLabels(av.Labels),
+		CreatedAt:       types.String{Value: av.CreatedAt},
+		UpdatedAt:       types.String{Value: av.UpdatedAt},
 	})
 
 	if err != nil {
@@ -234,19 +264,33 @@ func (e avResource) Update(ctx context.Context, req tfsdk.UpdateResourceRequest,
 	now := time.Now()
 	var updatedAt string = now.Format("2006-01-02 15:04:05")
 
-	_, err = e.client.AVs.Update(ctx, edison.AV{
+	op, err := e.client.AVs.StartUpdate(ctx, edison.AV{
 		ID:           id.(types.String).Value,
 		TenantID:     av.TenantID.Value,
 		AccountID:    av.AccountID.Value,
 		TenantFolder: av.TenantFolder.Value,
 		TenantQueue:  av.TenantQueue.Value,
+		Labels:       mergeLabels(e.defaultLabels, labelsFromMap(av.Labels)),
 		CreatedAt:    av.CreatedAt.Value,
 		UpdatedAt:    updatedAt,
 	})
+	if err == nil {
+		_, err = waitForOperation(ctx, e.client, op)
+	}
+	var updated edison.AV
+	if err == nil {
+		updated, err = e.client.AVs.Get(ctx, id.(types.String).Value)
+	}
 	if err != nil {
-		tflog.Info(ctx, "AV Update: "+err.Error())
+		if diag, ok := quotaDiagnostic(err); ok {
+			resp.Diagnostics = append(resp.Diagnostics, diag)
+			return
+		}
+		resp.Diagnostics = append(resp.Diagnostics, operationDiagnostic("updating the AV", err))
+		return
 	}
 	av.ID = id.(types.String)
+	av.EffectiveLabels = mapFromLabels(updated.Labels)
 
 	err = resp.State.Set(ctx, &av)
 	if err != nil {
@@ -262,9 +306,13 @@ func (e avResource) Delete(ctx context.Context, req tfsdk.DeleteResourceRequest,
 	if err != nil {
 		tflog.Info(ctx, "AV Delete: "+err.Error())
 	}
-	err = e.client.AVs.Delete(ctx, id.(types.String).Value)
-	if err != nil && !errors.Is(err, edison.ErrEHSClusterNotFound) {
-		tflog.Info(ctx, "AV Delete: "+err.Error())
+	op, err := e.client.AVs.StartDelete(ctx, id.(types.String).Value)
+	if err == nil {
+		_, err = waitForOperation(ctx, e.client, op)
+	}
+	if err != nil && !errors.Is(err, edison.ErrAVNotFound) {
+		resp.Diagnostics = append(resp.Diagnostics, operationDiagnostic("deleting the AV", err))
+		return
 	}
 	resp.State.RemoveResource(ctx)
 }
@@ -20,7 +20,7 @@ type awResourceType struct {
 
 func (e awResourceType) GetSchema(_ context.Context) (schema.Schema, []*tfprotov6.Diagnostic) {
 	return schema.Schema{
-		Attributes: map[string]schema.Attribute{
+		Attributes: labelAttributes(map[string]schema.Attribute{
 			"id": {
 				Type:     types.StringType,
 				Computed: true,
@@ -31,6 +31,17 @@ func (e awResourceType) GetSchema(_ context.Context) (schema.Schema, []*tfprotov
 			},
 			"ehs_cluster_id": {
 				Type:     types.StringType,
+				Optional: true,
+				Computed: true,
+			},
+			"region": {
+				Type:     types.StringType,
+				Optional: true,
+				Computed: true,
+			},
+			"migration_strategy": {
+				Type:     types.StringType,
+				Optional: true,
 				Computed: true,
 			},
 			"dicom_endpoint": {
@@ -61,21 +72,25 @@ func (e awResourceType) GetSchema(_ context.Context) (schema.Schema, []*tfprotov
 				Type:     types.StringType,
 				Computed: true,
 			},
-		},
+		}),
 	}, nil
 }
 
 type awData struct {
-	ID              types.String `tfsdk:"id"`
-	ConcurrentUsers int          `tfsdk:"concurrent_users"`
-	DicomEndPoint   types.String `tfsdk:"dicom_endpoint"`
-	DNSEndPoint     types.String `tfsdk:"dns_endpoint"`
-	EHSClusterID    types.String `tfsdk:"ehs_cluster_id"`
-	EAAccounID      types.String `tfsdk:"ea_account_id"`
-	EAServiceEP     types.String `tfsdk:"ea_service_ep"`
-	EAVpcEP         types.String `tfsdk:"ea_vpc_ep"`
-	CreatedAt       types.String `tfsdk:"created_at"`
-	UpdatedAt       types.String `tfsdk:"updated_at"`
+	ID                types.String `tfsdk:"id"`
+	ConcurrentUsers   int          `tfsdk:"concurrent_users"`
+	DicomEndPoint     types.String `tfsdk:"dicom_endpoint"`
+	DNSEndPoint       types.String `tfsdk:"dns_endpoint"`
+	EHSClusterID      types.String `tfsdk:"ehs_cluster_id"`
+	Region            types.String `tfsdk:"region"`
+	MigrationStrategy types.String `tfsdk:"migration_strategy"`
+	EAAccounID        types.String `tfsdk:"ea_account_id"`
+	EAServiceEP       types.String `tfsdk:"ea_service_ep"`
+	EAVpcEP           types.String `tfsdk:"ea_vpc_ep"`
+	Labels            types.Map    `tfsdk:"labels"`
+	EffectiveLabels   types.Map    `tfsdk:"effective_labels"`
+	CreatedAt         types.String `tfsdk:"created_at"`
+	UpdatedAt         types.String `tfsdk:"updated_at"`
 }
 
 func (s awResourceType) NewResource(_ context.Context, p tfsdk.Provider) (tfsdk.Resource, []*tfprotov6.Diagnostic) {
@@ -89,11 +104,12 @@ func (s awResourceType) NewResource(_ context.Context, p tfsdk.Provider) (tfsdk.
 			},
 		}
 	}
-	return awResource{client: prov.client}, nil
+	return awResource{client: prov.client, defaultLabels: prov.defaultLabels}, nil
 }
 
 type awResource struct {
-	client *edison.Client
+	client        *edison.Client
+	defaultLabels map[string]string
 }
 
 func (e awResource) Create(ctx context.Context, req tfsdk.CreateResourceRequest, resp *tfsdk.CreateResourceResponse) {
@@ -118,26 +134,55 @@ func (e awResource) Create(ctx context.Context, req tfsdk.CreateResourceRequest,
 	var createdAt string = now.Format("2006-01-02 15:04:05")
 	var updatedAt string = now.Format("2006-01-02 15:04:05")
 
-	eaw, err := e.client.AWs.Create(ctx, edison.AW{
-		ConcurrentUsers: aw.ConcurrentUsers,
-		DicomEndPoint:   aw.DicomEndPoint.Value,
-		EHSClusterID:    aw.EHSClusterID.Value,
-		DNSEndPoint:     dnsEP,
-		EAAccounID:      aw.EAAccounID.Value,
-		EAServiceEP:     aw.EAServiceEP.Value,
-		EAVpcEP:         vpcEP,
-		CreatedAt:       createdAt,
-		UpdatedAt:       updatedAt,
+	op, err := e.client.AWs.StartCreate(ctx, edison.AW{
+		ConcurrentUsers:   aw.ConcurrentUsers,
+		DicomEndPoint:     aw.DicomEndPoint.Value,
+		EHSClusterID:      aw.EHSClusterID.Value,
+		Region:            aw.Region.Value,
+		MigrationStrategy: aw.MigrationStrategy.Value,
+		DNSEndPoint:       dnsEP,
+		EAAccounID:        aw.EAAccounID.Value,
+		EAServiceEP:       aw.EAServiceEP.Value,
+		EAVpcEP:           vpcEP,
+		Labels:            mergeLabels(e.defaultLabels, labelsFromMap(aw.Labels)),
+		CreatedAt:         createdAt,
+		UpdatedAt:         updatedAt,
 	})
+	if err == nil {
+		op, err = waitForOperation(ctx, e.client, op)
+	}
+	var eaw edison.AW
 	if err != nil {
-		tflog.Info(ctx, "AW Create: "+err.Error())
+		if diag, ok := quotaDiagnostic(err); ok {
+			resp.Diagnostics = append(resp.Diagnostics, diag)
+			return
+		}
+		if diag, ok := placementDiagnostic(err); ok {
+			resp.Diagnostics = append(resp.Diagnostics, diag)
+			return
+		}
+		resp.Diagnostics = append(resp.Diagnostics, operationDiagnostic("creating the AW", err))
+		return
+	}
+	err = waitFor(ctx, edison.ErrAWNotFound, func(index uint64) (uint64, bool, error) {
+		created, next, err := e.client.AWs.Watch(ctx, op.ResourceID, index, waitPollTimeout)
+		eaw = created
+		return next, err == nil, err
+	})
+	if err != nil {
+		resp.Diagnostics = append(resp.Diagnostics, operationDiagnostic("creating the AW", err))
+		return
 	}
 
 	aw.ID = types.String{Value: eaw.ID}
+	aw.EHSClusterID = types.String{Value: eaw.EHSClusterID}
+	aw.Region = types.String{Value: eaw.Region}
+	aw.MigrationStrategy = types.String{Value: eaw.MigrationStrategy}
 	aw.CreatedAt = types.String{Value: eaw.CreatedAt}
 	aw.UpdatedAt = types.String{Value: eaw.UpdatedAt}
 	aw.DNSEndPoint = types.String{Value: dnsEP}
 	aw.EAVpcEP = types.String{Value: vpcEP}
+	aw.EffectiveLabels = mapFromLabels(eaw.Labels)
 
 	err = resp.State.Set(ctx, &aw)
 	if err != nil {
@@ -154,24 +199,46 @@ func (e awResource) Read(ctx context.Context, req tfsdk.ReadResourceRequest, res
 		tflog.Info(ctx, "AW Read: "+err.Error())
 	}
 
+	labels, err := req.State.GetAttribute(ctx, tftypes.NewAttributePath().WithAttributeName("labels"))
+	if err != nil {
+		tflog.Info(ctx, "AW Read: "+err.Error())
+	}
+
 	aw, err := e.client.AWs.Get(ctx, id.(types.String).Value)
-	if err != nil && !errors.Is(err, edison.ErrEHSClusterNotFound) {
+	if err != nil && !errors.Is(err, edison.ErrAWNotFound) {
 		tflog.Info(ctx, "AW Read: "+err.Error())
-	} else if errors.Is(err, edison.ErrEHSClusterNotFound) {
+	} else if errors.Is(err, edison.ErrAWNotFound) {
 		resp.State.RemoveResource(ctx)
 		return
 	}
 
+	// A schedule changes the AW's concurrent users through the day, which
+	// isn't drift from the configuration.
+	concurrentUsers := aw.ConcurrentUsers
+	if aw.ScheduleID != "" {
+		prior, err := req.State.GetAttribute(ctx, tftypes.NewAttributePath().WithAttributeName("concurrent_users"))
+		if err != nil {
+			tflog.Info(ctx, "AW Read: "+err.Error())
+		} else {
+			concurrentUsers = intFromNumber(prior)
+		}
+	}
+
 	err = resp.State.Set(ctx, &awData{
-		ID:              types.String{Value: aw.ID},
-		ConcurrentUsers: aw.ConcurrentUsers,
-		DicomEndPoint:   types.String{Value: aw.DicomEndPoint},
-		DNSEndPoint:     types.String{Value: aw.DNSEndPoint},
-		EAAccounID:      types.String{Value: aw.EAAccounID},
-		EAServiceEP:     types.String{Value: aw.EAServiceEP},
-		EAVpcEP:         types.String{Value: aw.EAVpcEP},
-		CreatedAt:       types.String{Value: aw.CreatedAt},
-		UpdatedAt:       types.String{Value: aw.UpdatedAt},
+		ID:                types.String{Value: aw.ID},
+		ConcurrentUsers:   concurrentUsers,
+		DicomEndPoint:     types.String{Value: aw.DicomEndPoint},
+		DNSEndPoint:       types.String{Value: aw.DNSEndPoint},
+		EHSClusterID:      types.String{Value: aw.EHSClusterID},
+		Region:            types.String{Value: aw.Region},
+		MigrationStrategy: types.String{Value: aw.MigrationStrategy},
+		EAAccounID:        types.String{Value: aw.EAAccounID},
+		EAServiceEP:       types.String{Value: aw.EAServiceEP},
+		EAVpcEP:           types.String{Value: aw.EAVpcEP},
+		Labels:            mapFromLabels(configuredLabels(aw.Labels, e.defaultLabels, labelsFromMap(labels.(types.Map)))),
+		EffectiveLabels:   mapFromLabels(aw.Labels),
+		CreatedAt:         types.String{Value: aw.CreatedAt},
+		UpdatedAt:         types.String{Value: aw.UpdatedAt},
 	})
 
 	if err != nil {
@@ -194,24 +261,72 @@ func (e awResource) Update(ctx context.Context, req tfsdk.UpdateResourceRequest,
 		tflog.Info(ctx, "AW Update: "+err.Error())
 	}
 
+	// Without a configured ehs_cluster_id, the AW stays where it was
+	// placed.
+	clusterID := aw.EHSClusterID.Value
+	if aw.EHSClusterID.Unknown || aw.EHSClusterID.Null {
+		prior, err := req.State.GetAttribute(ctx, tftypes.NewAttributePath().WithAttributeName("ehs_cluster_id"))
+		if err != nil {
+			tflog.Info(ctx, "AW Update: "+err.Error())
+		} else {
+			clusterID = prior.(types.String).Value
+		}
+	}
+
 	now := time.Now()
 	var updatedAt string = now.Format("2006-01-02 15:04:05")
 
-	_, err = e.client.AWs.Update(ctx, edison.AW{
-		ID:              id.(types.String).Value,
-		ConcurrentUsers: aw.ConcurrentUsers,
-		DicomEndPoint:   aw.DicomEndPoint.Value,
-		DNSEndPoint:     aw.DNSEndPoint.Value,
-		EAAccounID:      aw.EAAccounID.Value,
-		EAServiceEP:     aw.EAServiceEP.Value,
-		EAVpcEP:         aw.EAVpcEP.Value,
-		CreatedAt:       aw.CreatedAt.Value,
-		UpdatedAt:       updatedAt,
+	op, err := e.client.AWs.StartUpdate(ctx, edison.AW{
+		ID:                id.(types.String).Value,
+		ConcurrentUsers:   aw.ConcurrentUsers,
+		DicomEndPoint:     aw.DicomEndPoint.Value,
+		DNSEndPoint:       aw.DNSEndPoint.Value,
+		EHSClusterID:      clusterID,
+		Region:            aw.Region.Value,
+		MigrationStrategy: aw.MigrationStrategy.Value,
+		EAAccounID:        aw.EAAccounID.Value,
+		EAServiceEP:       aw.EAServiceEP.Value,
+		EAVpcEP:           aw.EAVpcEP.Value,
+		Labels:            mergeLabels(e.defaultLabels, labelsFromMap(aw.Labels)),
+		CreatedAt:         aw.CreatedAt.Value,
+		UpdatedAt:         updatedAt,
 	})
+	if err == nil {
+		_, err = waitForOperation(ctx, e.client, op)
+	}
+	var updated edison.AW
+	if err == nil {
+		updated, err = e.client.AWs.Get(ctx, id.(types.String).Value)
+	}
+	// Moving the AW to another EHS Cluster migrates it in place.
+	if err == nil && updated.MigrationID != "" {
+		err = waitForMigration(ctx, e.client, updated)
+		if err == nil {
+			updated, err = e.client.AWs.Get(ctx, id.(types.String).Value)
+		}
+	}
 	if err != nil {
-		tflog.Info(ctx, "AW Update: "+err.Error())
+		if diag, ok := quotaDiagnostic(err); ok {
+			resp.Diagnostics = append(resp.Diagnostics, diag)
+			return
+		}
+		if diag, ok := placementDiagnostic(err); ok {
+			resp.Diagnostics = append(resp.Diagnostics, diag)
+			return
+		}
+		if diag, ok := migrationDiagnostic(err); ok {
+			resp.Diagnostics = append(resp.Diagnostics, diag)
+			return
+		}
+		resp.Diagnostics = append(resp.Diagnostics, operationDiagnostic("updating the AW", err))
+		return
 	}
 	aw.ID = id.(types.String)
+	aw.EHSClusterID = types.String{Value: updated.EHSClusterID}
+	aw.Region = types.String{Value: updated.Region}
+	aw.DNSEndPoint = types.String{Value: updated.DNSEndPoint}
+	aw.MigrationStrategy = types.String{Value: updated.MigrationStrategy}
+	aw.EffectiveLabels = mapFromLabels(updated.Labels)
 
 	err = resp.State.Set(ctx, &aw)
 	if err != nil {
@@ -227,9 +342,13 @@ func (e awResource) Delete(ctx context.Context, req tfsdk.DeleteResourceRequest,
 	if err != nil {
 		tflog.Info(ctx, "AW Delete: "+err.Error())
 	}
-	err = e.client.AWs.Delete(ctx, id.(types.String).Value)
-	if err != nil && !errors.Is(err, edison.ErrEHSClusterNotFound) {
-		tflog.Info(ctx, "AW Delete: "+err.Error())
+	op, err := e.client.AWs.StartDelete(ctx, id.(types.String).Value)
+	if err == nil {
+		_, err = waitForOperation(ctx, e.client, op)
+	}
+	if err != nil && !errors.Is(err, edison.ErrAWNotFound) {
+		resp.Diagnostics = append(resp.Diagnostics, operationDiagnostic("deleting the AW", err))
+		return
 	}
 	resp.State.RemoveResource(ctx)
 }
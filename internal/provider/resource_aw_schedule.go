@@ -0,0 +1,304 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	edison "github.com/rahoolp/terraform-provider-edison/internal/client"
+)
+
+var scheduleRuleAttrTypes = map[string]attr.Type{
+	"cron":             types.StringType,
+	"concurrent_users": types.NumberType,
+}
+
+var scheduledActionAttrTypes = map[string]attr.Type{
+	"at":               types.StringType,
+	"cron":             types.StringType,
+	"concurrent_users": types.NumberType,
+}
+
+type awScheduleResourceType struct {
+}
+
+func (a awScheduleResourceType) GetSchema(_ context.Context) (schema.Schema, []*tfprotov6.Diagnostic) {
+	return schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"id": {
+				Type:     types.StringType,
+				Computed: true,
+			},
+			"aw_id": {
+				Type:     types.StringType,
+				Required: true,
+			},
+			"timezone": {
+				Type:     types.StringType,
+				Optional: true,
+				Computed: true,
+			},
+			"rules": {
+				Type:     types.ListType{ElemType: types.ObjectType{AttrTypes: scheduleRuleAttrTypes}},
+				Required: true,
+			},
+			"next_action": {
+				Type:     types.ObjectType{AttrTypes: scheduledActionAttrTypes},
+				Computed: true,
+			},
+			"created_at": {
+				Type:     types.StringType,
+				Computed: true,
+			},
+			"updated_at": {
+				Type:     types.StringType,
+				Computed: true,
+			},
+		},
+	}, nil
+}
+
+type awScheduleData struct {
+	ID         types.String `tfsdk:"id"`
+	AWID       types.String `tfsdk:"aw_id"`
+	Timezone   types.String `tfsdk:"timezone"`
+	Rules      types.List   `tfsdk:"rules"`
+	NextAction types.Object `tfsdk:"next_action"`
+	CreatedAt  types.String `tfsdk:"created_at"`
+	UpdatedAt  types.String `tfsdk:"updated_at"`
+}
+
+func (a awScheduleResourceType) NewResource(_ context.Context, p tfsdk.Provider) (tfsdk.Resource, []*tfprotov6.Diagnostic) {
+	prov, ok := p.(*provider)
+	if !ok {
+		return nil, []*tfprotov6.Diagnostic{
+			{
+				Severity: tfprotov6.DiagnosticSeverityError,
+				Summary:  "Error converting provider",
+				Detail:   fmt.Sprintf("An unexpected error was encountered converting the provider. This is always a bug in the provider.\n\nType: %T", p),
+			},
+		}
+	}
+	return awScheduleResource{client: prov.client}, nil
+}
+
+type awScheduleResource struct {
+	client *edison.Client
+}
+
+func scheduleRulesFromList(list types.List) []edison.ScheduleRule {
+	if list.Null || list.Unknown {
+		return nil
+	}
+	var results []edison.ScheduleRule
+	for _, elem := range list.Elems {
+		attrs := elem.(types.Object).Attrs
+		results = append(results, edison.ScheduleRule{
+			Cron:            attrs["cron"].(types.String).Value,
+			ConcurrentUsers: intFromNumber(attrs["concurrent_users"]),
+		})
+	}
+	return results
+}
+
+func listFromScheduleRules(rules []edison.ScheduleRule) types.List {
+	list := types.List{ElemType: types.ObjectType{AttrTypes: scheduleRuleAttrTypes}}
+	for _, rule := range rules {
+		list.Elems = append(list.Elems, types.Object{
+			AttrTypes: scheduleRuleAttrTypes,
+			Attrs: map[string]attr.Value{
+				"cron":             types.String{Value: rule.Cron},
+				"concurrent_users": numberFromInt(rule.ConcurrentUsers),
+			},
+		})
+	}
+	return list
+}
+
+func objectFromScheduledAction(action *edison.ScheduledAction) types.Object {
+	obj := types.Object{AttrTypes: scheduledActionAttrTypes}
+	if action == nil {
+		obj.Null = true
+		return obj
+	}
+	obj.Attrs = map[string]attr.Value{
+		"at":               types.String{Value: action.At},
+		"cron":             types.String{Value: action.Cron},
+		"concurrent_users": numberFromInt(action.ConcurrentUsers),
+	}
+	return obj
+}
+
+// setFromSchedule copies what the server has for the schedule into data.
+func (data *awScheduleData) setFromSchedule(sched edison.AWSchedule) {
+	data.ID = types.String{Value: sched.ID}
+	data.AWID = types.String{Value: sched.AWID}
+	data.Timezone = types.String{Value: sched.Timezone}
+	data.Rules = listFromScheduleRules(sched.Rules)
+	data.NextAction = objectFromScheduledAction(sched.NextAction)
+	data.CreatedAt = types.String{Value: sched.CreatedAt}
+	data.UpdatedAt = types.String{Value: sched.UpdatedAt}
+}
+
+// awScheduleDiagnostic turns an error creating or changing an AW schedule
+// into an error diagnostic.
+func awScheduleDiagnostic(err error) (*tfprotov6.Diagnostic, bool) {
+	switch {
+	case errors.Is(err, edison.ErrAWAlreadyScheduled):
+		return &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "AW already has a schedule",
+			Detail:   "An AW can only have one schedule. Add these rules to its existing schedule instead.",
+		}, true
+	case errors.Is(err, edison.ErrAWNotFound):
+		return &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "AW not found",
+			Detail:   "The schedule's aw_id doesn't name an existing AW.",
+		}, true
+	}
+	return nil, false
+}
+
+func (a awScheduleResource) Create(ctx context.Context, req tfsdk.CreateResourceRequest, resp *tfsdk.CreateResourceResponse) {
+
+	tflog.Info(ctx, "AW Schedule Create..")
+
+	var data awScheduleData
+	err := req.Plan.Get(ctx, &data)
+	if err != nil {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "Error parsing plan",
+			Detail:   "An unexpected error was encountered parsing the plan. This is always a bug in the provider.\n\nDetails: " + err.Error(),
+		})
+		return
+	}
+
+	sched, err := a.client.AWSchedules.Create(ctx, edison.AWSchedule{
+		AWID:     data.AWID.Value,
+		Timezone: data.Timezone.Value,
+		Rules:    scheduleRulesFromList(data.Rules),
+	})
+	if err != nil {
+		if diag, ok := awScheduleDiagnostic(err); ok {
+			resp.Diagnostics = append(resp.Diagnostics, diag)
+			return
+		}
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "Error creating AW schedule",
+			Detail:   "An unexpected error was encountered creating the AW schedule.\n\nDetails: " + err.Error(),
+		})
+		return
+	}
+
+	data.setFromSchedule(sched)
+
+	err = resp.State.Set(ctx, &data)
+	if err != nil {
+		tflog.Info(ctx, "AW Schedule Create: "+err.Error())
+	}
+}
+
+func (a awScheduleResource) Read(ctx context.Context, req tfsdk.ReadResourceRequest, resp *tfsdk.ReadResourceResponse) {
+
+	tflog.Info(ctx, "AW Schedule Read..")
+
+	var data awScheduleData
+	err := req.State.Get(ctx, &data)
+	if err != nil {
+		tflog.Info(ctx, "AW Schedule Read: "+err.Error())
+		return
+	}
+
+	sched, err := a.client.AWSchedules.Get(ctx, data.ID.Value)
+	if errors.Is(err, edison.ErrAWScheduleNotFound) {
+		resp.State.RemoveResource(ctx)
+		return
+	} else if err != nil {
+		tflog.Info(ctx, "AW Schedule Read: "+err.Error())
+		return
+	}
+
+	data.setFromSchedule(sched)
+
+	err = resp.State.Set(ctx, &data)
+	if err != nil {
+		tflog.Info(ctx, "AW Schedule Read: "+err.Error())
+	}
+}
+
+func (a awScheduleResource) Update(ctx context.Context, req tfsdk.UpdateResourceRequest, resp *tfsdk.UpdateResourceResponse) {
+
+	tflog.Info(ctx, "AW Schedule Update..")
+
+	var prior awScheduleData
+	err := req.State.Get(ctx, &prior)
+	if err != nil {
+		tflog.Info(ctx, "AW Schedule Update: "+err.Error())
+	}
+
+	var data awScheduleData
+	err = req.Plan.Get(ctx, &data)
+	if err != nil {
+		tflog.Info(ctx, "AW Schedule Update: "+err.Error())
+	}
+
+	if data.AWID.Value != prior.AWID.Value {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "AW schedule can't be moved",
+			Detail:   "A schedule's aw_id is set when it's created. Replace the schedule to set a different AW's concurrent users.",
+		})
+		return
+	}
+
+	sched, err := a.client.AWSchedules.Update(ctx, edison.AWSchedule{
+		ID:       prior.ID.Value,
+		AWID:     prior.AWID.Value,
+		Timezone: data.Timezone.Value,
+		Rules:    scheduleRulesFromList(data.Rules),
+	})
+	if err != nil {
+		if diag, ok := awScheduleDiagnostic(err); ok {
+			resp.Diagnostics = append(resp.Diagnostics, diag)
+			return
+		}
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "Error updating AW schedule",
+			Detail:   "An unexpected error was encountered updating the AW schedule.\n\nDetails: " + err.Error(),
+		})
+		return
+	}
+
+	data.setFromSchedule(sched)
+
+	err = resp.State.Set(ctx, &data)
+	if err != nil {
+		tflog.Info(ctx, "AW Schedule Update: "+err.Error())
+	}
+}
+
+func (a awScheduleResource) Delete(ctx context.Context, req tfsdk.DeleteResourceRequest, resp *tfsdk.DeleteResourceResponse) {
+
+	tflog.Info(ctx, "AW Schedule Delete..")
+
+	id, err := req.State.GetAttribute(ctx, tftypes.NewAttributePath().WithAttributeName("id"))
+	if err != nil {
+		tflog.Info(ctx, "AW Schedule Delete: "+err.Error())
+	}
+	err = a.client.AWSchedules.Delete(ctx, id.(types.String).Value)
+	if err != nil && !errors.Is(err, edison.ErrAWScheduleNotFound) {
+		tflog.Info(ctx, "AW Schedule Delete: "+err.Error())
+	}
+	resp.State.RemoveResource(ctx)
+}
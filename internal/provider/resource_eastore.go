@@ -20,7 +20,7 @@ type eastoreResourceType struct {
 
 func (e eastoreResourceType) GetSchema(_ context.Context) (schema.Schema, []*tfprotov6.Diagnostic) {
 	return schema.Schema{
-		Attributes: map[string]schema.Attribute{
+		Attributes: labelAttributes(map[string]schema.Attribute{
 			"id": {
 				Type:     types.StringType,
 				Computed: true,
@@ -57,7 +57,11 @@ func (e eastoreResourceType) GetSchema(_ context.Context) (schema.Schema, []*tfp
 				Type:     types.StringType,
 				Computed: true,
 			},
-		},
+			"force_destroy": {
+				Type:     types.BoolType,
+				Optional: true,
+			},
+		}),
 	}, nil
 }
 
@@ -69,8 +73,11 @@ type eastoreData struct {
 	AET              types.String `tfsdk:"aet"`
 	AccountID        types.String `tfsdk:"account_id"`
 	ServiceEP        types.String `tfsdk:"service_ep"`
+	Labels           types.Map    `tfsdk:"labels"`
+	EffectiveLabels  types.Map    `tfsdk:"effective_labels"`
 	CreatedAt        types.String `tfsdk:"created_at"`
 	UpdatedAt        types.String `tfsdk:"updated_at"`
+	ForceDestroy     types.Bool   `tfsdk:"force_destroy"`
 }
 
 func (s eastoreResourceType) NewResource(_ context.Context, p tfsdk.Provider) (tfsdk.Resource, []*tfprotov6.Diagnostic) {
@@ -84,11 +91,12 @@ func (s eastoreResourceType) NewResource(_ context.Context, p tfsdk.Provider) (t
 			},
 		}
 	}
-	return eastoreResource{client: prov.client}, nil
+	return eastoreResource{client: prov.client, defaultLabels: prov.defaultLabels}, nil
 }
 
 type eastoreResource struct {
-	client *edison.Client
+	client        *edison.Client
+	defaultLabels map[string]string
 }
 
 func (e eastoreResource) Create(ctx context.Context, req tfsdk.CreateResourceRequest, resp *tfsdk.CreateResourceResponse) {
@@ -117,7 +125,7 @@ func (e eastoreResource) Create(ctx context.Context, req tfsdk.CreateResourceReq
 	var createdAt string = now.Format("2006-01-02 15:04:05")
 	var updatedAt string = now.Format("2006-01-02 15:04:05")
 
-	eastore, err := e.client.EAStores.Create(ctx, edison.EAStore{
+	op, err := e.client.EAStores.StartCreate(ctx, edison.EAStore{
 		ID:               id,
 		PartitionSpaceTB: eastr.PartitionSpaceTB,
 		IPAddress:        ipAddress,
@@ -125,11 +133,30 @@ func (e eastoreResource) Create(ctx context.Context, req tfsdk.CreateResourceReq
 		AET:              aet,
 		AccountID:        account_id,
 		ServiceEP:        service_ep,
+		Labels:           mergeLabels(e.defaultLabels, labelsFromMap(eastr.Labels)),
 		CreatedAt:        createdAt,
 		UpdatedAt:        updatedAt,
 	})
+	if err == nil {
+		op, err = waitForOperation(ctx, e.client, op)
+	}
+	var eastore edison.EAStore
 	if err != nil {
-		tflog.Info(ctx, "EA Store Create: "+err.Error())
+		if diag, ok := quotaDiagnostic(err); ok {
+			resp.Diagnostics = append(resp.Diagnostics, diag)
+			return
+		}
+		resp.Diagnostics = append(resp.Diagnostics, operationDiagnostic("creating the EA Store", err))
+		return
+	}
+	err = waitFor(ctx, edison.ErrEAStoreNotFound, func(index uint64) (uint64, bool, error) {
+		created, next, err := e.client.EAStores.Watch(ctx, op.ResourceID, index, waitPollTimeout)
+		eastore = created
+		return next, err == nil, err
+	})
+	if err != nil {
+		resp.Diagnostics = append(resp.Diagnostics, operationDiagnostic("creating the EA Store", err))
+		return
 	}
 
 	eastr.ID = types.String{Value: eastore.ID}
@@ -141,6 +168,7 @@ func (e eastoreResource) Create(ctx context.Context, req tfsdk.CreateResourceReq
 	eastr.ServiceEP = types.String{Value: service_ep}
 	eastr.CreatedAt = types.String{Value: createdAt}
 	eastr.UpdatedAt = types.String{Value: updatedAt}
+	eastr.EffectiveLabels = mapFromLabels(eastore.Labels)
 
 	err = resp.State.Set(ctx, &eastr)
 	if err != nil {
@@ -157,6 +185,16 @@ func (e eastoreResource) Read(ctx context.Context, req tfsdk.ReadResourceRequest
 		tflog.Info(ctx, "EA Store Read: "+err.Error())
 	}
 
+	labels, err := req.State.GetAttribute(ctx, tftypes.NewAttributePath().WithAttributeName("labels"))
+	if err != nil {
+		tflog.Info(ctx, "EA Store Read: "+err.Error())
+	}
+
+	forceDestroy, err := req.State.GetAttribute(ctx, tftypes.NewAttributePath().WithAttributeName("force_destroy"))
+	if err != nil {
+		tflog.Info(ctx, "EA Store Read: "+err.Error())
+	}
+
 	eastr, err := e.client.EAStores.Get(ctx, id.(types.String).Value)
 	if err != nil && !errors.Is(err, edison.ErrEAStoreNotFound) {
 		tflog.Info(ctx, "EA Store Read: "+err.Error())
@@ -173,8 +211,11 @@ func (e eastoreResource) Read(ctx context.Context, req tfsdk.ReadResourceRequest
 		AET:              types.String{Value: eastr.AET},
 		AccountID:        types.String{Value: eastr.AccountID},
 		ServiceEP:        types.String{Value: eastr.ServiceEP},
+		Labels:           mapFromLabels(configuredLabels(eastr.Labels, e.defaultLabels, labelsFromMap(labels.(types.Map)))),
+		EffectiveLabels:  mapFromLabels(eastr.Labels),
 		CreatedAt:        types.String{Value: eastr.CreatedAt},
 		UpdatedAt:        types.String{Value: eastr.UpdatedAt},
+		ForceDestroy:     forceDestroy.(types.Bool),
 	})
 
 	if err != nil {
@@ -200,7 +241,7 @@ func (e eastoreResource) Update(ctx context.Context, req tfsdk.UpdateResourceReq
 	now := time.Now()
 	var updatedAt string = now.Format("2006-01-02 15:04:05")
 
-	_, err = e.client.EAStores.Update(ctx, edison.EAStore{
+	op, err := e.client.EAStores.StartUpdate(ctx, edison.EAStore{
 		ID:               id.(types.String).Value,
 		PartitionSpaceTB: eastr.PartitionSpaceTB,
 		IPAddress:        eastr.IPAddress.Value,
@@ -208,13 +249,27 @@ func (e eastoreResource) Update(ctx context.Context, req tfsdk.UpdateResourceReq
 		AET:              eastr.AET.Value,
 		AccountID:        eastr.AccountID.Value,
 		ServiceEP:        eastr.IPAddress.Value,
+		Labels:           mergeLabels(e.defaultLabels, labelsFromMap(eastr.Labels)),
 		CreatedAt:        eastr.CreatedAt.Value,
 		UpdatedAt:        updatedAt,
 	})
+	if err == nil {
+		_, err = waitForOperation(ctx, e.client, op)
+	}
+	var updated edison.EAStore
+	if err == nil {
+		updated, err = e.client.EAStores.Get(ctx, id.(types.String).Value)
+	}
 	if err != nil {
-		tflog.Info(ctx, "EA Store Update: "+err.Error())
+		if diag, ok := quotaDiagnostic(err); ok {
+			resp.Diagnostics = append(resp.Diagnostics, diag)
+			return
+		}
+		resp.Diagnostics = append(resp.Diagnostics, operationDiagnostic("updating the EA Store", err))
+		return
 	}
 	eastr.ID = id.(types.String)
+	eastr.EffectiveLabels = mapFromLabels(updated.Labels)
 
 	err = resp.State.Set(ctx, &eastr)
 	if err != nil {
@@ -230,9 +285,22 @@ func (e eastoreResource) Delete(ctx context.Context, req tfsdk.DeleteResourceReq
 	if err != nil {
 		tflog.Info(ctx, "EA Store Delete: "+err.Error())
 	}
-	err = e.client.EAStores.Delete(ctx, id.(types.String).Value)
-	if err != nil && !errors.Is(err, edison.ErrEAStoreNotFound) {
+	forceDestroy, err := req.State.GetAttribute(ctx, tftypes.NewAttributePath().WithAttributeName("force_destroy"))
+	if err != nil {
 		tflog.Info(ctx, "EA Store Delete: "+err.Error())
 	}
+	if forceDestroy.(types.Bool).Value {
+		err = e.client.EAStores.ForceDelete(ctx, id.(types.String).Value)
+	} else {
+		var op edison.Operation
+		op, err = e.client.EAStores.StartDelete(ctx, id.(types.String).Value)
+		if err == nil {
+			_, err = waitForOperation(ctx, e.client, op)
+		}
+	}
+	if err != nil && !errors.Is(err, edison.ErrEAStoreNotFound) {
+		resp.Diagnostics = append(resp.Diagnostics, operationDiagnostic("deleting the EA Store", err))
+		return
+	}
 	resp.State.RemoveResource(ctx)
 }
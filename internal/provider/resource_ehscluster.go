@@ -20,7 +20,7 @@ type ehsclusterResourceType struct {
 
 func (e ehsclusterResourceType) GetSchema(_ context.Context) (schema.Schema, []*tfprotov6.Diagnostic) {
 	return schema.Schema{
-		Attributes: map[string]schema.Attribute{
+		Attributes: labelAttributes(map[string]schema.Attribute{
 			"id": {
 				Type:     types.StringType,
 				Computed: true,
@@ -32,10 +32,14 @@ func (e ehsclusterResourceType) GetSchema(_ context.Context) (schema.Schema, []*
 			"profile": {
 				Type:     types.StringType,
 				Required: true,
+				Description: "The EHS Cluster's profile. Changing it resizes the cluster in place. " +
+					"A resize that reduces capacity is warned about when it's applied, not when it's planned.",
 			},
 			"release": {
 				Type:     types.StringType,
 				Required: true,
+				Description: "The EHS Cluster's release. Deprecated and EOL releases are reported " +
+					"when the cluster is created, updated, or refreshed, not when it's planned.",
 			},
 			"tag": {
 				Type:     types.StringType,
@@ -57,6 +61,19 @@ func (e ehsclusterResourceType) GetSchema(_ context.Context) (schema.Schema, []*
 				Type:     types.StringType,
 				Computed: true,
 			},
+			"account_id": {
+				Type:     types.StringType,
+				Optional: true,
+			},
+			"autoscaling": autoscalingAttribute(),
+			"current_nodes": {
+				Type:     types.NumberType,
+				Computed: true,
+			},
+			"desired_nodes": {
+				Type:     types.NumberType,
+				Computed: true,
+			},
 			"created_at": {
 				Type:     types.StringType,
 				Computed: true,
@@ -65,7 +82,7 @@ func (e ehsclusterResourceType) GetSchema(_ context.Context) (schema.Schema, []*
 				Type:     types.StringType,
 				Computed: true,
 			},
-		},
+		}),
 	}, nil
 }
 
@@ -79,6 +96,12 @@ type ehsclusterData struct {
 	APIServerEndPoint types.String `tfsdk:"api_server_endpoint"`
 	VPC               types.String `tfsdk:"vpc"`
 	ClusterName       types.String `tfsdk:"cluster_name"`
+	AccountID         types.String `tfsdk:"account_id"`
+	Autoscaling       types.Object `tfsdk:"autoscaling"`
+	CurrentNodes      types.Number `tfsdk:"current_nodes"`
+	DesiredNodes      types.Number `tfsdk:"desired_nodes"`
+	Labels            types.Map    `tfsdk:"labels"`
+	EffectiveLabels   types.Map    `tfsdk:"effective_labels"`
 	CreatedAt         types.String `tfsdk:"created_at"`
 	UpdatedAt         types.String `tfsdk:"updated_at"`
 }
@@ -94,11 +117,12 @@ func (s ehsclusterResourceType) NewResource(_ context.Context, p tfsdk.Provider)
 			},
 		}
 	}
-	return ehsclusterResource{client: prov.client}, nil
+	return ehsclusterResource{client: prov.client, defaultLabels: prov.defaultLabels}, nil
 }
 
 type ehsclusterResource struct {
-	client *edison.Client
+	client        *edison.Client
+	defaultLabels map[string]string
 }
 
 func (e ehsclusterResource) Create(ctx context.Context, req tfsdk.CreateResourceRequest, resp *tfsdk.CreateResourceResponse) {
@@ -117,6 +141,13 @@ func (e ehsclusterResource) Create(ctx context.Context, req tfsdk.CreateResource
 		return
 	}
 
+	if diag, ok := releaseDiagnostic(ctx, e.client, ehscluster.Release.Value, true); ok {
+		resp.Diagnostics = append(resp.Diagnostics, diag)
+		if diag.Severity == tfprotov6.DiagnosticSeverityError {
+			return
+		}
+	}
+
 	var vpc string = "vpc-0c6aa52f85161d3cc"
 	var apiSrvEP string = "https://5a4028bb2291be0fa29ab9717a8b9e92.gr7.us-east-1.eks.amazonaws.com/"
 	var cluster_name string = ehscluster.Tag.Value
@@ -124,20 +155,53 @@ func (e ehsclusterResource) Create(ctx context.Context, req tfsdk.CreateResource
 	var createdAt string = now.Format("2006-01-02 15:04:05")
 	var updatedAt string = now.Format("2006-01-02 15:04:05")
 
-	ecluster, err := e.client.EHSClusters.Create(ctx, edison.EHSCluster{
+	op, err := e.client.EHSClusters.StartCreate(ctx, edison.EHSCluster{
 		Region:            ehscluster.Region.Value,
 		Profile:           ehscluster.Profile.Value,
 		Release:           ehscluster.Release.Value,
 		VPC:               vpc,
 		Tag:               ehscluster.Tag.Value,
 		ClusterName:       cluster_name,
+		AccountID:         ehscluster.AccountID.Value,
 		APIServerEndPoint: apiSrvEP,
+		Autoscaling:       autoscalingFromObject(ehscluster.Autoscaling),
 		//DicomEndPoint:     ehscluster.DicomEndPoint.Value,
+		Labels:    mergeLabels(e.defaultLabels, labelsFromMap(ehscluster.Labels)),
 		CreatedAt: createdAt,
 		UpdatedAt: updatedAt,
 	})
+	if err == nil {
+		op, err = waitForOperation(ctx, e.client, op)
+	}
+	var ecluster edison.EHSCluster
 	if err != nil {
-		tflog.Info(ctx, "EHS Cluster Create: "+err.Error())
+		if diag, ok := quotaDiagnostic(err); ok {
+			resp.Diagnostics = append(resp.Diagnostics, diag)
+			return
+		}
+		if diag, ok := autoscalingDiagnostic(err); ok {
+			resp.Diagnostics = append(resp.Diagnostics, diag)
+			return
+		}
+		if errors.Is(err, edison.ErrReleaseEndOfLife) {
+			resp.Diagnostics = append(resp.Diagnostics, &tfprotov6.Diagnostic{
+				Severity: tfprotov6.DiagnosticSeverityError,
+				Summary:  "EHS release " + ehscluster.Release.Value + " is end of life",
+				Detail:   err.Error() + ". Choose a GA release.",
+			})
+			return
+		}
+		resp.Diagnostics = append(resp.Diagnostics, operationDiagnostic("creating the EHS Cluster", err))
+		return
+	}
+	err = waitFor(ctx, edison.ErrEHSClusterNotFound, func(index uint64) (uint64, bool, error) {
+		cluster, next, err := e.client.EHSClusters.Watch(ctx, op.ResourceID, index, waitPollTimeout)
+		ecluster = cluster
+		return next, err == nil, err
+	})
+	if err != nil {
+		resp.Diagnostics = append(resp.Diagnostics, operationDiagnostic("creating the EHS Cluster", err))
+		return
 	}
 
 	ehscluster.ID = types.String{Value: ecluster.ID}
@@ -146,6 +210,9 @@ func (e ehsclusterResource) Create(ctx context.Context, req tfsdk.CreateResource
 	ehscluster.APIServerEndPoint = types.String{Value: apiSrvEP}
 	ehscluster.VPC = types.String{Value: vpc}
 	ehscluster.ClusterName = types.String{Value: cluster_name}
+	ehscluster.CurrentNodes = numberFromInt(ecluster.CurrentNodes)
+	ehscluster.DesiredNodes = numberFromInt(ecluster.DesiredNodes)
+	ehscluster.EffectiveLabels = mapFromLabels(ecluster.Labels)
 
 	err = resp.State.Set(ctx, &ehscluster)
 	if err != nil {
@@ -162,6 +229,11 @@ func (e ehsclusterResource) Read(ctx context.Context, req tfsdk.ReadResourceRequ
 		tflog.Info(ctx, "EHS Cluster Read: "+err.Error())
 	}
 
+	labels, err := req.State.GetAttribute(ctx, tftypes.NewAttributePath().WithAttributeName("labels"))
+	if err != nil {
+		tflog.Info(ctx, "EHS Cluster Read: "+err.Error())
+	}
+
 	ehscluster, err := e.client.EHSClusters.Get(ctx, id.(types.String).Value)
 	if err != nil && !errors.Is(err, edison.ErrEHSClusterNotFound) {
 		tflog.Info(ctx, "EHS Cluster Read: "+err.Error())
@@ -169,6 +241,11 @@ func (e ehsclusterResource) Read(ctx context.Context, req tfsdk.ReadResourceRequ
 		resp.State.RemoveResource(ctx)
 		return
 	}
+	if err == nil {
+		if diag, ok := releaseDiagnostic(ctx, e.client, ehscluster.Release, false); ok {
+			resp.Diagnostics = append(resp.Diagnostics, diag)
+		}
+	}
 
 	err = resp.State.Set(ctx, &ehsclusterData{
 		ID:                types.String{Value: ehscluster.ID},
@@ -177,11 +254,17 @@ func (e ehsclusterResource) Read(ctx context.Context, req tfsdk.ReadResourceRequ
 		Release:           types.String{Value: ehscluster.Release},
 		VPC:               types.String{Value: ehscluster.VPC},
 		ClusterName:       types.String{Value: ehscluster.ClusterName},
+		AccountID:         types.String{Value: ehscluster.AccountID, Null: ehscluster.AccountID == ""},
 		Tag:               types.String{Value: ehscluster.Tag},
 		APIServerEndPoint: types.String{Value: ehscluster.APIServerEndPoint},
+		Autoscaling:       objectFromAutoscaling(ehscluster.Autoscaling),
+		CurrentNodes:      numberFromInt(ehscluster.CurrentNodes),
+		DesiredNodes:      numberFromInt(ehscluster.DesiredNodes),
 		//DicomEndPoint:     types.String{Value: ehscluster.DicomEndPoint},
-		CreatedAt: types.String{Value: ehscluster.CreatedAt},
-		UpdatedAt: types.String{Value: ehscluster.UpdatedAt},
+		Labels:          mapFromLabels(configuredLabels(ehscluster.Labels, e.defaultLabels, labelsFromMap(labels.(types.Map)))),
+		EffectiveLabels: mapFromLabels(ehscluster.Labels),
+		CreatedAt:       types.String{Value: ehscluster.CreatedAt},
+		UpdatedAt:       types.String{Value: ehscluster.UpdatedAt},
 	})
 
 	if err != nil {
@@ -207,7 +290,18 @@ func (e ehsclusterResource) Update(ctx context.Context, req tfsdk.UpdateResource
 	now := time.Now()
 	var updatedAt string = now.Format("2006-01-02 15:04:05")
 
-	_, err = e.client.EHSClusters.Update(ctx, edison.EHSCluster{
+	prior, err := e.client.EHSClusters.Get(ctx, id.(types.String).Value)
+	if err != nil {
+		tflog.Info(ctx, "EHS Cluster Update: "+err.Error())
+	}
+	if diag, ok := releaseDiagnostic(ctx, e.client, ehscluster.Release.Value, ehscluster.Release.Value != prior.Release); ok {
+		resp.Diagnostics = append(resp.Diagnostics, diag)
+		if diag.Severity == tfprotov6.DiagnosticSeverityError {
+			return
+		}
+	}
+
+	op, err := e.client.EHSClusters.StartUpdate(ctx, edison.EHSCluster{
 		ID:                id.(types.String).Value,
 		Profile:           ehscluster.Profile.Value,
 		Region:            ehscluster.Region.Value,
@@ -215,15 +309,60 @@ func (e ehsclusterResource) Update(ctx context.Context, req tfsdk.UpdateResource
 		VPC:               ehscluster.VPC.Value,
 		Tag:               ehscluster.Tag.Value,
 		ClusterName:       ehscluster.ClusterName.Value,
+		AccountID:         ehscluster.AccountID.Value,
 		APIServerEndPoint: ehscluster.APIServerEndPoint.Value,
+		Autoscaling:       autoscalingFromObject(ehscluster.Autoscaling),
 		//DicomEndPoint:     ehscluster.DicomEndPoint.Value,
+		Labels:    mergeLabels(e.defaultLabels, labelsFromMap(ehscluster.Labels)),
 		CreatedAt: ehscluster.CreatedAt.Value,
 		UpdatedAt: updatedAt,
 	})
+	if err == nil {
+		_, err = waitForOperation(ctx, e.client, op)
+	}
+	var updated edison.EHSCluster
+	if err == nil {
+		updated, err = e.client.EHSClusters.Get(ctx, id.(types.String).Value)
+	}
+	if err == nil && updated.Status == edison.EHSClusterUpgrading {
+		err = waitForUpgrade(ctx, e.client, updated)
+		if err == nil {
+			updated, err = e.client.EHSClusters.Get(ctx, id.(types.String).Value)
+		}
+	}
+	if err == nil && updated.Status == edison.EHSClusterResizing {
+		_, err = waitForOperation(ctx, e.client, edison.Operation{ID: updated.OperationID, Status: edison.OperationRunning})
+		if err == nil {
+			updated, err = e.client.EHSClusters.Get(ctx, id.(types.String).Value)
+		}
+	}
 	if err != nil {
-		tflog.Info(ctx, "EHS Cluster Update: "+err.Error())
+		if diag, ok := quotaDiagnostic(err); ok {
+			resp.Diagnostics = append(resp.Diagnostics, diag)
+			return
+		}
+		if diag, ok := upgradeDiagnostic(err); ok {
+			resp.Diagnostics = append(resp.Diagnostics, diag)
+			return
+		}
+		if diag, ok := resizeDiagnostic(err); ok {
+			resp.Diagnostics = append(resp.Diagnostics, diag)
+			return
+		}
+		if diag, ok := autoscalingDiagnostic(err); ok {
+			resp.Diagnostics = append(resp.Diagnostics, diag)
+			return
+		}
+		resp.Diagnostics = append(resp.Diagnostics, operationDiagnostic("updating the EHS Cluster", err))
+		return
+	}
+	if diag, ok := capacityWarning(prior, updated); ok {
+		resp.Diagnostics = append(resp.Diagnostics, diag)
 	}
 	ehscluster.ID = id.(types.String)
+	ehscluster.CurrentNodes = numberFromInt(updated.CurrentNodes)
+	ehscluster.DesiredNodes = numberFromInt(updated.DesiredNodes)
+	ehscluster.EffectiveLabels = mapFromLabels(updated.Labels)
 
 	err = resp.State.Set(ctx, &ehscluster)
 	if err != nil {
@@ -239,9 +378,13 @@ func (e ehsclusterResource) Delete(ctx context.Context, req tfsdk.DeleteResource
 	if err != nil {
 		tflog.Info(ctx, "EHS Cluster Delete: "+err.Error())
 	}
-	err = e.client.EHSClusters.Delete(ctx, id.(types.String).Value)
+	op, err := e.client.EHSClusters.StartDelete(ctx, id.(types.String).Value)
+	if err == nil {
+		_, err = waitForOperation(ctx, e.client, op)
+	}
 	if err != nil && !errors.Is(err, edison.ErrEHSClusterNotFound) {
-		tflog.Info(ctx, "EHS Cluster Delete: "+err.Error())
+		resp.Diagnostics = append(resp.Diagnostics, operationDiagnostic("deleting the EHS Cluster", err))
+		return
 	}
 	resp.State.RemoveResource(ctx)
 }
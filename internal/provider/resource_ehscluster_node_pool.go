@@ -0,0 +1,372 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	edison "github.com/rahoolp/terraform-provider-edison/internal/client"
+)
+
+var taintAttrTypes = map[string]attr.Type{
+	"key":    types.StringType,
+	"value":  types.StringType,
+	"effect": types.StringType,
+}
+
+type nodePoolResourceType struct {
+}
+
+func (n nodePoolResourceType) GetSchema(_ context.Context) (schema.Schema, []*tfprotov6.Diagnostic) {
+	return schema.Schema{
+		Attributes: labelAttributes(map[string]schema.Attribute{
+			"id": {
+				Type:     types.StringType,
+				Computed: true,
+			},
+			"ehs_cluster_id": {
+				Type:     types.StringType,
+				Required: true,
+			},
+			"name": {
+				Type:     types.StringType,
+				Required: true,
+			},
+			"instance_size": {
+				Type:     types.StringType,
+				Required: true,
+			},
+			"min_count": {
+				Type:     types.NumberType,
+				Required: true,
+			},
+			"max_count": {
+				Type:     types.NumberType,
+				Required: true,
+			},
+			"desired_count": {
+				Type:     types.NumberType,
+				Required: true,
+			},
+			"taints": {
+				Type:     types.ListType{ElemType: types.ObjectType{AttrTypes: taintAttrTypes}},
+				Optional: true,
+			},
+			"current_count": {
+				Type:     types.NumberType,
+				Computed: true,
+			},
+			"created_at": {
+				Type:     types.StringType,
+				Computed: true,
+			},
+			"updated_at": {
+				Type:     types.StringType,
+				Computed: true,
+			},
+		}),
+	}, nil
+}
+
+type nodePoolData struct {
+	ID              types.String `tfsdk:"id"`
+	EHSClusterID    types.String `tfsdk:"ehs_cluster_id"`
+	Name            types.String `tfsdk:"name"`
+	InstanceSize    types.String `tfsdk:"instance_size"`
+	MinCount        int          `tfsdk:"min_count"`
+	MaxCount        int          `tfsdk:"max_count"`
+	DesiredCount    int          `tfsdk:"desired_count"`
+	Taints          types.List   `tfsdk:"taints"`
+	CurrentCount    types.Number `tfsdk:"current_count"`
+	Labels          types.Map    `tfsdk:"labels"`
+	EffectiveLabels types.Map    `tfsdk:"effective_labels"`
+	CreatedAt       types.String `tfsdk:"created_at"`
+	UpdatedAt       types.String `tfsdk:"updated_at"`
+}
+
+func (n nodePoolResourceType) NewResource(_ context.Context, p tfsdk.Provider) (tfsdk.Resource, []*tfprotov6.Diagnostic) {
+	prov, ok := p.(*provider)
+	if !ok {
+		return nil, []*tfprotov6.Diagnostic{
+			{
+				Severity: tfprotov6.DiagnosticSeverityError,
+				Summary:  "Error converting provider",
+				Detail:   fmt.Sprintf("An unexpected error was encountered converting the provider. This is always a bug in the provider.\n\nType: %T", p),
+			},
+		}
+	}
+	return nodePoolResource{client: prov.client, defaultLabels: prov.defaultLabels}, nil
+}
+
+type nodePoolResource struct {
+	client        *edison.Client
+	defaultLabels map[string]string
+}
+
+func taintsFromList(list types.List) []edison.Taint {
+	if list.Null || list.Unknown {
+		return nil
+	}
+	var results []edison.Taint
+	for _, elem := range list.Elems {
+		attrs := elem.(types.Object).Attrs
+		results = append(results, edison.Taint{
+			Key:    attrs["key"].(types.String).Value,
+			Value:  attrs["value"].(types.String).Value,
+			Effect: attrs["effect"].(types.String).Value,
+		})
+	}
+	return results
+}
+
+func listFromTaints(taints []edison.Taint) types.List {
+	list := types.List{ElemType: types.ObjectType{AttrTypes: taintAttrTypes}}
+	if len(taints) < 1 {
+		list.Null = true
+		return list
+	}
+	for _, taint := range taints {
+		list.Elems = append(list.Elems, types.Object{
+			AttrTypes: taintAttrTypes,
+			Attrs: map[string]attr.Value{
+				"key":    types.String{Value: taint.Key},
+				"value":  types.String{Value: taint.Value, Null: taint.Value == ""},
+				"effect": types.String{Value: taint.Effect},
+			},
+		})
+	}
+	return list
+}
+
+// waitForNodePool waits for the node pool to finish scaling, returning it
+// as it is afterwards.
+func waitForNodePool(ctx context.Context, client *edison.Client, pool edison.NodePool) (edison.NodePool, error) {
+	if pool.Status != edison.NodePoolScaling {
+		return pool, nil
+	}
+	_, err := waitForOperation(ctx, client, edison.Operation{ID: pool.OperationID, Status: edison.OperationRunning})
+	if err != nil {
+		return pool, err
+	}
+	return client.NodePools.Get(ctx, pool.EHSClusterID, pool.ID)
+}
+
+// nodePoolDiagnostic turns an error changing a node pool into an error
+// diagnostic, so the apply fails instead of recording a pool size the
+// cluster isn't running.
+func nodePoolDiagnostic(err error) (*tfprotov6.Diagnostic, bool) {
+	var failed edison.OperationFailedError
+	switch {
+	case errors.Is(err, edison.ErrInstanceSizeChanged):
+		return &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "Node pool instance size can't be changed",
+			Detail:   "A node pool's instance_size is set when it's created. Replace the node pool to use a different instance size.",
+		}, true
+	case errors.Is(err, edison.ErrNodePoolTooSmall):
+		return &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "Node pool is needed for AW seats",
+			Detail:   "The EHS Cluster hosts more AW seats than it would have room for without these nodes. Move or shrink its AWs first.",
+		}, true
+	case errors.Is(err, edison.ErrNodePoolBusy):
+		return &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "Node pool can't be changed right now",
+			Detail:   "The node pool is still scaling. Try again once it's ready.",
+		}, true
+	case errors.As(err, &failed) && failed.Operation.Kind == "nodepool.scale":
+		return &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "Node pool scaling didn't complete",
+			Detail:   failed.Error() + ". The node pool is still at its previous size.",
+		}, true
+	}
+	return nil, false
+}
+
+func (n nodePoolResource) Create(ctx context.Context, req tfsdk.CreateResourceRequest, resp *tfsdk.CreateResourceResponse) {
+
+	tflog.Info(ctx, "Node Pool Create..")
+
+	var data nodePoolData
+	err := req.Plan.Get(ctx, &data)
+	if err != nil {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "Error parsing plan",
+			Detail:   "An unexpected error was encountered parsing the plan. This is always a bug in the provider.\n\nDetails: " + err.Error(),
+		})
+		return
+	}
+
+	pool, err := n.client.NodePools.Create(ctx, edison.NodePool{
+		EHSClusterID: data.EHSClusterID.Value,
+		Name:         data.Name.Value,
+		InstanceSize: data.InstanceSize.Value,
+		MinCount:     data.MinCount,
+		MaxCount:     data.MaxCount,
+		DesiredCount: data.DesiredCount,
+		Taints:       taintsFromList(data.Taints),
+		Labels:       mergeLabels(n.defaultLabels, labelsFromMap(data.Labels)),
+	})
+	if err == nil {
+		pool, err = waitForNodePool(ctx, n.client, pool)
+	}
+	if err != nil {
+		if diag, ok := nodePoolDiagnostic(err); ok {
+			resp.Diagnostics = append(resp.Diagnostics, diag)
+			return
+		}
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "Error creating node pool",
+			Detail:   "An unexpected error was encountered creating the node pool.\n\nDetails: " + err.Error(),
+		})
+		return
+	}
+
+	data.ID = types.String{Value: pool.ID}
+	data.CurrentCount = types.Number{Value: big.NewFloat(float64(pool.CurrentCount))}
+	data.EffectiveLabels = mapFromLabels(pool.Labels)
+	data.CreatedAt = types.String{Value: pool.CreatedAt}
+	data.UpdatedAt = types.String{Value: pool.UpdatedAt}
+
+	err = resp.State.Set(ctx, &data)
+	if err != nil {
+		tflog.Info(ctx, "Node Pool Create: "+err.Error())
+	}
+}
+
+func (n nodePoolResource) Read(ctx context.Context, req tfsdk.ReadResourceRequest, resp *tfsdk.ReadResourceResponse) {
+
+	tflog.Info(ctx, "Node Pool Read..")
+
+	var data nodePoolData
+	err := req.State.Get(ctx, &data)
+	if err != nil {
+		tflog.Info(ctx, "Node Pool Read: "+err.Error())
+		return
+	}
+
+	pool, err := n.client.NodePools.Get(ctx, data.EHSClusterID.Value, data.ID.Value)
+	if errors.Is(err, edison.ErrNodePoolNotFound) || errors.Is(err, edison.ErrEHSClusterNotFound) {
+		resp.State.RemoveResource(ctx)
+		return
+	} else if err != nil {
+		tflog.Info(ctx, "Node Pool Read: "+err.Error())
+		return
+	}
+
+	data.Name = types.String{Value: pool.Name}
+	data.InstanceSize = types.String{Value: pool.InstanceSize}
+	data.MinCount = pool.MinCount
+	data.MaxCount = pool.MaxCount
+	data.DesiredCount = pool.DesiredCount
+	data.Taints = listFromTaints(pool.Taints)
+	data.CurrentCount = types.Number{Value: big.NewFloat(float64(pool.CurrentCount))}
+	data.Labels = mapFromLabels(configuredLabels(pool.Labels, n.defaultLabels, labelsFromMap(data.Labels)))
+	data.EffectiveLabels = mapFromLabels(pool.Labels)
+	data.CreatedAt = types.String{Value: pool.CreatedAt}
+	data.UpdatedAt = types.String{Value: pool.UpdatedAt}
+
+	err = resp.State.Set(ctx, &data)
+	if err != nil {
+		tflog.Info(ctx, "Node Pool Read: "+err.Error())
+	}
+}
+
+func (n nodePoolResource) Update(ctx context.Context, req tfsdk.UpdateResourceRequest, resp *tfsdk.UpdateResourceResponse) {
+
+	tflog.Info(ctx, "Node Pool Update..")
+
+	var prior nodePoolData
+	err := req.State.Get(ctx, &prior)
+	if err != nil {
+		tflog.Info(ctx, "Node Pool Update: "+err.Error())
+	}
+
+	var data nodePoolData
+	err = req.Plan.Get(ctx, &data)
+	if err != nil {
+		tflog.Info(ctx, "Node Pool Update: "+err.Error())
+	}
+
+	if data.EHSClusterID.Value != prior.EHSClusterID.Value {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "Node pool can't be moved",
+			Detail:   "A node pool's ehs_cluster_id is set when it's created. Replace the node pool to add it to a different EHS Cluster.",
+		})
+		return
+	}
+
+	pool, err := n.client.NodePools.Update(ctx, edison.NodePool{
+		ID:           prior.ID.Value,
+		EHSClusterID: prior.EHSClusterID.Value,
+		Name:         data.Name.Value,
+		InstanceSize: data.InstanceSize.Value,
+		MinCount:     data.MinCount,
+		MaxCount:     data.MaxCount,
+		DesiredCount: data.DesiredCount,
+		Taints:       taintsFromList(data.Taints),
+		Labels:       mergeLabels(n.defaultLabels, labelsFromMap(data.Labels)),
+	})
+	if err == nil {
+		pool, err = waitForNodePool(ctx, n.client, pool)
+	}
+	if err != nil {
+		if diag, ok := nodePoolDiagnostic(err); ok {
+			resp.Diagnostics = append(resp.Diagnostics, diag)
+			return
+		}
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "Error updating node pool",
+			Detail:   "An unexpected error was encountered updating the node pool.\n\nDetails: " + err.Error(),
+		})
+		return
+	}
+
+	data.ID = prior.ID
+	data.CurrentCount = types.Number{Value: big.NewFloat(float64(pool.CurrentCount))}
+	data.EffectiveLabels = mapFromLabels(pool.Labels)
+	data.CreatedAt = types.String{Value: pool.CreatedAt}
+	data.UpdatedAt = types.String{Value: pool.UpdatedAt}
+
+	err = resp.State.Set(ctx, &data)
+	if err != nil {
+		tflog.Info(ctx, "Node Pool Update: "+err.Error())
+	}
+}
+
+func (n nodePoolResource) Delete(ctx context.Context, req tfsdk.DeleteResourceRequest, resp *tfsdk.DeleteResourceResponse) {
+
+	tflog.Info(ctx, "Node Pool Delete..")
+
+	id, err := req.State.GetAttribute(ctx, tftypes.NewAttributePath().WithAttributeName("id"))
+	if err != nil {
+		tflog.Info(ctx, "Node Pool Delete: "+err.Error())
+	}
+	clusterID, err := req.State.GetAttribute(ctx, tftypes.NewAttributePath().WithAttributeName("ehs_cluster_id"))
+	if err != nil {
+		tflog.Info(ctx, "Node Pool Delete: "+err.Error())
+	}
+	err = n.client.NodePools.Delete(ctx, clusterID.(types.String).Value, id.(types.String).Value)
+	if diag, ok := nodePoolDiagnostic(err); ok {
+		resp.Diagnostics = append(resp.Diagnostics, diag)
+		return
+	}
+	if err != nil && !errors.Is(err, edison.ErrNodePoolNotFound) && !errors.Is(err, edison.ErrEHSClusterNotFound) {
+		tflog.Info(ctx, "Node Pool Delete: "+err.Error())
+	}
+	resp.State.RemoveResource(ctx)
+}
@@ -0,0 +1,232 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	edison "github.com/rahoolp/terraform-provider-edison/internal/client"
+)
+
+type webhookResourceType struct {
+}
+
+func (w webhookResourceType) GetSchema(_ context.Context) (schema.Schema, []*tfprotov6.Diagnostic) {
+	return schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"id": {
+				Type:     types.StringType,
+				Computed: true,
+			},
+			"url": {
+				Type:     types.StringType,
+				Required: true,
+			},
+			"events": {
+				Type:     types.ListType{ElemType: types.StringType},
+				Optional: true,
+			},
+			"resource_types": {
+				Type:     types.ListType{ElemType: types.StringType},
+				Optional: true,
+			},
+			"secret": {
+				Type:      types.StringType,
+				Required:  true,
+				Sensitive: true,
+			},
+			"created_at": {
+				Type:     types.StringType,
+				Computed: true,
+			},
+			"updated_at": {
+				Type:     types.StringType,
+				Computed: true,
+			},
+		},
+	}, nil
+}
+
+type webhookData struct {
+	ID            types.String `tfsdk:"id"`
+	URL           types.String `tfsdk:"url"`
+	Events        types.List   `tfsdk:"events"`
+	ResourceTypes types.List   `tfsdk:"resource_types"`
+	Secret        types.String `tfsdk:"secret"`
+	CreatedAt     types.String `tfsdk:"created_at"`
+	UpdatedAt     types.String `tfsdk:"updated_at"`
+}
+
+func (w webhookResourceType) NewResource(_ context.Context, p tfsdk.Provider) (tfsdk.Resource, []*tfprotov6.Diagnostic) {
+	prov, ok := p.(*provider)
+	if !ok {
+		return nil, []*tfprotov6.Diagnostic{
+			{
+				Severity: tfprotov6.DiagnosticSeverityError,
+				Summary:  "Error converting provider",
+				Detail:   fmt.Sprintf("An unexpected error was encountered converting the provider. This is always a bug in the provider.\n\nType: %T", p),
+			},
+		}
+	}
+	return webhookResource{client: prov.client}, nil
+}
+
+type webhookResource struct {
+	client *edison.Client
+}
+
+func stringsFromList(list types.List) []string {
+	if list.Null || list.Unknown {
+		return nil
+	}
+	var results []string
+	for _, elem := range list.Elems {
+		results = append(results, elem.(types.String).Value)
+	}
+	return results
+}
+
+func listFromStrings(strs []string) types.List {
+	list := types.List{ElemType: types.StringType}
+	if len(strs) < 1 {
+		list.Null = true
+		return list
+	}
+	for _, s := range strs {
+		list.Elems = append(list.Elems, types.String{Value: s})
+	}
+	return list
+}
+
+func (w webhookResource) Create(ctx context.Context, req tfsdk.CreateResourceRequest, resp *tfsdk.CreateResourceResponse) {
+
+	tflog.Info(ctx, "Webhook Create..")
+
+	var hook webhookData
+	err := req.Plan.Get(ctx, &hook)
+	if err != nil {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "Error parsing plan",
+			Detail:   "An unexpected error was encountered parsing the plan. This is always a bug in the provider.\n\nDetails: " + err.Error(),
+		})
+		return
+	}
+
+	webhook, err := w.client.Webhooks.Create(ctx, edison.Webhook{
+		URL:           hook.URL.Value,
+		Events:        stringsFromList(hook.Events),
+		ResourceTypes: stringsFromList(hook.ResourceTypes),
+		Secret:        hook.Secret.Value,
+	})
+	if err != nil {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "Error creating webhook",
+			Detail:   "An unexpected error was encountered creating the webhook.\n\nDetails: " + err.Error(),
+		})
+		return
+	}
+
+	hook.ID = types.String{Value: webhook.ID}
+	hook.CreatedAt = types.String{Value: webhook.CreatedAt}
+	hook.UpdatedAt = types.String{Value: webhook.UpdatedAt}
+
+	err = resp.State.Set(ctx, &hook)
+	if err != nil {
+		tflog.Info(ctx, "Webhook Create: "+err.Error())
+	}
+}
+
+func (w webhookResource) Read(ctx context.Context, req tfsdk.ReadResourceRequest, resp *tfsdk.ReadResourceResponse) {
+
+	tflog.Info(ctx, "Webhook Read..")
+
+	var hook webhookData
+	err := req.State.Get(ctx, &hook)
+	if err != nil {
+		tflog.Info(ctx, "Webhook Read: "+err.Error())
+		return
+	}
+
+	webhook, err := w.client.Webhooks.Get(ctx, hook.ID.Value)
+	if errors.Is(err, edison.ErrWebhookNotFound) {
+		resp.State.RemoveResource(ctx)
+		return
+	} else if err != nil {
+		tflog.Info(ctx, "Webhook Read: "+err.Error())
+		return
+	}
+
+	hook.URL = types.String{Value: webhook.URL}
+	hook.Events = listFromStrings(webhook.Events)
+	hook.ResourceTypes = listFromStrings(webhook.ResourceTypes)
+	hook.CreatedAt = types.String{Value: webhook.CreatedAt}
+	hook.UpdatedAt = types.String{Value: webhook.UpdatedAt}
+
+	err = resp.State.Set(ctx, &hook)
+	if err != nil {
+		tflog.Info(ctx, "Webhook Read: "+err.Error())
+	}
+}
+
+func (w webhookResource) Update(ctx context.Context, req tfsdk.UpdateResourceRequest, resp *tfsdk.UpdateResourceResponse) {
+
+	tflog.Info(ctx, "Webhook Update..")
+
+	id, err := req.State.GetAttribute(ctx, tftypes.NewAttributePath().WithAttributeName("id"))
+	if err != nil {
+		tflog.Info(ctx, "Webhook Update: "+err.Error())
+	}
+
+	var hook webhookData
+	err = req.Plan.Get(ctx, &hook)
+	if err != nil {
+		tflog.Info(ctx, "Webhook Update: "+err.Error())
+	}
+
+	webhook, err := w.client.Webhooks.Update(ctx, edison.Webhook{
+		ID:            id.(types.String).Value,
+		URL:           hook.URL.Value,
+		Events:        stringsFromList(hook.Events),
+		ResourceTypes: stringsFromList(hook.ResourceTypes),
+		Secret:        hook.Secret.Value,
+	})
+	if err != nil {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "Error updating webhook",
+			Detail:   "An unexpected error was encountered updating the webhook.\n\nDetails: " + err.Error(),
+		})
+		return
+	}
+	hook.ID = id.(types.String)
+	hook.CreatedAt = types.String{Value: webhook.CreatedAt}
+	hook.UpdatedAt = types.String{Value: webhook.UpdatedAt}
+
+	err = resp.State.Set(ctx, &hook)
+	if err != nil {
+		tflog.Info(ctx, "Webhook Update: "+err.Error())
+	}
+}
+
+func (w webhookResource) Delete(ctx context.Context, req tfsdk.DeleteResourceRequest, resp *tfsdk.DeleteResourceResponse) {
+
+	tflog.Info(ctx, "Webhook Delete..")
+
+	id, err := req.State.GetAttribute(ctx, tftypes.NewAttributePath().WithAttributeName("id"))
+	if err != nil {
+		tflog.Info(ctx, "Webhook Delete: "+err.Error())
+	}
+	err = w.client.Webhooks.Delete(ctx, id.(types.String).Value)
+	if err != nil && !errors.Is(err, edison.ErrWebhookNotFound) {
+		tflog.Info(ctx, "Webhook Delete: "+err.Error())
+	}
+	resp.State.RemoveResource(ctx)
+}
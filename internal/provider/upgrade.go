@@ -0,0 +1,36 @@
+package provider
+
+import (
+	"errors"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	edison "github.com/rahoolp/terraform-provider-edison/internal/client"
+)
+
+// upgradeDiagnostic turns an error changing an EHS Cluster's release into
+// an error diagnostic, so the apply fails instead of recording a release
+// the cluster isn't running.
+func upgradeDiagnostic(err error) (*tfprotov6.Diagnostic, bool) {
+	var failed edison.UpgradeFailedError
+	switch {
+	case errors.As(err, &failed):
+		return &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "EHS Cluster upgrade didn't complete",
+			Detail:   failed.Error() + ". The cluster is still on " + failed.Upgrade.FromRelease + ".",
+		}, true
+	case errors.Is(err, edison.ErrInvalidUpgradePath):
+		return &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "Invalid EHS Cluster upgrade",
+			Detail:   "Releases can only be upgraded to a newer release in the catalog, without skipping a major version.",
+		}, true
+	case errors.Is(err, edison.ErrEHSClusterBusy):
+		return &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "EHS Cluster can't be changed right now",
+			Detail:   err.Error() + ". Try again once it's finished.",
+		}, true
+	}
+	return nil, false
+}
@@ -0,0 +1,57 @@
+package provider
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	edison "github.com/rahoolp/terraform-provider-edison/internal/client"
+)
+
+// checkAPIVersion compares the API versions the server serves with the one
+// the client speaks, pointing the client at an older version if that's all
+// the server has. Mismatches are warnings, so an out of date provider or
+// server doesn't stop anyone working.
+func checkAPIVersion(ctx context.Context, client *edison.Client) []*tfprotov6.Diagnostic {
+	warning := func(summary, detail string) []*tfprotov6.Diagnostic {
+		return []*tfprotov6.Diagnostic{{
+			Severity: tfprotov6.DiagnosticSeverityWarning,
+			Summary:  summary,
+			Detail:   detail,
+		}}
+	}
+	caps, err := client.Capabilities(ctx)
+	if err == edison.ErrCapabilitiesNotFound {
+		client.SetAPIVersion("")
+		return warning("edisond is older than this provider",
+			"The server predates API versioning, so the provider is using its unversioned routes. Anything added to the API since may not work. Upgrade edisond to API version "+edison.APIVersion+".")
+	}
+	if err != nil {
+		return warning("Error checking edisond's capabilities",
+			"The provider couldn't check which API versions the server supports, and will assume it supports "+edison.APIVersion+".\n\nDetails: "+err.Error())
+	}
+	newest := caps.Newest()
+	v, ok := caps.Version(edison.APIVersion)
+	if !ok {
+		if edison.CompareVersions(newest, edison.APIVersion) < 0 {
+			latest, _ := caps.Version(newest)
+			client.SetAPIVersion(strings.Trim(latest.Path, "/"))
+			return warning("edisond is older than this provider",
+				"The server's newest API version is "+newest+", but this provider uses "+edison.APIVersion+". Anything added to the API since may not work. Upgrade edisond.")
+		}
+		return warning("edisond is newer than this provider",
+			"The server no longer serves API version "+edison.APIVersion+", which this provider uses, so requests will fail. Upgrade the provider to one that supports "+newest+".")
+	}
+	var diags []*tfprotov6.Diagnostic
+	if v.DeprecatedAt != "" {
+		detail := "API version " + edison.APIVersion + ", which this provider uses, was deprecated at " + v.DeprecatedAt + "."
+		if v.SunsetAt != "" {
+			detail += " The server will stop serving it at " + v.SunsetAt + "."
+		}
+		diags = append(diags, warning("edisond API version "+edison.APIVersion+" is deprecated", detail+" Upgrade the provider to one that supports "+newest+".")...)
+	} else if edison.CompareVersions(newest, edison.APIVersion) > 0 {
+		diags = append(diags, warning("edisond is newer than this provider",
+			"The server supports API version "+newest+", but this provider uses "+edison.APIVersion+". Upgrade the provider to use newer features.")...)
+	}
+	return diags
+}
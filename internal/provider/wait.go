@@ -0,0 +1,111 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	edison "github.com/rahoolp/terraform-provider-edison/internal/client"
+)
+
+const (
+	// waitPollTimeout is how long each blocking query in a wait can take.
+	waitPollTimeout = 30 * time.Second
+	waitTimeout     = 10 * time.Minute
+)
+
+// waitFor calls poll, passing along the index each call returns, until poll
+// reports it's done. poll should make a blocking query, so each call only
+// returns once the resource has changed. While the resource isn't visible
+// yet, poll is retried with backoff instead.
+func waitFor(ctx context.Context, notFound error, poll func(index uint64) (uint64, bool, error)) error {
+	ctx, cancel := context.WithTimeout(ctx, waitTimeout)
+	defer cancel()
+	var index uint64
+	backoff := 100 * time.Millisecond
+	for {
+		next, done, err := poll(index)
+		if errors.Is(err, notFound) {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			if backoff < 5*time.Second {
+				backoff *= 2
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+		index = next
+	}
+}
+
+// waitForOperation waits for op to finish. Operations the server finished
+// before responding are returned as they are.
+func waitForOperation(ctx context.Context, client *edison.Client, op edison.Operation) (edison.Operation, error) {
+	if op.Done() {
+		return op, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, waitTimeout)
+	defer cancel()
+	return client.Operations.Wait(ctx, op.ID)
+}
+
+// waitForUpgrade waits for the operation tracking a cluster's rolling
+// upgrade to finish. If the upgrade didn't succeed, it returns an
+// UpgradeFailedError, which says whether it was rolled back.
+func waitForUpgrade(ctx context.Context, client *edison.Client, cluster edison.EHSCluster) error {
+	_, err := waitForOperation(ctx, client, edison.Operation{ID: cluster.OperationID, Status: edison.OperationRunning})
+	var failed edison.OperationFailedError
+	if !errors.As(err, &failed) {
+		return err
+	}
+	up, getErr := client.Upgrades.Get(ctx, cluster.UpgradeID)
+	if getErr != nil {
+		return err
+	}
+	return edison.UpgradeFailedError{Upgrade: up}
+}
+
+// waitForMigration waits for the operation tracking an AW's migration to
+// finish. If the migration didn't succeed, it returns a
+// MigrationFailedError, which says the step it failed at.
+func waitForMigration(ctx context.Context, client *edison.Client, aw edison.AW) error {
+	_, err := waitForOperation(ctx, client, edison.Operation{ID: aw.OperationID, Status: edison.OperationRunning})
+	var failed edison.OperationFailedError
+	if !errors.As(err, &failed) {
+		return err
+	}
+	mig, getErr := client.Migrations.Get(ctx, aw.MigrationID)
+	if getErr != nil {
+		return err
+	}
+	return edison.MigrationFailedError{Migration: mig}
+}
+
+// operationDiagnostic turns an error starting or waiting for a change that
+// runs as an operation into an error diagnostic. action describes the
+// change, like "creating the AW". Once a change runs as an operation, an
+// error means it failed, was cancelled or is still running, so the apply
+// has to fail instead of recording the plan.
+func operationDiagnostic(action string, err error) *tfprotov6.Diagnostic {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "Timed out " + action,
+			Detail:   "The change didn't finish within " + waitTimeout.String() + ". It may still be running; refresh to see the result.\n\nDetails: " + err.Error(),
+		}
+	}
+	return &tfprotov6.Diagnostic{
+		Severity: tfprotov6.DiagnosticSeverityError,
+		Summary:  "Error " + action,
+		Detail:   "An unexpected error was encountered " + action + ".\n\nDetails: " + err.Error(),
+	}
+}